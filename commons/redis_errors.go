@@ -21,3 +21,11 @@ func UnknownCommandErr(cmd string, args []string) error {
 	}
 	return fmt.Errorf("ERR unknown command '%s', with args beginning with: %s", cmd, argStr)
 }
+
+func UnknownSubcommandErr(cmd string, subcommand string) error {
+	return fmt.Errorf("ERR unknown subcommand '%s'. Try %s HELP.", subcommand, strings.ToUpper(cmd))
+}
+
+func WrongTypeErr() error {
+	return errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+}
@@ -1,16 +1,61 @@
 package config
 
+import "time"
+
 var Host string = "localhost"
 var Port int = 7379
 
+// space separated IPv4 and IPv6 addresses the server listens on, eg. "127.0.0.1 ::1". empty
+// listens on Host only.
+var Bind string = ""
+
+// path of the unix socket the server listens on, besides the TCP ports. empty disables it.
+var UnixSocket string = ""
+
+// permissions of the unix socket file, eg. 0700. 0 leaves them to the umask.
+var UnixSocketPerm uint32 = 0
+
 // logs the raw request body if set to true.
 var LogRequest bool = false
 
+// path of the file the pid of the server is written to while it runs. empty if it isn't written.
+var Pidfile string = ""
+
+// how long a shutdown waits for the replies of the clients to be written before the server exits,
+// unless it's asked for with SHUTDOWN NOW.
+var ShutdownTimeout time.Duration = 10 * time.Second
+
+// the network backend serving the connections: "epoll", on Linux only, or "net", which serves
+// each connection with goroutines of its own and runs anywhere. empty picks epoll on Linux.
+var NetBackend string = ""
+
+// number of goroutines reading the commands from the sockets and writing the replies to them,
+// including the one running the commands. the commands always run one at a time.
+var IoThreads int = 1
+
 // storage config
 
+// number of logical databases, selected with SELECT.
+var Databases int = 16
+
 // maximum number of keys that can store in the store before eviction kicks in
 var MaxKeys int = 100
 
+// lazy freeing config
+
+// whether the values of the evicted keys are freed in the background.
+var LazyfreeLazyEviction bool = false
+
+// whether the values of the expired keys are freed in the background.
+var LazyfreeLazyExpire bool = false
+
+// whether the values deleted as a side effect of a command, eg. the value overwritten by SET
+// or RENAME, are freed in the background.
+var LazyfreeLazyServerDel bool = false
+
+// whether DEL frees the values in the background, like UNLINK does.
+var LazyfreeLazyUserDel bool = false
+
 // eviction policy config parameters
 
 // defines the maximum resolution for the Least Recently Used (LRU) cache eviction policy.
@@ -21,3 +66,92 @@ var EvictionRatio float32 = 0.20
 
 // number of keys to sample while selecting the best candidate for removal.
 var LRUEvictionSampleSize int = 5
+
+// monitoring config
+
+// events that take at least this long, such as slow commands and expire cycles, are recorded
+// by the latency monitor, see LATENCY. 0 disables the monitor.
+var LatencyMonitorThreshold time.Duration = 0
+
+// commands that run for longer than this many microseconds are recorded in the slow log,
+// see SLOWLOG. 0 records every command, and a negative value disables the slow log.
+var SlowlogLogSlowerThan int64 = 10000
+
+// maximum number of entries kept in the slow log. the oldest entries are dropped first.
+var SlowlogMaxLen int = 128
+
+// port of the HTTP listener serving the metrics of the server on /metrics, in the Prometheus
+// text format. 0 disables the listener.
+var MetricsPort int = 0
+
+// pub/sub config
+
+// classes of keyspace events that get published, in the format of Redis's notify-keyspace-events
+// setting (eg. "KEx" for expired events on both keyspace and keyevent channels). empty disables them.
+var NotifyKeyspaceEvents string = ""
+
+// scripting config
+
+// how long a script can run before the server starts replying BUSY to the other clients,
+// and accepting SCRIPT KILL to stop the script.
+var BusyReplyThreshold time.Duration = 5 * time.Second
+
+// security config
+
+// password of the default user. empty lets the clients run commands without authenticating,
+// unless the ACL file says otherwise.
+var RequirePass string = ""
+
+// path of the file the ACL users are loaded from at startup, and loaded from and saved to with
+// ACL LOAD and ACL SAVE. empty if the users aren't kept in a file.
+var AclFile string = ""
+
+// maximum number of entries kept in the ACL log. the oldest entries are dropped first.
+var AclLogMaxLen int = 128
+
+// tls config
+
+// port of the listener that only accepts TLS connections. 0 disables it. the plaintext listener
+// can be disabled by setting Port to 0.
+var TlsPort int = 0
+
+// paths of the PEM encoded certificate of the server and its private key.
+var TlsCertFile string = ""
+var TlsKeyFile string = ""
+
+// path of the PEM encoded certificates of the CAs the certificates of the clients are verified with.
+var TlsCaCertFile string = ""
+
+// whether the clients have to present a certificate: "yes", "optional" (verified if presented) or "no".
+var TlsAuthClients string = "yes"
+
+// space separated TLS versions the server accepts, eg. "TLSv1.2 TLSv1.3". empty accepts TLSv1.2 and up.
+var TlsProtocols string = ""
+
+// colon separated cipher suites the server accepts for TLSv1.2 and below, by their IANA names, eg.
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". empty accepts the default ones. the TLSv1.3 suites can't
+// be configured.
+var TlsCiphers string = ""
+
+// client config
+
+// maximum number of clients connected at once. the clients connecting past it are told so and
+// disconnected. lowered at startup if the open files limit of the process can't fit them.
+var MaxClients int = 10000
+
+// clients idle for this many seconds are disconnected, except for the subscribers and the clients
+// waiting for their commands to run. 0 never disconnects them.
+var Timeout int = 0
+
+// interval in seconds of the TCP keepalive probes sent to the clients, which detect the peers that
+// went away without closing their connections. 0 disables the probes.
+var TcpKeepalive int = 300
+
+// maximum number of keys remembered for the clients tracking the keys they read, see CLIENT
+// TRACKING. the clients are told to drop some of their keys once there are more. 0 is unlimited.
+var TrackingTableMaxKeys int = 1000000
+
+// maximum number of bytes that can pile up in a client's output buffer, eg. when a
+// slow subscriber can't keep up with the published messages. the client is disconnected
+// once the limit is crossed.
+var ClientOutputBufferLimit int = 32 * 1024 * 1024
@@ -0,0 +1,207 @@
+package acl_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+)
+
+func TestAcl(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ACL Suite")
+}
+
+var _ = Describe("User", func() {
+	var u *acl.User
+
+	BeforeEach(func() {
+		u = acl.NewUser("alice")
+	})
+
+	It("should be disabled and allowed nothing until rules are set", func() {
+		Expect(u.Enabled()).To(BeFalse())
+		Expect(u.CanRunCommand("get", "", []string{acl.CategoryRead})).To(BeFalse())
+		Expect(u.CanAccessKey("key", true, false)).To(BeFalse())
+		Expect(u.String()).To(Equal("user alice off resetchannels -@all"))
+	})
+
+	It("should apply the command rules in order", func() {
+		Expect(u.SetRules("+@read", "-ttl", "+client|id")).To(Succeed())
+
+		Expect(u.CanRunCommand("get", "", []string{acl.CategoryRead})).To(BeTrue())
+		Expect(u.CanRunCommand("ttl", "", []string{acl.CategoryRead})).To(BeFalse())
+		Expect(u.CanRunCommand("set", "", []string{acl.CategoryWrite})).To(BeFalse())
+		Expect(u.CanRunCommand("client", "id", nil)).To(BeTrue())
+		Expect(u.CanRunCommand("client", "kill", nil)).To(BeFalse())
+		Expect(u.CommandRules()).To(Equal("-@all +@read -ttl +client|id"))
+
+		Expect(u.SetRules("allcommands", "-client")).To(Succeed())
+		Expect(u.CanRunCommand("client", "id", nil)).To(BeFalse())
+		Expect(u.CommandRules()).To(Equal("+@all -client"))
+	})
+
+	It("should check the keys against the read and write patterns", func() {
+		Expect(u.SetRules("~shared:*", "%R~config:*", "%W~log:*")).To(Succeed())
+
+		Expect(u.CanAccessKey("shared:1", true, true)).To(BeTrue())
+		Expect(u.CanAccessKey("config:1", true, false)).To(BeTrue())
+		Expect(u.CanAccessKey("config:1", false, true)).To(BeFalse())
+		Expect(u.CanAccessKey("log:1", false, true)).To(BeTrue())
+		Expect(u.CanAccessKey("log:1", true, true)).To(BeFalse())
+		Expect(u.CanAccessKey("other", false, false)).To(BeFalse())
+		Expect(u.KeyRules()).To(Equal("~shared:* %R~config:* %W~log:*"))
+	})
+
+	It("should match the channels, and the patterns of PSUBSCRIBE literally", func() {
+		Expect(u.SetRules("&news:*")).To(Succeed())
+
+		Expect(u.CanAccessChannel("news:sports", false)).To(BeTrue())
+		Expect(u.CanAccessChannel("news:*", true)).To(BeTrue())
+		Expect(u.CanAccessChannel("news:s*", true)).To(BeFalse())
+		Expect(u.CanAccessChannel("weather", false)).To(BeFalse())
+	})
+
+	It("should store the hashes of the passwords", func() {
+		Expect(u.SetRules("on", ">secret")).To(Succeed())
+		Expect(u.CheckPassword("secret")).To(BeTrue())
+		Expect(u.CheckPassword("wrong")).To(BeFalse())
+		Expect(u.Passwords()).To(ConsistOf(acl.HashPassword("secret")))
+
+		Expect(u.SetRules("<secret", "#"+acl.HashPassword("other"))).To(Succeed())
+		Expect(u.CheckPassword("secret")).To(BeFalse())
+		Expect(u.CheckPassword("other")).To(BeTrue())
+
+		Expect(u.SetRules("nopass")).To(Succeed())
+		Expect(u.CheckPassword("anything")).To(BeTrue())
+		Expect(u.Flags()).To(Equal([]string{"on", "nopass"}))
+	})
+
+	It("should apply either all the rules or none of them", func() {
+		err := u.SetRules("on", "+@read", "+@nonexistent")
+		Expect(err).To(MatchError("Error in ACL SETUSER modifier '+@nonexistent': Unknown command or category name in ACL"))
+		Expect(u.Enabled()).To(BeFalse())
+		Expect(u.CommandRules()).To(Equal("-@all"))
+
+		Expect(u.SetRules("#nothex")).To(MatchError(ContainSubstring("The password hash must be exactly 64 characters")))
+		Expect(u.SetRules("<missing")).To(MatchError(ContainSubstring("does not exist")))
+		Expect(u.SetRules("%X~key")).To(MatchError(ContainSubstring("Syntax error")))
+		Expect(u.SetRules("allkeys", "~key")).To(MatchError(ContainSubstring("Adding a pattern after the * pattern")))
+	})
+
+	It("should be reset to the state of a new user", func() {
+		Expect(u.SetRules("on", "nopass", "allkeys", "allchannels", "allcommands")).To(Succeed())
+		Expect(u.String()).To(Equal("user alice on nopass ~* &* +@all"))
+
+		Expect(u.SetRules("reset")).To(Succeed())
+		Expect(u.String()).To(Equal("user alice off resetchannels -@all"))
+	})
+})
+
+var _ = Describe("Users", func() {
+	var users *acl.Users
+
+	BeforeEach(func() {
+		users = acl.NewUsers()
+	})
+
+	It("should authenticate the clients as the enabled users with the right password", func() {
+		Expect(users.SetUser("alice", "on", ">secret")).To(Succeed())
+		c := client.NewClient(-1, nil)
+
+		Expect(users.Authenticate(c, "alice", "wrong")).To(MatchError(acl.ErrWrongPass))
+		Expect(users.Authenticate(c, "nobody", "secret")).To(MatchError(acl.ErrWrongPass))
+		Expect(users.Authenticate(c, "alice", "secret")).To(Succeed())
+		Expect(c.User).To(Equal("alice"))
+		Expect(c.Authenticated).To(BeTrue())
+
+		Expect(users.SetUser("alice", "off")).To(Succeed())
+		Expect(users.Authenticate(c, "alice", "secret")).To(MatchError(acl.ErrWrongPass))
+	})
+
+	It("should require the clients to authenticate once the default user has a password", func() {
+		c := client.NewClient(-1, nil)
+		users.SetDefaultAuth(c)
+		Expect(users.AuthRequired(c)).To(BeFalse())
+
+		Expect(users.SetUser(acl.DefaultUser, ">secret")).To(Succeed())
+		other := client.NewClient(-1, nil)
+		users.SetDefaultAuth(other)
+
+		// the clients that connected before stay authenticated.
+		Expect(users.AuthRequired(c)).To(BeFalse())
+		Expect(users.AuthRequired(other)).To(BeTrue())
+	})
+
+	It("should disconnect the clients of the deleted users", func() {
+		users := acl.GetUsers()
+		Expect(users.SetUser("alice", "on", "nopass")).To(Succeed())
+		c := client.NewClient(-1, nil)
+		client.Register(c)
+		defer c.Release()
+		Expect(users.Authenticate(c, "alice", "")).To(Succeed())
+
+		Expect(users.DeleteUsers("alice", "nobody")).To(Equal(1))
+		Expect(c.IsKilled()).To(BeTrue())
+
+		_, err := users.DeleteUsers(acl.DefaultUser)
+		Expect(err).To(MatchError("ERR The 'default' user cannot be removed"))
+	})
+
+	It("should load the users from the ACL file and save them to it", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "users.acl")
+		Expect(os.WriteFile(path, []byte("user alice on nopass ~cache:* +get\n\nuser bob off\n"), 0644)).To(Succeed())
+
+		Expect(users.LoadFile(path)).To(Succeed())
+		Expect(users.GetUser("alice").String()).To(Equal("user alice on nopass ~cache:* resetchannels -@all +get"))
+		Expect(users.GetUser("bob")).ToNot(BeNil())
+		Expect(users.GetUser(acl.DefaultUser).String()).To(Equal("user default on nopass ~* &* +@all"))
+
+		Expect(users.SetUser("bob", "on")).To(Succeed())
+		Expect(users.SaveFile(path)).To(Succeed())
+		contents, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(
+			"user alice on nopass ~cache:* resetchannels -@all +get\n" +
+				"user bob on resetchannels -@all\n" +
+				"user default on nopass ~* &* +@all\n"))
+	})
+
+	It("should not change the users if the ACL file has an error", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "users.acl")
+		Expect(os.WriteFile(path, []byte("user alice on\nuser bob +@nonexistent\n"), 0644)).To(Succeed())
+
+		Expect(users.LoadFile(path)).To(MatchError(path + ":2: Error in applying operation '+@nonexistent': Unknown command or category name in ACL"))
+		Expect(users.GetUser("alice")).To(BeNil())
+	})
+
+	It("should keep the users of every instance apart", func() {
+		Expect(users.SetUser("alice", "on")).To(Succeed())
+
+		Expect(acl.NewUsers().GetUser("alice")).To(BeNil())
+		Expect(acl.GetUsers().GetUser("alice")).To(BeNil())
+	})
+})
+
+var _ = Describe("Log", func() {
+	It("should group the similar entries, newest first", func() {
+		log := acl.NewLog()
+		log.Record(acl.ReasonCommand, acl.ContextTopLevel, "get", "alice", "id=1")
+		log.Record(acl.ReasonKey, acl.ContextTopLevel, "key", "alice", "id=1")
+		log.Record(acl.ReasonCommand, acl.ContextTopLevel, "get", "alice", "id=2")
+
+		entries := log.Entries(10)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Object).To(Equal("get"))
+		Expect(entries[0].Count).To(BeEquivalentTo(2))
+		Expect(entries[0].ClientInfo).To(Equal("id=2"))
+		Expect(entries[1].Reason).To(Equal(acl.ReasonKey))
+
+		log.Reset()
+		Expect(log.Entries(10)).To(BeEmpty())
+	})
+})
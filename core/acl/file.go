@@ -0,0 +1,72 @@
+package acl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// replaces the users with the ones defined in the ACL file, one per line as in
+// user <name> [rules ...]. The default user keeps its default rules if the file doesn't define
+// it. Nothing changes if the file has any error, and the clients authenticated as the users
+// that no longer exist are disconnected otherwise.
+func (us *Users) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	loaded := make(map[string]*User)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] != "user" || len(fields) < 2 {
+			return fmt.Errorf("%s:%d: should start with user keyword", path, line)
+		}
+
+		name := fields[1]
+		if _, exists := loaded[name]; exists {
+			return fmt.Errorf("%s:%d: duplicate user '%s' found", path, line, name)
+		}
+
+		u := NewUser(name)
+		for _, rule := range fields[2:] {
+			if err := u.applyRule(rule); err != nil {
+				return fmt.Errorf("%s:%d: Error in applying operation '%s': %s", path, line, rule, err)
+			}
+		}
+		loaded[name] = u
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if _, exists := loaded[DefaultUser]; !exists {
+		loaded[DefaultUser] = newDefaultUser()
+	}
+
+	us.users = loaded
+	us.killOrphanedClients()
+	return nil
+}
+
+// writes the users to the ACL file. The file is replaced at once, so that it's never left half written.
+func (us *Users) SaveFile(path string) error {
+	var contents strings.Builder
+	for _, u := range us.List() {
+		contents.WriteString(u.String())
+		contents.WriteByte('\n')
+	}
+
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, []byte(contents.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
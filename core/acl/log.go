@@ -0,0 +1,120 @@
+package acl
+
+import (
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// reasons the commands of the users get denied, as reported by ACL LOG.
+const (
+	ReasonCommand = "command"
+	ReasonKey     = "key"
+	ReasonChannel = "channel"
+	ReasonAuth    = "auth"
+)
+
+// contexts the commands get denied in, as reported by ACL LOG.
+const (
+	ContextTopLevel = "toplevel"
+	ContextMulti    = "multi"
+	ContextLua      = "lua"
+)
+
+// the denials of the same kind that happen within this window of each other are grouped into
+// a single entry of the log, like Redis does.
+const logEntryGroupingWindow = 60 * time.Second
+
+// LogEntry records the commands that were denied, and the authentications that failed.
+type LogEntry struct {
+	// number of denials grouped into the entry.
+	Count int64
+
+	Reason  string
+	Context string
+
+	// the full name of the denied command, or the key or channel the user can't access.
+	Object string
+
+	Username string
+
+	// description of the client the denial last happened to, as reported by CLIENT INFO.
+	ClientInfo string
+
+	Id          int64
+	Created     time.Time
+	LastUpdated time.Time
+}
+
+// Log keeps the latest security events, as ACL LOG lists them. The newest entry comes first.
+type Log struct {
+	entries []*LogEntry
+	nextId  int64
+}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+var logInstance *Log
+
+// returns the ACL log of the server.
+func GetLog() *Log {
+	if logInstance == nil {
+		logInstance = NewLog()
+	}
+
+	return logInstance
+}
+
+// records the denial, or adds it to the entry of a similar denial that happened recently.
+func (l *Log) Record(reason string, context string, object string, username string, clientInfo string) {
+	now := time.Now()
+
+	for i, entry := range l.entries {
+		if entry.Reason != reason || entry.Context != context || entry.Object != object || entry.Username != username ||
+			now.Sub(entry.LastUpdated) > logEntryGroupingWindow {
+			continue
+		}
+
+		entry.Count++
+		entry.ClientInfo = clientInfo
+		entry.LastUpdated = now
+
+		// the updated entry becomes the newest.
+		copy(l.entries[1:i+1], l.entries[:i])
+		l.entries[0] = entry
+		return
+	}
+
+	entry := &LogEntry{
+		Count:       1,
+		Reason:      reason,
+		Context:     context,
+		Object:      object,
+		Username:    username,
+		ClientInfo:  clientInfo,
+		Id:          l.nextId,
+		Created:     now,
+		LastUpdated: now,
+	}
+	l.nextId++
+
+	l.entries = append([]*LogEntry{entry}, l.entries...)
+	if len(l.entries) > config.AclLogMaxLen {
+		l.entries = l.entries[:max(config.AclLogMaxLen, 0)]
+	}
+}
+
+// returns up to count of the newest entries, newest first.
+func (l *Log) Entries(count int) []*LogEntry {
+	if count > len(l.entries) {
+		count = len(l.entries)
+	}
+	return append([]*LogEntry(nil), l.entries[:count]...)
+}
+
+// removes all the entries of the log.
+func (l *Log) Reset() {
+	l.entries = nil
+}
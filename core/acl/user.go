@@ -0,0 +1,516 @@
+package acl
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// ACL categories of the commands, as listed by ACL CAT and allowed or denied with +@category and -@category.
+const (
+	CategoryKeyspace    = "keyspace"
+	CategoryRead        = "read"
+	CategoryWrite       = "write"
+	CategorySet         = "set"
+	CategorySortedSet   = "sortedset"
+	CategoryList        = "list"
+	CategoryHash        = "hash"
+	CategoryString      = "string"
+	CategoryBitmap      = "bitmap"
+	CategoryHyperLogLog = "hyperloglog"
+	CategoryGeo         = "geo"
+	CategoryStream      = "stream"
+	CategoryPubSub      = "pubsub"
+	CategoryAdmin       = "admin"
+	CategoryFast        = "fast"
+	CategorySlow        = "slow"
+	CategoryBlocking    = "blocking"
+	CategoryDangerous   = "dangerous"
+	CategoryConnection  = "connection"
+	CategoryTransaction = "transaction"
+	CategoryScripting   = "scripting"
+)
+
+// all the ACL categories, in the order ACL CAT lists them.
+var Categories = []string{
+	CategoryKeyspace, CategoryRead, CategoryWrite, CategorySet, CategorySortedSet, CategoryList,
+	CategoryHash, CategoryString, CategoryBitmap, CategoryHyperLogLog, CategoryGeo, CategoryStream,
+	CategoryPubSub, CategoryAdmin, CategoryFast, CategorySlow, CategoryBlocking, CategoryDangerous,
+	CategoryConnection, CategoryTransaction, CategoryScripting,
+}
+
+// returns true if the name is one of the ACL categories.
+func IsCategory(name string) bool {
+	for _, category := range Categories {
+		if category == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reports whether the command, or its subcommand if the subcommand isn't empty, exists. The names
+// are lowercase. It's set by the eval package, which defines the commands and depends on this one.
+var CommandExists = func(command string, subcommand string) bool {
+	return true
+}
+
+// a rule of the user allowing or denying commands. The rules are applied in order, so that
+// eg. +@all -flushall allows every command but FLUSHALL.
+type commandRule struct {
+	allow bool
+
+	// lowercase names of the command and its subcommand the rule applies to, or of the category it
+	// applies to. all of them are empty for the rule applying to all the commands.
+	command    string
+	subcommand string
+	category   string
+}
+
+// returns true if the rule applies to the command, which belongs to the categories.
+func (r commandRule) matches(command string, subcommand string, categories []string) bool {
+	switch {
+	case r.category != "":
+		for _, category := range categories {
+			if category == r.category {
+				return true
+			}
+		}
+		return false
+	case r.command == "":
+		return true
+	default:
+		return r.command == command && (r.subcommand == "" || r.subcommand == subcommand)
+	}
+}
+
+// returns the rule as it is written, eg. +@read or -client|kill.
+func (r commandRule) String() string {
+	sign := "-"
+	if r.allow {
+		sign = "+"
+	}
+
+	switch {
+	case r.category != "":
+		return sign + "@" + r.category
+	case r.command == "":
+		return sign + "@all"
+	case r.subcommand != "":
+		return sign + r.command + "|" + r.subcommand
+	default:
+		return sign + r.command
+	}
+}
+
+// a pattern of the keys the user can access, set with ~pattern for reading and writing the keys,
+// or with %R~pattern and %W~pattern for only reading or writing them.
+type keyPattern struct {
+	pattern string
+	read    bool
+	write   bool
+}
+
+// returns the pattern as it is written, eg. %R~cache:*.
+func (p keyPattern) String() string {
+	switch {
+	case p.read && p.write:
+		return "~" + p.pattern
+	case p.read:
+		return "%R~" + p.pattern
+	default:
+		return "%W~" + p.pattern
+	}
+}
+
+// User is an ACL user the clients authenticate as with AUTH. It is allowed to run the commands,
+// access the keys and use the channels its rules allow, and nothing else.
+type User struct {
+	Name string
+
+	// whether the clients can authenticate as the user.
+	enabled bool
+
+	// whether any password is accepted for the user.
+	nopass bool
+
+	// SHA-256 hashes of the passwords of the user, hex encoded.
+	passwords []string
+
+	commands []commandRule
+	keys     []keyPattern
+	channels []string
+}
+
+// returns a new user, which is disabled and can't run any command until rules are set for it.
+func NewUser(name string) *User {
+	return &User{Name: name}
+}
+
+// returns a copy of the user, which can be modified without affecting the user.
+func (u *User) clone() *User {
+	clone := *u
+	clone.passwords = append([]string(nil), u.passwords...)
+	clone.commands = append([]commandRule(nil), u.commands...)
+	clone.keys = append([]keyPattern(nil), u.keys...)
+	clone.channels = append([]string(nil), u.channels...)
+	return &clone
+}
+
+// returns true if the clients can authenticate as the user.
+func (u *User) Enabled() bool {
+	return u.enabled
+}
+
+// returns the hex encoded SHA-256 hash of the password, as the passwords are stored.
+func HashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
+}
+
+// returns true if the password is one of the passwords of the user.
+func (u *User) CheckPassword(password string) bool {
+	if u.nopass {
+		return true
+	}
+
+	hash := []byte(HashPassword(password))
+	for _, existing := range u.passwords {
+		if subtle.ConstantTimeCompare([]byte(existing), hash) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true if the rule adds or removes a password, or its hash, eg. >password.
+func IsPasswordRule(rule string) bool {
+	return rule != "" && strings.ContainsRune("><#!", rune(rule[0]))
+}
+
+// returns true if the user is allowed to run the command, or its subcommand if the subcommand
+// isn't empty. The command belongs to the categories. The names are lowercase.
+func (u *User) CanRunCommand(command string, subcommand string, categories []string) bool {
+	allowed := false
+	for _, rule := range u.commands {
+		if rule.matches(command, subcommand, categories) {
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// returns true if the user is allowed to access the key in the given ways.
+func (u *User) CanAccessKey(key string, read bool, write bool) bool {
+	for _, pattern := range u.keys {
+		if (read && !pattern.read) || (write && !pattern.write) {
+			continue
+		}
+		if pattern.pattern == "*" || utils.GlobMatch(pattern.pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true if the user is allowed to use the channel. If literal is set, the channel is a
+// pattern given to PSUBSCRIBE, which has to be one of the patterns of the user.
+func (u *User) CanAccessChannel(channel string, literal bool) bool {
+	for _, pattern := range u.channels {
+		if pattern == "*" || pattern == channel || (!literal && utils.GlobMatch(pattern, channel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// applies the rules to the user in order. Either all the rules are applied or none of them is,
+// in which case the error tells which rule is wrong and why.
+func (u *User) SetRules(rules ...string) error {
+	updated := u.clone()
+	for _, rule := range rules {
+		if err := updated.applyRule(rule); err != nil {
+			return &RuleError{Rule: rule, Reason: err}
+		}
+	}
+
+	*u = *updated
+	return nil
+}
+
+// RuleError is returned when a rule of a user can't be applied.
+type RuleError struct {
+	Rule   string
+	Reason error
+}
+
+func (e *RuleError) Error() string {
+	return "Error in ACL SETUSER modifier '" + e.Rule + "': " + e.Reason.Error()
+}
+
+var (
+	errSyntax            = errors.New("Syntax error")
+	errUnknownCommand    = errors.New("Unknown command or category name in ACL")
+	errInvalidHash       = errors.New("The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+	errNoSuchPassword    = errors.New("The password you are trying to remove from the user does not exist")
+	errKeyAfterAllKeys   = errors.New("Adding a pattern after the * pattern (or the 'allkeys' flag) is not valid and does not have any effect. Try 'resetkeys' to start with an empty list of patterns")
+	errChannelAfterAll   = errors.New("Adding a pattern after the * pattern (or the 'allchannels' flag) is not valid and does not have any effect. Try 'resetchannels' to start with an empty list of channels")
+	errSelectorsNotAvail = errors.New("Selectors are not supported")
+)
+
+// applies a single rule to the user.
+func (u *User) applyRule(rule string) error {
+	switch strings.ToLower(rule) {
+	case "on":
+		u.enabled = true
+	case "off":
+		u.enabled = false
+	case "nopass":
+		u.nopass = true
+		u.passwords = nil
+	case "resetpass":
+		u.nopass = false
+		u.passwords = nil
+	case "allkeys":
+		u.keys = []keyPattern{{pattern: "*", read: true, write: true}}
+	case "resetkeys":
+		u.keys = nil
+	case "allchannels":
+		u.channels = []string{"*"}
+	case "resetchannels":
+		u.channels = nil
+	case "allcommands":
+		return u.applyRule("+@all")
+	case "nocommands":
+		return u.applyRule("-@all")
+	case "reset":
+		u.nopass = false
+		u.passwords = nil
+		u.keys = nil
+		u.channels = nil
+		u.enabled = false
+		u.commands = nil
+	default:
+		return u.applyPatternRule(rule)
+	}
+	return nil
+}
+
+// applies the rules that carry a password, a pattern or a command.
+func (u *User) applyPatternRule(rule string) error {
+	if rule == "" {
+		return errSyntax
+	}
+
+	switch rule[0] {
+	case '>':
+		u.addPassword(HashPassword(rule[1:]))
+	case '#':
+		if !isPasswordHash(rule[1:]) {
+			return errInvalidHash
+		}
+		u.addPassword(rule[1:])
+	case '<':
+		return u.removePassword(HashPassword(rule[1:]))
+	case '!':
+		if !isPasswordHash(rule[1:]) {
+			return errInvalidHash
+		}
+		return u.removePassword(rule[1:])
+	case '~':
+		return u.addKeyPattern(keyPattern{pattern: rule[1:], read: true, write: true})
+	case '%':
+		end := strings.IndexByte(rule, '~')
+		if end <= 1 {
+			return errSyntax
+		}
+
+		pattern := keyPattern{pattern: rule[end+1:]}
+		for _, flag := range strings.ToUpper(rule[1:end]) {
+			switch flag {
+			case 'R':
+				pattern.read = true
+			case 'W':
+				pattern.write = true
+			default:
+				return errSyntax
+			}
+		}
+		return u.addKeyPattern(pattern)
+	case '&':
+		return u.addChannelPattern(rule[1:])
+	case '+', '-':
+		return u.addCommandRule(rule[0] == '+', strings.ToLower(rule[1:]))
+	case '(':
+		return errSelectorsNotAvail
+	default:
+		return errSyntax
+	}
+	return nil
+}
+
+// returns true if the string is a hex encoded SHA-256 hash, as the passwords are stored.
+func isPasswordHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	for _, ch := range hash {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (u *User) addPassword(hash string) {
+	u.nopass = false
+	for _, existing := range u.passwords {
+		if existing == hash {
+			return
+		}
+	}
+	u.passwords = append(u.passwords, hash)
+}
+
+func (u *User) removePassword(hash string) error {
+	u.nopass = false
+	for i, existing := range u.passwords {
+		if existing == hash {
+			u.passwords = append(u.passwords[:i], u.passwords[i+1:]...)
+			return nil
+		}
+	}
+	return errNoSuchPassword
+}
+
+func (u *User) addKeyPattern(pattern keyPattern) error {
+	for _, existing := range u.keys {
+		if existing.pattern == "*" && existing.read && existing.write {
+			return errKeyAfterAllKeys
+		}
+	}
+
+	if pattern.pattern == "*" && pattern.read && pattern.write {
+		u.keys = []keyPattern{pattern}
+		return nil
+	}
+	u.keys = append(u.keys, pattern)
+	return nil
+}
+
+func (u *User) addChannelPattern(pattern string) error {
+	for _, existing := range u.channels {
+		if existing == "*" {
+			return errChannelAfterAll
+		}
+	}
+
+	if pattern == "*" {
+		u.channels = []string{pattern}
+		return nil
+	}
+	u.channels = append(u.channels, pattern)
+	return nil
+}
+
+// adds the rule allowing or denying the command, command|subcommand or @category.
+func (u *User) addCommandRule(allow bool, name string) error {
+	rule := commandRule{allow: allow}
+
+	switch {
+	case name == "@all":
+		// the rule overrides all the rules before it.
+		u.commands = nil
+		if allow {
+			u.commands = []commandRule{rule}
+		}
+		return nil
+	case strings.HasPrefix(name, "@"):
+		if !IsCategory(name[1:]) {
+			return errUnknownCommand
+		}
+		rule.category = name[1:]
+	default:
+		rule.command, rule.subcommand, _ = strings.Cut(name, "|")
+		if rule.command == "" || (strings.Contains(name, "|") && rule.subcommand == "") {
+			return errSyntax
+		}
+		if !CommandExists(rule.command, rule.subcommand) {
+			return errUnknownCommand
+		}
+	}
+
+	u.commands = append(u.commands, rule)
+	return nil
+}
+
+// returns the flags of the user, as reported by ACL GETUSER.
+func (u *User) Flags() []string {
+	flags := []string{"off"}
+	if u.enabled {
+		flags[0] = "on"
+	}
+	if u.nopass {
+		flags = append(flags, "nopass")
+	}
+	return flags
+}
+
+// returns the hashes of the passwords of the user.
+func (u *User) Passwords() []string {
+	return append([]string(nil), u.passwords...)
+}
+
+// returns the rules of the user for the commands, eg. +@all -flushall.
+func (u *User) CommandRules() string {
+	rules := make([]string, 0, len(u.commands)+1)
+	if len(u.commands) == 0 || u.commands[0].command != "" || u.commands[0].category != "" || !u.commands[0].allow {
+		rules = append(rules, "-@all")
+	}
+	for _, rule := range u.commands {
+		rules = append(rules, rule.String())
+	}
+	return strings.Join(rules, " ")
+}
+
+// returns the key patterns of the user, eg. ~cache:* %R~config:*.
+func (u *User) KeyRules() string {
+	patterns := make([]string, 0, len(u.keys))
+	for _, pattern := range u.keys {
+		patterns = append(patterns, pattern.String())
+	}
+	return strings.Join(patterns, " ")
+}
+
+// returns the channel patterns of the user, eg. &news:*.
+func (u *User) ChannelRules() string {
+	patterns := make([]string, 0, len(u.channels))
+	for _, pattern := range u.channels {
+		patterns = append(patterns, "&"+pattern)
+	}
+	return strings.Join(patterns, " ")
+}
+
+// returns the rules that recreate the user, as listed by ACL LIST and saved to the ACL file,
+// eg. user default on nopass ~* &* +@all.
+func (u *User) String() string {
+	parts := []string{"user", u.Name}
+	parts = append(parts, u.Flags()...)
+	for _, hash := range u.passwords {
+		parts = append(parts, "#"+hash)
+	}
+	if keys := u.KeyRules(); keys != "" {
+		parts = append(parts, keys)
+	}
+	if channels := u.ChannelRules(); channels != "" {
+		parts = append(parts, channels)
+	} else {
+		parts = append(parts, "resetchannels")
+	}
+	parts = append(parts, u.CommandRules())
+	return strings.Join(parts, " ")
+}
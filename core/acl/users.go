@@ -0,0 +1,144 @@
+package acl
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+)
+
+// name of the user the clients are authenticated as when they connect, which always exists.
+const DefaultUser = client.DefaultUser
+
+// Users are the ACL users of an instance, keyed by their names. The default user always exists.
+type Users struct {
+	users map[string]*User
+
+	// returns the clients to disconnect once the users they are authenticated as are deleted.
+	clients func() []*client.Client
+}
+
+// returns the users of an instance without connected clients, eg. an embedded engine, which
+// only has the default user.
+func NewUsers() *Users {
+	return &Users{
+		users:   map[string]*User{DefaultUser: newDefaultUser()},
+		clients: func() []*client.Client { return nil },
+	}
+}
+
+var usersInstance *Users
+
+// returns the users of the server.
+func GetUsers() *Users {
+	if usersInstance == nil {
+		usersInstance = NewUsers()
+		usersInstance.clients = client.Connected
+	}
+
+	return usersInstance
+}
+
+// returns the default user as it is until configured otherwise: any client can authenticate as
+// it, and it can run every command on every key and channel.
+func newDefaultUser() *User {
+	u := NewUser(DefaultUser)
+	u.enabled = true
+	u.nopass = true
+	u.keys = []keyPattern{{pattern: "*", read: true, write: true}}
+	u.channels = []string{"*"}
+	u.commands = []commandRule{{allow: true}}
+	return u
+}
+
+// returns the user with the given name, nil if there is none.
+func (us *Users) GetUser(name string) *User {
+	return us.users[name]
+}
+
+// applies the rules to the user with the given name, creating the user if it doesn't exist.
+// Nothing changes if any of the rules can't be applied.
+func (us *Users) SetUser(name string, rules ...string) error {
+	u, exists := us.users[name]
+	if !exists {
+		u = NewUser(name)
+	}
+
+	if err := u.SetRules(rules...); err != nil {
+		return err
+	}
+	us.users[name] = u
+	return nil
+}
+
+// deletes the users with the given names and disconnects the clients authenticated as them.
+// Returns the number of users that were deleted.
+func (us *Users) DeleteUsers(names ...string) (int, error) {
+	for _, name := range names {
+		if name == DefaultUser {
+			return 0, errors.New("ERR The 'default' user cannot be removed")
+		}
+	}
+
+	deleted := 0
+	for _, name := range names {
+		if _, exists := us.users[name]; exists {
+			delete(us.users, name)
+			deleted++
+		}
+	}
+
+	us.killOrphanedClients()
+	return deleted, nil
+}
+
+// disconnects the clients authenticated as users that no longer exist.
+func (us *Users) killOrphanedClients() {
+	for _, c := range us.clients() {
+		if _, exists := us.users[c.User]; !exists {
+			c.Kill()
+		}
+	}
+}
+
+// returns the users, sorted by their names.
+func (us *Users) List() []*User {
+	list := make([]*User, 0, len(us.users))
+	for _, u := range us.users {
+		list = append(list, u)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// error replied to the clients that fail to authenticate, which doesn't tell whether the user exists.
+var ErrWrongPass = errors.New("WRONGPASS invalid username-password pair or user is disabled.")
+
+// authenticates the client as the user, if the user is enabled and the password is right.
+func (us *Users) Authenticate(c *client.Client, username string, password string) error {
+	u := us.users[username]
+	if u == nil || !u.enabled || !u.CheckPassword(password) {
+		return ErrWrongPass
+	}
+
+	c.User = username
+	c.Authenticated = true
+	return nil
+}
+
+// authenticates the newly connected client as the default user if the default user doesn't
+// need a password, like Redis does.
+func (us *Users) SetDefaultAuth(c *client.Client) {
+	u := us.users[DefaultUser]
+	c.User = DefaultUser
+	c.Authenticated = u.enabled && u.nopass
+}
+
+// returns true if the client has to authenticate before it can run commands.
+func (us *Users) AuthRequired(c *client.Client) bool {
+	u := us.users[DefaultUser]
+	return !c.Authenticated && (!u.nopass || !u.enabled)
+}
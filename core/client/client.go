@@ -0,0 +1,317 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	redisio "github.com/shashwatrathod/redis-internals/core/io"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+)
+
+// returned by Flush when the client went past its output buffer limit and has to be disconnected.
+var ErrOutputBufferLimitReached = errors.New("client output buffer limit reached")
+
+// Represents a single connection to the server along with the state
+// that has to survive across the commands it sends.
+type Client struct {
+	// unique, monotonically increasing id of the client.
+	Id int64
+
+	// file descriptor of the client's socket.
+	Fd int
+
+	// address of the client's end of the connection, as ip:port. empty for the fake clients.
+	Addr string
+
+	// address of the server's end of the connection, as ip:port. empty for the fake clients.
+	LAddr string
+
+	// whether the client is connected over the unix socket, in which case both of the addresses are path:0.
+	UnixSocket bool
+
+	// name of the connection, set by the client.
+	Name string
+
+	// name of the user the client is authenticated as.
+	User string
+
+	// whether the client authenticated, either with AUTH or by connecting while the default user
+	// didn't need a password.
+	Authenticated bool
+
+	// when the client connected, and when it last sent a command.
+	CreatedAt       time.Time
+	LastInteraction time.Time
+
+	// lowercase name of the last command the client ran, eg. get or client|list. empty until
+	// the client runs its first command.
+	LastCmd string
+
+	// set with CLIENT NO-EVICT. the server doesn't evict clients to free memory, so this is
+	// only reported by CLIENT LIST.
+	NoEvict bool
+
+	// set with CLIENT NO-TOUCH, in which case the commands of the client don't update the last
+	// access times of the keys, except for TOUCH.
+	NoTouch bool
+
+	// options of the client side caching, set with CLIENT TRACKING. nil while tracking is off.
+	Tracking *Tracking
+
+	// command read while the clients were paused with CLIENT PAUSE, that runs once they are
+	// unpaused. nothing more is read from the client in the meantime.
+	Postponed *QueuedCommand
+
+	// the RESP protocol version negotiated with HELLO.
+	Protocol int
+
+	// index of the database selected with SELECT.
+	Db int
+
+	// the instance the commands of the client run against, an *eval.Instance, which holds the
+	// databases, the stats, the ACL users and the rest of the state of the embedded engine the
+	// client belongs to. nil for the clients of the server, see eval.InstanceOf.
+	Instance interface{}
+
+	// channels, patterns and sharded channels the client is subscribed to.
+	Channels      map[string]struct{}
+	Patterns      map[string]struct{}
+	ShardChannels map[string]struct{}
+
+	// state of the transaction started with MULTI. nil if the client is not in a transaction.
+	Transaction *Transaction
+
+	// keys watched by the client, along with their versions at the time they were watched.
+	WatchedKeys map[WatchedKey]uint64
+
+	// underlying connection the commands are read from and the replies are flushed to.
+	conn io.ReadWriter
+
+	// bytes read from the connection that weren't parsed into a command yet, eg. the commands
+	// pipelined after the one that ran, or the start of a command that didn't fully arrive.
+	querybuf []byte
+
+	// replies that are yet to be written to the connection.
+	outbuf []byte
+
+	// set once the connection is closed, after which the replies are dropped.
+	released bool
+
+	// set once the client is killed, after which the server closes the connection as soon as
+	// it is done with the current batch of events.
+	closeAsap bool
+}
+
+// Options of the server assisted client side caching of a client, see CLIENT TRACKING.
+type Tracking struct {
+	// the client is told about the changes to all the keys with the prefixes, instead of the
+	// changes to the keys it read.
+	Bcast    bool
+	Prefixes []string
+
+	// only the keys read right after CLIENT CACHING yes are tracked (OptIn), or all of them
+	// except the ones read right after CLIENT CACHING no (OptOut).
+	OptIn  bool
+	OptOut bool
+
+	// set with CLIENT CACHING for the next command, or for the next transaction.
+	Caching bool
+
+	// the client isn't told about the keys it modified itself.
+	NoLoop bool
+
+	// id of the client the invalidation messages are sent to instead, 0 for the client itself.
+	Redirect int64
+
+	// set once the client the messages are redirected to has disconnected.
+	BrokenRedirect bool
+}
+
+// A key watched with WATCH, in the database that was selected when it was watched.
+type WatchedKey struct {
+	Db  int
+	Key string
+}
+
+// A command queued by a client in a transaction.
+type QueuedCommand struct {
+	Cmd  string
+	Args []string
+}
+
+// Represents a transaction started with MULTI, whose commands are
+// queued until EXEC runs them all at once.
+type Transaction struct {
+	Queue []QueuedCommand
+	// set if a command could not be queued, in which case EXEC discards the transaction.
+	Aborted bool
+}
+
+// the number of bytes read from a client's socket at once, like Redis's PROTO_IOBUF_LEN.
+const ReadBufferSize = 16 * 1024
+
+var nextClientId int64 = 0
+
+// clients that have replies waiting in their output buffers.
+var pendingWrites = map[*Client]struct{}{}
+
+// returns a new Client for the connection with the given file descriptor.
+func NewClient(fd int, conn io.ReadWriter) *Client {
+	nextClientId++
+	return newClient(nextClientId, fd, conn)
+}
+
+func newClient(id int64, fd int, conn io.ReadWriter) *Client {
+	now := time.Now()
+	return &Client{
+		Id:              id,
+		Fd:              fd,
+		User:            DefaultUser,
+		CreatedAt:       now,
+		LastInteraction: now,
+		Protocol:        resp.Resp2,
+		Channels:        make(map[string]struct{}),
+		Patterns:        make(map[string]struct{}),
+		ShardChannels:   make(map[string]struct{}),
+		WatchedKeys:     make(map[WatchedKey]uint64),
+		conn:            conn,
+	}
+}
+
+// returns a client that runs commands on behalf of the server itself, eg. for
+// the commands called by scripts. Everything written to it is discarded.
+func NewFakeClient() *Client {
+	c := NewClient(-1, nil)
+	c.released = true
+	return c
+}
+
+// returns a fake client with the given id, which doesn't take one of the ids of the clients of
+// the server, eg. for the clients of an embedded engine.
+func NewFakeClientWithId(id int64) *Client {
+	c := newClient(id, -1, nil)
+	c.released = true
+	return c
+}
+
+// Read reads from the underlying connection.
+func (c *Client) Read(b []byte) (int, error) {
+	return c.conn.Read(b)
+}
+
+// Write appends the given bytes to the client's output buffer. Nothing is
+// written to the connection until Flush is called, so that writing to a
+// slow client never blocks the caller.
+func (c *Client) Write(b []byte) (int, error) {
+	if len(b) == 0 || c.released {
+		return len(b), nil
+	}
+	c.outbuf = append(c.outbuf, b...)
+	pendingWrites[c] = struct{}{}
+	return len(b), nil
+}
+
+// Flush writes as much of the output buffer to the connection as it accepts without blocking.
+// returns true if the output buffer was fully drained.
+func (c *Client) Flush() (bool, error) {
+	drained, err := c.WriteOut()
+	if drained {
+		delete(pendingWrites, c)
+	}
+	return drained, err
+}
+
+// WriteOut writes the output buffer like Flush does, but leaves the client among the ones with
+// pending writes, so that the clients can be written out from more than one goroutine at once.
+// Flush has to be called once the output buffer drains, from the goroutine running the commands.
+func (c *Client) WriteOut() (bool, error) {
+	for len(c.outbuf) > 0 {
+		n, err := c.conn.Write(c.outbuf)
+		if n > 0 {
+			c.outbuf = c.outbuf[n:]
+		}
+		if err == syscall.EAGAIN {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(c.outbuf) == 0 {
+		c.outbuf = nil
+
+		// the connection may hold back some of what it took, eg. the TLS records the socket didn't take.
+		if buffered, ok := c.conn.(redisio.BufferedConn); ok {
+			drained, err := buffered.FlushBuffered()
+			if err != nil || !drained {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	if len(c.outbuf) > config.ClientOutputBufferLimit {
+		return false, ErrOutputBufferLimitReached
+	}
+
+	return false, nil
+}
+
+// returns the number of bytes waiting in the output buffer.
+func (c *Client) PendingBytes() int {
+	return len(c.outbuf)
+}
+
+// returns the number of bytes read from the socket that the client didn't run as commands yet,
+// whether they're in its query buffer or the connection holds them. The socket won't become
+// readable again for them, so they have to be read without waiting for it.
+func (c *Client) PendingReads() int {
+	return len(c.querybuf) + c.connPendingReads()
+}
+
+// returns the number of bytes the connection read from the socket, but the client didn't read yet.
+func (c *Client) connPendingReads() int {
+	if buffered, ok := c.conn.(redisio.BufferedConn); ok {
+		return buffered.PendingReads()
+	}
+	return 0
+}
+
+// Release drops the client's buffered replies and forgets the client. Must be called once the
+// connection is closed.
+func (c *Client) Release() {
+	c.released = true
+	c.outbuf = nil
+	c.Postponed = nil
+	delete(pendingWrites, c)
+	Unregister(c)
+}
+
+// returns the number of channels and patterns the client is subscribed to.
+func (c *Client) SubscriptionCount() int {
+	return len(c.Channels) + len(c.Patterns)
+}
+
+// returns the number of sharded channels the client is subscribed to.
+func (c *Client) ShardSubscriptionCount() int {
+	return len(c.ShardChannels)
+}
+
+// returns true if the client is in the RESP2 subscriber mode, where it can only
+// issue the commands that manage its subscriptions.
+func (c *Client) InSubscriberMode() bool {
+	return c.Protocol == resp.Resp2 && c.SubscriptionCount()+c.ShardSubscriptionCount() > 0
+}
+
+// returns the clients that have replies waiting in their output buffers.
+func PendingWrites() []*Client {
+	clients := make([]*Client, 0, len(pendingWrites))
+	for c := range pendingWrites {
+		clients = append(clients, c)
+	}
+	return clients
+}
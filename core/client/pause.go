@@ -0,0 +1,38 @@
+package client
+
+import "time"
+
+// the clients are paused with CLIENT PAUSE until pauseEnd, either entirely or only for the
+// commands that may modify the dataset.
+var (
+	pauseEnd        time.Time
+	pauseWritesOnly bool
+)
+
+// pauses the clients until the given time. if writesOnly is set, only the commands that may
+// modify the dataset are postponed. a pause that is already in effect is only ever extended.
+func Pause(end time.Time, writesOnly bool) {
+	if paused, currentWritesOnly := Paused(); paused {
+		writesOnly = writesOnly && currentWritesOnly
+		if pauseEnd.After(end) {
+			end = pauseEnd
+		}
+	}
+
+	pauseEnd = end
+	pauseWritesOnly = writesOnly
+}
+
+// ends the pause, if any.
+func Unpause() {
+	pauseEnd = time.Time{}
+	pauseWritesOnly = false
+}
+
+// returns true if the clients are paused, along with whether only their writes are.
+func Paused() (bool, bool) {
+	if !time.Now().Before(pauseEnd) {
+		return false, false
+	}
+	return true, pauseWritesOnly
+}
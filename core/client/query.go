@@ -0,0 +1,122 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"syscall"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+)
+
+// the most bytes the query buffer of a client holds, like Redis's client-query-buffer-limit.
+// The clients sending a command longer than this are disconnected.
+const QueryBufferLimit = 1024 * 1024 * 1024
+
+var errQueryBufferLimitReached = errors.New("client query buffer limit reached")
+
+// the buffers the sockets are read into, shared by the clients so that the idle ones don't hold
+// on to one. only what's read is copied into the query buffers.
+var readBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, ReadBufferSize)
+		return &buf
+	},
+}
+
+// ReadCommand returns the next command the client sent, as the command name followed by its
+// arguments. The bytes read past the command, eg. the commands pipelined after it, are kept in
+// the query buffer of the client for the next calls. Returns syscall.EAGAIN until a whole
+// command has arrived.
+func (c *Client) ReadCommand() ([]string, error) {
+	for {
+		if tokens, err := c.parseCommand(); tokens != nil || err != nil {
+			return tokens, err
+		}
+
+		// the query buffer only holds the start of a command, or nothing at all.
+		if len(c.querybuf) >= QueryBufferLimit {
+			return nil, errQueryBufferLimitReached
+		}
+		if err := c.readQuery(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reads what the connection has into the query buffer, until the socket has to be waited for
+// again. returns syscall.EAGAIN if there was nothing to read.
+func (c *Client) readQuery() error {
+	buf := readBuffers.Get().(*[]byte)
+	defer readBuffers.Put(buf)
+
+	read := 0
+	for {
+		n, err := c.conn.Read(*buf)
+		if n > 0 {
+			if config.LogRequest {
+				log.Println("Raw input: ", fmt.Sprintf("%q", (*buf)[:n]))
+			}
+			c.querybuf = append(c.querybuf, (*buf)[:n]...)
+			read += n
+		}
+
+		switch {
+		case err == syscall.EAGAIN && read > 0:
+			return nil
+		case err != nil:
+			return err
+		case n == 0:
+			// the peer closed the connection.
+			return io.EOF
+		case n < len(*buf) && c.connPendingReads() == 0:
+			// the socket has nothing more for now, and neither has the connection.
+			return nil
+		}
+	}
+}
+
+// takes the first command off the query buffer. returns nil if the query buffer doesn't hold a
+// whole command yet.
+func (c *Client) parseCommand() ([]string, error) {
+	for len(c.querybuf) > 0 {
+		value, next, err := resp.DecodeTyped(c.querybuf, 0)
+		if errors.Is(err, resp.ErrIncomplete) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ERR Protocol error: %w", err)
+		}
+		c.consumeQuery(next)
+
+		if value.Type != resp.RespArrayIdentifier {
+			return nil, errors.New("ERR Protocol error: expected an array of bulk strings")
+		}
+		if len(value.Items) == 0 {
+			// like Redis, the empty commands are skipped.
+			continue
+		}
+
+		tokens := make([]string, len(value.Items))
+		for i, item := range value.Items {
+			if item.Type != resp.RespBulkStringIdentifier || item.IsNull {
+				return nil, errors.New("ERR Protocol error: expected an array of bulk strings")
+			}
+			tokens[i] = item.Str
+		}
+		return tokens, nil
+	}
+	return nil, nil
+}
+
+// drops the first n bytes of the query buffer, which were parsed into a command.
+func (c *Client) consumeQuery(n int) {
+	if n == len(c.querybuf) {
+		c.querybuf = nil
+		return
+	}
+	c.querybuf = c.querybuf[n:]
+}
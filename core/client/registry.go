@@ -0,0 +1,66 @@
+package client
+
+import "sort"
+
+// name of the user the clients are authenticated as when they connect.
+const DefaultUser = "default"
+
+// clients connected to the server, keyed by their ids.
+var connected = map[int64]*Client{}
+
+// clients that were killed, and whose connections are yet to be closed.
+var killed = map[*Client]struct{}{}
+
+// adds the client to the connected clients, eg. as listed by CLIENT LIST.
+func Register(c *Client) {
+	connected[c.Id] = c
+}
+
+// removes the client from the connected clients.
+func Unregister(c *Client) {
+	delete(connected, c.Id)
+	delete(killed, c)
+}
+
+// returns the connected clients, sorted by their ids.
+func Connected() []*Client {
+	clients := make([]*Client, 0, len(connected))
+	for _, c := range connected {
+		clients = append(clients, c)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Id < clients[j].Id
+	})
+	return clients
+}
+
+// returns the connected client with the given id, nil if there is none.
+func ById(id int64) *Client {
+	return connected[id]
+}
+
+// Kill marks the client to be disconnected. The connection is closed by the server once it is
+// done with the current batch of events, so the client still gets the reply of the command it
+// is running.
+func (c *Client) Kill() {
+	if c.closeAsap {
+		return
+	}
+	c.closeAsap = true
+	killed[c] = struct{}{}
+}
+
+// returns true if the client was killed, and is waiting for its connection to be closed.
+func (c *Client) IsKilled() bool {
+	return c.closeAsap
+}
+
+// returns the clients that were killed, whose connections are yet to be closed.
+func Killed() []*Client {
+	clients := make([]*Client, 0, len(killed))
+	for c := range killed {
+		clients = append(clients, c)
+	}
+	return clients
+}
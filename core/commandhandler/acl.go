@@ -0,0 +1,45 @@
+package commandhandler
+
+import (
+	"errors"
+
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+)
+
+// returns an error if the client has to authenticate first, or if its user isn't allowed to
+// run the command. The denied commands are recorded in the ACL log. The commands flagged no_auth
+// can be run before authenticating, and regardless of the permissions of the user.
+func checkPermissions(cmd *eval.RedisCmd, c *client.Client) error {
+	if eval.CommandMap[cmd.Cmd].HasFlag(eval.FlagNoAuth) {
+		return nil
+	}
+
+	instance := eval.InstanceOf(c)
+	if instance.Users.AuthRequired(c) {
+		return errors.New("NOAUTH Authentication required.")
+	}
+
+	reason, object := eval.CheckPermissions(instance.Users.GetUser(c.User), cmd)
+	if reason == "" {
+		return nil
+	}
+
+	instance.AclLog.Record(reason, aclContext(c), object, c.User, eval.ClientInfoString(c))
+	return eval.PermissionErr(reason, object, c.User)
+}
+
+// returns the context the command of the client runs in, as reported by ACL LOG.
+func aclContext(c *client.Client) string {
+	switch {
+	case c == eval.InstanceOf(c).ScriptClient:
+		return acl.ContextLua
+	// only the clients of the server run EXEC, so the ones of the engines, which run concurrently
+	// with the server, don't read execClient.
+	case c.Transaction != nil || (c.Instance == nil && c == execClient):
+		return acl.ContextMulti
+	default:
+		return acl.ContextTopLevel
+	}
+}
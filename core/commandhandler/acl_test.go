@@ -0,0 +1,159 @@
+package commandhandler_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+var _ = Describe("ACL", func() {
+	var (
+		s    *store.DataStore
+		c    *client.Client
+		conn *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+		client.Register(c)
+		acl.GetUsers().SetDefaultAuth(c)
+	})
+
+	AfterEach(func() {
+		c.Release()
+		_, err := acl.GetUsers().DeleteUsers("alice")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(acl.GetUsers().SetUser(acl.DefaultUser, "reset", "on", "nopass", "allkeys", "allchannels", "allcommands")).To(Succeed())
+		acl.GetLog().Reset()
+		s.Reset()
+	})
+
+	It("should require the clients to authenticate when the default user has a password", func() {
+		Expect(run(s, c, conn, "AUTH", "secret")).To(HavePrefix("-ERR AUTH <password> called without any password configured"))
+		Expect(run(s, c, conn, "ACL", "SETUSER", "default", ">secret")).To(Equal("+OK\r\n"))
+
+		otherConn := &bytes.Buffer{}
+		other := client.NewClient(-1, otherConn)
+		acl.GetUsers().SetDefaultAuth(other)
+
+		Expect(run(s, other, otherConn, "GET", "key")).To(Equal("-NOAUTH Authentication required."))
+		Expect(run(s, other, otherConn, "HELLO", "3")).To(HavePrefix("-NOAUTH HELLO must be called with the client already authenticated"))
+		Expect(run(s, other, otherConn, "AUTH", "wrong")).To(Equal("-WRONGPASS invalid username-password pair or user is disabled."))
+		Expect(run(s, other, otherConn, "AUTH", "secret")).To(Equal("+OK\r\n"))
+		Expect(run(s, other, otherConn, "GET", "key")).To(Equal("$-1\r\n"))
+	})
+
+	It("should authenticate as the user with AUTH and HELLO", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", ">secret", "+@all", "~*")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "AUTH", "alice", "secret")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "ACL", "WHOAMI")).To(Equal("$5\r\nalice\r\n"))
+
+		Expect(run(s, c, conn, "HELLO", "3", "AUTH", "default", "")).To(ContainSubstring("proto"))
+		Expect(run(s, c, conn, "ACL", "WHOAMI")).To(Equal("$7\r\ndefault\r\n"))
+	})
+
+	It("should deny the commands, keys and channels the user isn't allowed", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", "nopass", "+@read", "+publish", "+auth", "%R~cache:*", "&news:*")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "AUTH", "alice", "")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "GET", "cache:1")).To(Equal("$-1\r\n"))
+		Expect(run(s, c, conn, "GET", "other")).To(Equal("-NOPERM No permissions to access a key"))
+		Expect(run(s, c, conn, "SET", "cache:1", "value")).To(Equal("-NOPERM User alice has no permissions to run the 'set' command"))
+		Expect(run(s, c, conn, "PUBLISH", "news:sports", "goal")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "PUBLISH", "weather", "rain")).To(Equal("-NOPERM No permissions to access a channel"))
+		Expect(run(s, c, conn, "CLIENT", "KILL", "ID", "1")).To(Equal("-NOPERM User alice has no permissions to run the 'client|kill' command"))
+
+		Expect(run(s, c, conn, "MULTI")).To(HavePrefix("-NOPERM"))
+	})
+
+	It("should check the commands called by the scripts against the user running them", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", "nopass", "+eval", "+get", "allkeys")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "AUTH", "alice", "")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "EVAL", "return redis.call('GET', KEYS[1])", "1", "key")).To(Equal("$-1\r\n"))
+		Expect(run(s, c, conn, "EVAL", "return redis.call('SET', KEYS[1], 'value')", "1", "key")).To(ContainSubstring("NOPERM"))
+
+		Expect(acl.GetLog().Entries(1)[0].Context).To(Equal(acl.ContextLua))
+	})
+
+	It("should record the denied commands and the failed authentications", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", ">secret", "+get")).To(Equal("+OK\r\n"))
+		run(s, c, conn, "AUTH", "alice", "wrong")
+		Expect(run(s, c, conn, "AUTH", "alice", "secret")).To(Equal("+OK\r\n"))
+		run(s, c, conn, "SET", "key", "value")
+		run(s, c, conn, "SET", "key", "value")
+
+		entries := acl.GetLog().Entries(10)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Reason).To(Equal(acl.ReasonCommand))
+		Expect(entries[0].Object).To(Equal("set"))
+		Expect(entries[0].Count).To(BeEquivalentTo(2))
+		Expect(entries[1].Reason).To(Equal(acl.ReasonAuth))
+		Expect(entries[1].Username).To(Equal("alice"))
+	})
+
+	It("should keep the passwords out of the slow log", func() {
+		threshold := config.SlowlogLogSlowerThan
+		config.SlowlogLogSlowerThan = 0
+		stats.GetSlowLog().Reset()
+		DeferCleanup(func() {
+			config.SlowlogLogSlowerThan = threshold
+			stats.GetSlowLog().Reset()
+		})
+
+		hash := acl.HashPassword("other")
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", ">secret", "#"+hash, "+@all", "~*")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "AUTH", "alice", "secret")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "HELLO", "3", "AUTH", "alice", "secret", "SETNAME", "app")).To(ContainSubstring("proto"))
+
+		entries := stats.GetSlowLog().Get(-1)
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Args).To(Equal([]string{"HELLO", "3", "AUTH", "(redacted)", "(redacted)", "SETNAME", "app"}))
+		Expect(entries[1].Args).To(Equal([]string{"AUTH", "(redacted)", "(redacted)"}))
+		Expect(entries[2].Args).To(Equal([]string{"ACL", "SETUSER", "alice", "on", "(redacted)", "(redacted)", "+@all", "~*"}))
+	})
+
+	It("should describe the users", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", "nopass", "+get", "~cache:*")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "ACL", "USERS")).To(Equal("*2\r\n$5\r\nalice\r\n$7\r\ndefault\r\n"))
+		Expect(run(s, c, conn, "ACL", "LIST")).To(ContainSubstring("user alice on nopass ~cache:* resetchannels -@all +get\r\n"))
+		Expect(run(s, c, conn, "ACL", "GETUSER", "alice")).To(Equal(
+			"*12\r\n$5\r\nflags\r\n*2\r\n$2\r\non\r\n$6\r\nnopass\r\n$9\r\npasswords\r\n*0\r\n" +
+				"$8\r\ncommands\r\n$10\r\n-@all +get\r\n$4\r\nkeys\r\n$8\r\n~cache:*\r\n" +
+				"$8\r\nchannels\r\n$0\r\n\r\n$9\r\nselectors\r\n*0\r\n"))
+		Expect(run(s, c, conn, "ACL", "GETUSER", "nobody")).To(Equal("$-1\r\n"))
+
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "+nonexistent")).To(Equal(
+			"-ERR Error in ACL SETUSER modifier '+nonexistent': Unknown command or category name in ACL"))
+	})
+
+	It("should tell whether the user could run a command", func() {
+		Expect(run(s, c, conn, "ACL", "SETUSER", "alice", "on", "+get", "~cache:*")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "ACL", "DRYRUN", "alice", "GET", "cache:1")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "ACL", "DRYRUN", "alice", "GET", "other")).To(Equal("$55\r\nUser alice has no permissions to access the 'other' key\r\n"))
+		Expect(run(s, c, conn, "ACL", "DRYRUN", "alice", "SET", "cache:1", "v")).To(Equal("$54\r\nUser alice has no permissions to run the 'set' command\r\n"))
+		Expect(run(s, c, conn, "ACL", "DRYRUN", "nobody", "GET", "key")).To(Equal("-ERR User 'nobody' not found"))
+	})
+
+	It("should list the commands in the categories", func() {
+		Expect(run(s, c, conn, "ACL", "CAT")).To(ContainSubstring("$8\r\nkeyspace\r\n"))
+
+		dangerous := run(s, c, conn, "ACL", "CAT", "dangerous")
+		Expect(dangerous).To(ContainSubstring("$8\r\nflushall\r\n"))
+		Expect(dangerous).To(ContainSubstring("$11\r\nclient|kill\r\n"))
+		Expect(dangerous).ToNot(ContainSubstring("$3\r\nget\r\n"))
+
+		Expect(run(s, c, conn, "ACL", "CAT", "unknown")).To(Equal("-ERR Unknown category 'unknown'"))
+	})
+})
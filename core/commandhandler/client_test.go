@@ -0,0 +1,194 @@
+package commandhandler_test
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/commandhandler"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/core/tracking"
+)
+
+var _ = Describe("CLIENT", func() {
+	var (
+		s     *store.DataStore
+		c     *client.Client
+		other *client.Client
+		conn  *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+		c.Addr = "127.0.0.1:5000"
+		other = client.NewClient(-1, &bytes.Buffer{})
+		other.Addr = "127.0.0.1:5001"
+		client.Register(c)
+		client.Register(other)
+	})
+
+	AfterEach(func() {
+		tracking.GetTracker().Disable(c)
+		c.Release()
+		other.Release()
+		client.Unpause()
+		s.Reset()
+	})
+
+	It("should reply with the id of the client", func() {
+		Expect(run(s, c, conn, "CLIENT", "ID")).To(Equal(":" + fmt.Sprint(c.Id) + "\r\n"))
+	})
+
+	It("should name the connection", func() {
+		Expect(run(s, c, conn, "CLIENT", "GETNAME")).To(Equal("$-1\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "SETNAME", "worker")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "GETNAME")).To(Equal("$6\r\nworker\r\n"))
+
+		Expect(run(s, c, conn, "CLIENT", "SETNAME", "two words")).To(HavePrefix("-ERR Client names cannot contain spaces"))
+		Expect(c.Name).To(Equal("worker"))
+
+		Expect(run(s, c, conn, "HELLO", "3", "SETNAME", "hello")).To(ContainSubstring("proto"))
+		Expect(c.Name).To(Equal("hello"))
+	})
+
+	It("should describe the connected clients", func() {
+		c.Name = "worker"
+		other.Channels["news"] = struct{}{}
+
+		info := run(s, c, conn, "CLIENT", "INFO")
+		Expect(info).To(ContainSubstring("id=" + fmt.Sprint(c.Id) + " addr=127.0.0.1:5000 "))
+		Expect(info).To(ContainSubstring(" name=worker "))
+		Expect(info).To(ContainSubstring(" flags=N db=0 sub=0 "))
+		Expect(info).To(ContainSubstring(" cmd=client|info user=default "))
+
+		list := run(s, c, conn, "CLIENT", "LIST")
+		Expect(list).To(ContainSubstring("id=" + fmt.Sprint(c.Id) + " "))
+		Expect(list).To(ContainSubstring("id=" + fmt.Sprint(other.Id) + " "))
+
+		pubsub := run(s, c, conn, "CLIENT", "LIST", "TYPE", "pubsub")
+		Expect(pubsub).To(ContainSubstring("id=" + fmt.Sprint(other.Id) + " "))
+		Expect(pubsub).To(ContainSubstring(" flags=P "))
+		Expect(pubsub).ToNot(ContainSubstring("id=" + fmt.Sprint(c.Id) + " "))
+
+		byId := run(s, c, conn, "CLIENT", "LIST", "ID", fmt.Sprint(c.Id))
+		Expect(byId).ToNot(ContainSubstring("id=" + fmt.Sprint(other.Id) + " "))
+
+		Expect(run(s, c, conn, "CLIENT", "LIST", "TYPE", "unknown")).To(Equal("-ERR Unknown client type 'unknown'"))
+	})
+
+	It("should flag the clients connected over the unix socket", func() {
+		c.Addr, c.LAddr, c.UnixSocket = "/tmp/redis.sock:0", "/tmp/redis.sock:0", true
+
+		info := run(s, c, conn, "CLIENT", "INFO")
+		Expect(info).To(ContainSubstring(" addr=/tmp/redis.sock:0 laddr=/tmp/redis.sock:0 "))
+		Expect(info).To(ContainSubstring(" flags=U "))
+	})
+
+	It("should kill the clients matching the filters", func() {
+		Expect(run(s, c, conn, "CLIENT", "KILL", "ID", fmt.Sprint(other.Id))).To(Equal(":1\r\n"))
+		Expect(other.IsKilled()).To(BeTrue())
+		Expect(client.Killed()).To(ConsistOf(other))
+	})
+
+	It("should skip the calling client unless asked not to", func() {
+		Expect(run(s, c, conn, "CLIENT", "KILL", "USER", "default")).To(Equal(":1\r\n"))
+		Expect(c.IsKilled()).To(BeFalse())
+
+		Expect(run(s, c, conn, "CLIENT", "KILL", "USER", "default", "SKIPME", "no")).To(Equal(":2\r\n"))
+		Expect(c.IsKilled()).To(BeTrue())
+	})
+
+	It("should only kill the clients older than the max age", func() {
+		other.CreatedAt = time.Now().Add(-time.Minute)
+
+		Expect(run(s, c, conn, "CLIENT", "KILL", "MAXAGE", "30")).To(Equal(":1\r\n"))
+		Expect(other.IsKilled()).To(BeTrue())
+	})
+
+	It("should kill the client connected from the address", func() {
+		Expect(run(s, c, conn, "CLIENT", "KILL", "127.0.0.1:5001")).To(Equal("+OK\r\n"))
+		Expect(other.IsKilled()).To(BeTrue())
+
+		Expect(run(s, c, conn, "CLIENT", "KILL", "127.0.0.1:6000")).To(Equal("-ERR No such client"))
+	})
+
+	It("should postpone the writes while the writes are paused", func() {
+		Expect(run(s, c, conn, "CLIENT", "PAUSE", "10000", "WRITE")).To(Equal("+OK\r\n"))
+
+		Expect(commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.SET, Args: []string{"key", "value"}}, other)).To(BeTrue())
+		Expect(commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.GET, Args: []string{"key"}}, other)).To(BeFalse())
+
+		other.Transaction = &client.Transaction{Queue: []client.QueuedCommand{{Cmd: eval.SET, Args: []string{"key", "value"}}}}
+		Expect(commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.EXEC}, other)).To(BeTrue())
+
+		Expect(run(s, c, conn, "CLIENT", "UNPAUSE")).To(Equal("+OK\r\n"))
+		Expect(commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.SET, Args: []string{"key", "value"}}, other)).To(BeFalse())
+	})
+
+	It("should postpone every command while the clients are paused, until the pause times out", func() {
+		Expect(run(s, c, conn, "CLIENT", "PAUSE", "50")).To(Equal("+OK\r\n"))
+		Expect(commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.GET, Args: []string{"key"}}, other)).To(BeTrue())
+
+		Eventually(func() bool {
+			return commandhandler.ShouldPostpone(&eval.RedisCmd{Cmd: eval.GET, Args: []string{"key"}}, other)
+		}).Should(BeFalse())
+	})
+
+	It("should not update the access times of the keys with NO-TOUCH on", func() {
+		s.Put("key", "value", nil)
+		s.GetKeyMetadata("key").LastAccessedTimestamp = 0
+
+		Expect(run(s, c, conn, "CLIENT", "NO-TOUCH", "on")).To(Equal("+OK\r\n"))
+		run(s, c, conn, "GET", "key")
+		Expect(s.GetKeyMetadata("key").LastAccessedTimestamp).To(BeZero())
+		Expect(run(s, c, conn, "CLIENT", "INFO")).To(ContainSubstring(" flags=T "))
+
+		run(s, c, conn, "TOUCH", "key")
+		Expect(s.GetKeyMetadata("key").LastAccessedTimestamp).ToNot(BeZero())
+	})
+
+	It("should turn tracking on and off", func() {
+		Expect(run(s, c, conn, "CLIENT", "GETREDIR")).To(Equal(":-1\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "REDIRECT", fmt.Sprint(other.Id), "NOLOOP")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "GETREDIR")).To(Equal(":" + fmt.Sprint(other.Id) + "\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "INFO")).To(ContainSubstring(" flags=t "))
+		Expect(run(s, c, conn, "CLIENT", "INFO")).To(ContainSubstring(" redir=" + fmt.Sprint(other.Id) + " "))
+
+		Expect(run(s, c, conn, "CLIENT", "TRACKINGINFO")).To(Equal(
+			"*6\r\n$5\r\nflags\r\n*2\r\n$2\r\non\r\n$6\r\nnoloop\r\n$8\r\nredirect\r\n:" + fmt.Sprint(other.Id) + "\r\n$8\r\nprefixes\r\n*0\r\n"))
+
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "off")).To(Equal("+OK\r\n"))
+		Expect(c.Tracking).To(BeNil())
+	})
+
+	It("should reject the conflicting tracking options", func() {
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "REDIRECT", "999999")).To(Equal("-ERR The client ID you want redirect to does not exist"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "PREFIX", "user:")).To(Equal("-ERR PREFIX option requires BCAST mode to be enabled"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "OPTIN", "OPTOUT")).To(Equal("-ERR You can't use both OPTIN and OPTOUT"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "BCAST", "OPTIN")).To(Equal("-ERR OPTIN and OPTOUT are not compatible with BCAST"))
+
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "OPTIN")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "BCAST")).To(HavePrefix("-ERR You can't switch BCAST mode on/off"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "OPTOUT")).To(HavePrefix("-ERR You can't switch OPTIN/OPTOUT mode"))
+	})
+
+	It("should only track the keys read right after CACHING yes in the OPTIN mode", func() {
+		Expect(run(s, c, conn, "CLIENT", "CACHING", "yes")).To(HavePrefix("-ERR CLIENT CACHING can be called only when"))
+		Expect(run(s, c, conn, "CLIENT", "TRACKING", "on", "OPTIN")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "CLIENT", "CACHING", "no")).To(Equal("-ERR CLIENT CACHING NO is only valid when tracking is enabled in OPTOUT mode."))
+
+		run(s, c, conn, "GET", "untracked")
+		Expect(run(s, c, conn, "CLIENT", "CACHING", "yes")).To(Equal("+OK\r\n"))
+		run(s, c, conn, "GET", "tracked")
+		Expect(c.Tracking.Caching).To(BeFalse())
+
+		keys, _ := tracking.GetTracker().TotalKeys()
+		Expect(keys).To(Equal(1))
+	})
+})
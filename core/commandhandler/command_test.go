@@ -0,0 +1,79 @@
+package commandhandler_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+var _ = Describe("COMMAND", func() {
+	var (
+		s    *store.DataStore
+		c    *client.Client
+		conn *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+	})
+
+	AfterEach(func() {
+		s.Reset()
+	})
+
+	It("should reject the commands with the wrong number of arguments before running them", func() {
+		Expect(run(s, c, conn, "GET")).To(Equal("-ERR wrong number of arguments for 'get' command"))
+		Expect(run(s, c, conn, "SET", "key")).To(Equal("-ERR wrong number of arguments for 'set' command"))
+		Expect(run(s, c, conn, "CLIENT")).To(Equal("-ERR wrong number of arguments for 'client' command"))
+		Expect(run(s, c, conn, "CLIENT", "ID", "extra")).To(Equal("-ERR wrong number of arguments for 'client|id' command"))
+		Expect(run(s, c, conn, "DEL", "a", "b")).To(Equal(":0\r\n"))
+	})
+
+	It("should describe the commands", func() {
+		Expect(run(s, c, conn, "COMMAND", "INFO", "get", "nosuch")).To(Equal(
+			"*2\r\n*10\r\n$3\r\nget\r\n:2\r\n*2\r\n+readonly\r\n+fast\r\n:1\r\n:1\r\n:1\r\n" +
+				"*3\r\n+@read\r\n+@string\r\n+@fast\r\n*0\r\n" +
+				"*1\r\n*6\r\n$5\r\nflags\r\n*2\r\n+RO\r\n+ACCESS\r\n" +
+				"$12\r\nbegin_search\r\n*4\r\n$4\r\ntype\r\n$5\r\nindex\r\n$4\r\nspec\r\n*2\r\n$5\r\nindex\r\n:1\r\n" +
+				"$9\r\nfind_keys\r\n*4\r\n$4\r\ntype\r\n$5\r\nrange\r\n$4\r\nspec\r\n*6\r\n$7\r\nlastkey\r\n:0\r\n$7\r\nkeystep\r\n:1\r\n$5\r\nlimit\r\n:0\r\n" +
+				"*0\r\n*-1\r\n"))
+
+		info := run(s, c, conn, "COMMAND", "INFO", "eval", "client|kill")
+		Expect(info).To(ContainSubstring("+movablekeys\r\n"))
+		Expect(info).To(ContainSubstring("$11\r\nclient|kill\r\n:-3\r\n*4\r\n+admin\r\n+noscript\r\n+loading\r\n+stale\r\n"))
+
+		Expect(run(s, c, conn, "COMMAND", "COUNT")).To(MatchRegexp(`^:\d+\r\n$`))
+		Expect(run(s, c, conn, "COMMAND", "DOCS", "get")).To(Equal(
+			"*2\r\n$3\r\nget\r\n*8\r\n$7\r\nsummary\r\n$34\r\nReturns the string value of a key.\r\n" +
+				"$5\r\nsince\r\n$5\r\n1.0.0\r\n$5\r\ngroup\r\n$6\r\nstring\r\n$10\r\ncomplexity\r\n$4\r\nO(1)\r\n"))
+	})
+
+	It("should not let the scripts run the admin commands", func() {
+		Expect(run(s, c, conn, "COMMAND", "INFO", "slowlog|reset")).To(ContainSubstring("+admin\r\n+noscript\r\n"))
+		Expect(run(s, c, conn, "EVAL", "return redis.call('SLOWLOG', 'RESET')", "0")).To(
+			HavePrefix("-ERR This Redis command is not allowed from script"))
+	})
+
+	It("should find the keys among the arguments of a command", func() {
+		Expect(run(s, c, conn, "COMMAND", "GETKEYS", "RENAME", "a", "b")).To(Equal("*2\r\n$1\r\na\r\n$1\r\nb\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "GETKEYS", "EVAL", "return 1", "2", "a", "b", "arg")).To(Equal("*2\r\n$1\r\na\r\n$1\r\nb\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "GETKEYS", "PING", "hello")).To(Equal("-ERR The command has no key arguments"))
+		Expect(run(s, c, conn, "COMMAND", "GETKEYS", "GET", "a", "b")).To(Equal("-ERR Invalid number of arguments specified for command"))
+		Expect(run(s, c, conn, "COMMAND", "GETKEYS", "NOSUCH", "a")).To(Equal("-ERR Invalid command specified"))
+	})
+
+	It("should list the names of the commands that pass the filter", func() {
+		Expect(run(s, c, conn, "COMMAND", "LIST")).To(ContainSubstring("$11\r\nclient|list\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "LIST", "FILTERBY", "PATTERN", "slowlog*")).To(Equal(
+			"*4\r\n$7\r\nslowlog\r\n$11\r\nslowlog|get\r\n$11\r\nslowlog|len\r\n$13\r\nslowlog|reset\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "LIST", "FILTERBY", "ACLCAT", "transaction")).To(Equal(
+			"*5\r\n$7\r\ndiscard\r\n$4\r\nexec\r\n$5\r\nmulti\r\n$7\r\nunwatch\r\n$5\r\nwatch\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "LIST", "FILTERBY", "MODULE", "search")).To(Equal("*0\r\n"))
+		Expect(run(s, c, conn, "COMMAND", "LIST", "FILTERBY", "NAME")).To(Equal("-ERR syntax error"))
+	})
+})
@@ -0,0 +1,101 @@
+package commandhandler
+
+import (
+	"errors"
+
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// EXEC dispatches the queued commands just like the ones received from the network,
+// so it is registered here rather than in the eval package.
+func init() {
+	eval.CommandMap[eval.EXEC] = &eval.Command{
+		Name:       eval.EXEC,
+		Arity:      1,
+		Flags:      eval.FlagNoScript | eval.FlagLoading | eval.FlagStale,
+		ClientEval: evalExec,
+		Categories: []string{acl.CategorySlow, acl.CategoryTransaction},
+	}
+}
+
+// the client whose transaction EXEC is running, if any.
+var execClient *client.Client
+
+// queues the command in the client's transaction and replies with QUEUED. Commands
+// that can't be run are rejected right away and mark the transaction as aborted.
+func queueCommand(cmd *eval.RedisCmd, c *client.Client) error {
+	if err := validate(cmd, c); err != nil {
+		c.Transaction.Aborted = true
+		return err
+	}
+
+	c.Transaction.Queue = append(c.Transaction.Queue, client.QueuedCommand{
+		Cmd:  cmd.Cmd,
+		Args: cmd.Args,
+	})
+
+	c.Write(resp.Encode("QUEUED", true))
+	return nil
+}
+
+// evalExec processes the EXEC command and runs all the commands queued since MULTI,
+// replying with an array of their replies. The transaction is not run and the reply is null
+// if any of the keys watched by the client was modified.
+func evalExec(args []string, c *client.Client, s store.Store) *eval.EvalResult {
+	transaction := c.Transaction
+	if transaction == nil {
+		return &eval.EvalResult{
+			Error:    errors.New("ERR EXEC without MULTI"),
+			Response: nil,
+		}
+	}
+
+	c.Transaction = nil
+	watchedKeysModified := eval.WatchedKeysModified(c)
+	eval.UnwatchAllKeys(c)
+
+	if transaction.Aborted {
+		return &eval.EvalResult{
+			Error:    errors.New("EXECABORT Transaction discarded because of previous errors."),
+			Response: nil,
+		}
+	}
+
+	if watchedKeysModified {
+		nullReply := resp.NullArray
+		if c.Protocol == resp.Resp3 {
+			nullReply = resp.Null
+		}
+		return &eval.EvalResult{
+			Response: nullReply,
+			Error:    nil,
+		}
+	}
+
+	execClient = c
+	defer func() { execClient = nil }()
+
+	replies := make([][]byte, 0, len(transaction.Queue))
+	for _, queued := range transaction.Queue {
+		result := execute(&eval.RedisCmd{Cmd: queued.Cmd, Args: queued.Args}, s, c)
+		if result.Error != nil {
+			replies = append(replies, resp.Encode(result.Error, false))
+			continue
+		}
+		replies = append(replies, result.Response)
+
+		// the commands queued after a SELECT run against the newly selected database.
+		if queued.Cmd == eval.SELECT {
+			s = selectedDb(c)
+		}
+	}
+
+	return &eval.EvalResult{
+		Response: resp.EncodeRawArray(replies),
+		Error:    nil,
+	}
+}
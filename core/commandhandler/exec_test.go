@@ -0,0 +1,149 @@
+package commandhandler_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/commandhandler"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+func TestCommandHandler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CommandHandler Suite")
+}
+
+// runs the command on behalf of the client and returns its reply.
+func run(s store.Store, c *client.Client, conn *bytes.Buffer, cmd string, args ...string) string {
+	err := commandhandler.EvalAndRespond(&eval.RedisCmd{Cmd: cmd, Args: args}, s, c)
+	if err != nil {
+		return "-" + err.Error()
+	}
+
+	_, err = c.Flush()
+	Expect(err).ToNot(HaveOccurred())
+
+	reply := conn.String()
+	conn.Reset()
+	return reply
+}
+
+var _ = Describe("Transactions", func() {
+	var (
+		s     *store.DataStore
+		c     *client.Client
+		other *client.Client
+		conn  *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		// watched keys are looked up in the databases of the server.
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+		other = client.NewClient(-1, &bytes.Buffer{})
+	})
+
+	AfterEach(func() {
+		s.Reset()
+	})
+
+	It("should queue the commands until EXEC runs them", func() {
+		Expect(run(s, c, conn, "MULTI")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+QUEUED\r\n"))
+		Expect(run(s, c, conn, "GET", "key")).To(Equal("+QUEUED\r\n"))
+		Expect(s.Get("key")).To(BeNil())
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*2\r\n+OK\r\n$5\r\nvalue\r\n"))
+		Expect(c.Transaction).To(BeNil())
+	})
+
+	It("should drop the queued commands on DISCARD", func() {
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "value")
+
+		Expect(run(s, c, conn, "DISCARD")).To(Equal("+OK\r\n"))
+		Expect(s.Get("key")).To(BeNil())
+		Expect(run(s, c, conn, "EXEC")).To(Equal("-ERR EXEC without MULTI"))
+	})
+
+	It("should abort the transaction if a command could not be queued", func() {
+		run(s, c, conn, "MULTI")
+		Expect(run(s, c, conn, "NOTACOMMAND")).To(HavePrefix("-ERR unknown command"))
+		run(s, c, conn, "SET", "key", "value")
+
+		Expect(run(s, c, conn, "EXEC")).To(HavePrefix("-EXECABORT"))
+		Expect(s.Get("key")).To(BeNil())
+	})
+
+	It("should not run the transaction if a watched key was modified", func() {
+		run(s, c, conn, "WATCH", "key")
+		run(s, other, &bytes.Buffer{}, "SET", "key", "theirs")
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "mine")
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*-1\r\n"))
+		Expect(s.Get("key").Value).To(Equal("theirs"))
+		Expect(c.WatchedKeys).To(BeEmpty())
+	})
+
+	It("should treat evicted watched keys as modified", func() {
+		s.Put("key", "value", nil)
+		run(s, c, conn, "WATCH", "key")
+		s.DeleteEvicted("key")
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "mine")
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*-1\r\n"))
+	})
+
+	It("should run the transaction if the watched keys were left untouched", func() {
+		run(s, c, conn, "WATCH", "key")
+		run(s, other, &bytes.Buffer{}, "SET", "unrelated", "value")
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "mine")
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*1\r\n+OK\r\n"))
+	})
+
+	It("should forget the watched keys on UNWATCH", func() {
+		run(s, c, conn, "WATCH", "key")
+		Expect(run(s, c, conn, "UNWATCH")).To(Equal("+OK\r\n"))
+		run(s, other, &bytes.Buffer{}, "SET", "key", "theirs")
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "mine")
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*1\r\n+OK\r\n"))
+	})
+
+	It("should run the commands queued after a SELECT against the selected database", func() {
+		defer store.GetDatabases().Get(1).Reset()
+
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SELECT", "1")
+		run(s, c, conn, "SET", "key", "value")
+
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*2\r\n+OK\r\n+OK\r\n"))
+		Expect(s.Get("key")).To(BeNil())
+		Expect(store.GetDatabases().Get(1).Get("key").Value).To(Equal("value"))
+	})
+
+	It("should track the watched keys of every database", func() {
+		db1 := store.GetDatabases().Get(1)
+		defer db1.Reset()
+
+		run(s, c, conn, "SELECT", "1")
+		run(db1, c, conn, "WATCH", "key")
+		run(db1, c, conn, "SELECT", "0")
+		run(db1, other, &bytes.Buffer{}, "SET", "key", "theirs")
+
+		run(s, c, conn, "MULTI")
+		run(s, c, conn, "SET", "key", "mine")
+		Expect(run(s, c, conn, "EXEC")).To(Equal("*-1\r\n"))
+		Expect(c.WatchedKeys).To(BeEmpty())
+	})
+})
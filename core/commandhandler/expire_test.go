@@ -0,0 +1,108 @@
+package commandhandler_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+var _ = Describe("EXPIRE", func() {
+	var (
+		s    *store.DataStore
+		c    *client.Client
+		conn *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+	})
+
+	AfterEach(func() {
+		c.Release()
+		s.Reset()
+	})
+
+	It("should set the expiry of the keys without one", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":-1\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "100")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":100\r\n"))
+	})
+
+	It("should replace the expiry of the keys with one", func() {
+		Expect(run(s, c, conn, "SET", "key", "value", "EX", "10")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "100")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":100\r\n"))
+	})
+
+	It("should not set the expiry of the keys that don't exist", func() {
+		Expect(run(s, c, conn, "EXPIRE", "missing", "100")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "PEXPIRE", "missing", "100")).To(Equal(":0\r\n"))
+	})
+
+	It("should delete the keys expired with a TTL that isn't positive", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "0")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "GET", "key")).To(Equal("$-1\r\n"))
+	})
+
+	It("should set the expiry in milliseconds with PEXPIRE", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "PEXPIRE", "key", "300")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "GET", "key")).To(Equal("$5\r\nvalue\r\n"))
+		Eventually(func() string {
+			return run(s, c, conn, "GET", "key")
+		}, time.Second).Should(Equal("$-1\r\n"))
+	})
+
+	It("should only set the expiry with NX and XX if the key has none or one", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "XX")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "nx")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "200", "NX")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "200", "XX")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":200\r\n"))
+	})
+
+	It("should only set the expiry with GT and LT if it's later or earlier", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		// a key without an expiry never expires.
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "GT")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "LT")).To(Equal(":1\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "200", "LT")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "PEXPIRE", "key", "50000", "LT")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "10", "GT")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "200", "XX", "GT")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":200\r\n"))
+	})
+
+	It("should reject the options that can't be used together or don't exist", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "NX", "XX")).To(Equal("-ERR NX and XX, GT or LT options at the same time are not compatible"))
+		Expect(run(s, c, conn, "EXPIRE", "key", "100", "GT", "LT")).To(Equal("-ERR GT and LT options at the same time are not compatible"))
+		Expect(run(s, c, conn, "PEXPIRE", "key", "100", "KEEPTTL")).To(Equal("-ERR Unsupported option KEEPTTL"))
+		Expect(run(s, c, conn, "TTL", "key")).To(Equal(":-1\r\n"))
+	})
+
+	It("should reject the SET expiries that aren't positive", func() {
+		Expect(run(s, c, conn, "SET", "key", "value")).To(Equal("+OK\r\n"))
+
+		Expect(run(s, c, conn, "SET", "key", "other", "EX", "-5")).To(Equal("-ERR invalid expire time in 'set' command"))
+		Expect(run(s, c, conn, "SET", "key", "other", "PX", "0")).To(Equal("-ERR invalid expire time in 'set' command"))
+		Expect(run(s, c, conn, "GET", "key")).To(Equal("$5\r\nvalue\r\n"))
+	})
+})
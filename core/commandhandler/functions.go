@@ -0,0 +1,232 @@
+package commandhandler
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the FUNCTION command.
+const (
+	functionLoad    = "LOAD"
+	functionList    = "LIST"
+	functionDelete  = "DELETE"
+	functionDump    = "DUMP"
+	functionRestore = "RESTORE"
+	functionFlush   = "FLUSH"
+	functionKill    = "KILL"
+)
+
+func init() {
+	eval.CommandMap[eval.FCALL] = &eval.Command{
+		Name:       eval.FCALL,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagMayReplicate,
+		ClientEval: evalFcall(false),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyReadWrite}},
+	}
+
+	eval.CommandMap[eval.FCALL_RO] = &eval.Command{
+		Name:       eval.FCALL_RO,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagReadOnly,
+		ClientEval: evalFcall(true),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyRead}},
+	}
+
+	eval.CommandMap[eval.FUNCTION] = &eval.Command{
+		Name:       eval.FUNCTION,
+		Arity:      -2,
+		ClientEval: evalFunction,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: eval.Subcommands(eval.FUNCTION,
+			eval.Subcommand(functionLoad, -3, eval.FlagWrite|eval.FlagDenyOom|eval.FlagNoScript, acl.CategoryWrite, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionList, -2, eval.FlagNoScript, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionDelete, 3, eval.FlagWrite|eval.FlagNoScript, acl.CategoryWrite, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionDump, 2, eval.FlagNoScript, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionRestore, -3, eval.FlagWrite|eval.FlagDenyOom|eval.FlagNoScript, acl.CategoryWrite, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionFlush, -2, eval.FlagWrite|eval.FlagNoScript, acl.CategoryWrite, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(functionKill, 2, eval.FlagNoScript|eval.FlagAllowBusy, acl.CategorySlow, acl.CategoryScripting),
+		),
+	}
+}
+
+// returns the eval function of FCALL and FCALL_RO, which call a function registered
+// by a library: FCALL function numkeys [key ...] [arg ...]. Functions registered with
+// the no-writes flag, and all the functions called with FCALL_RO, are not allowed to
+// call commands that modify the dataset.
+func evalFcall(readOnly bool) func([]string, *client.Client, store.Store) *eval.EvalResult {
+	return func(args []string, c *client.Client, s store.Store) *eval.EvalResult {
+		keys, fnArgs, err := parseScriptKeys(args[1:])
+		if err != nil {
+			return &eval.EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+
+		fn := eval.InstanceOf(c).Scripts.GetFunction(args[0])
+		if fn == nil {
+			return &eval.EvalResult{
+				Error:    errors.New("ERR Function not found"),
+				Response: nil,
+			}
+		}
+
+		noWrites := fn.HasFlag(scripting.FlagNoWrites)
+		if readOnly && !noWrites {
+			return &eval.EvalResult{
+				Error:    errors.New("ERR Can not execute a script with write flag using *_ro command."),
+				Response: nil,
+			}
+		}
+
+		response, err := eval.InstanceOf(c).Scripts.CallFunction(fn.Name, keys, fnArgs, c, scriptCallHandler(s, c, noWrites))
+
+		return &eval.EvalResult{
+			Response: response,
+			Error:    err,
+		}
+	}
+}
+
+// evalFunction processes the FUNCTION command, which manages the function libraries with
+// its LOAD, LIST, DELETE, DUMP, RESTORE, FLUSH and KILL subcommands.
+func evalFunction(args []string, c *client.Client, s store.Store) *eval.EvalResult {
+	var err error
+	var response []byte = resp.Encode("OK", true)
+	scripts := eval.InstanceOf(c).Scripts
+
+	switch strings.ToUpper(args[0]) {
+	case functionLoad:
+		response, err = functionLoadCommand(args[1:], scripts)
+	case functionList:
+		response, err = functionListCommand(args[1:], scripts, c.Protocol == resp.Resp3)
+	case functionDelete:
+		err = scripts.DeleteLibrary(args[1])
+	case functionDump:
+		response = resp.Encode(string(scripts.DumpFunctions()), false)
+	case functionRestore:
+		err = functionRestoreCommand(args[1:], scripts)
+	case functionFlush:
+		// the libraries are dropped right away, so ASYNC and SYNC behave the same.
+		if len(args) > 2 || (len(args) == 2 && !strings.EqualFold(args[1], "ASYNC") && !strings.EqualFold(args[1], "SYNC")) {
+			err = errors.New("ERR FUNCTION FLUSH only supports SYNC|ASYNC option")
+			break
+		}
+		scripts.FlushFunctions()
+	case functionKill:
+		err = scripts.Kill()
+	default:
+		err = commons.UnknownSubcommandErr(eval.FUNCTION, args[0])
+	}
+
+	if err != nil {
+		return &eval.EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+	return &eval.EvalResult{
+		Response: response,
+		Error:    nil,
+	}
+}
+
+// FUNCTION LOAD [REPLACE] code
+func functionLoadCommand(args []string, scripts *scripting.Scripts) ([]byte, error) {
+	if len(args) > 2 {
+		return nil, commons.WrongNumberOfArgumentsErr(eval.FUNCTION + "|" + functionLoad)
+	}
+
+	replace := false
+	if len(args) == 2 {
+		if !strings.EqualFold(args[0], "REPLACE") {
+			return nil, errors.New("ERR Unknown option given: " + args[0])
+		}
+		replace = true
+	}
+
+	name, err := scripts.LoadLibrary(args[len(args)-1], replace)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Encode(name, false), nil
+}
+
+// FUNCTION LIST [LIBRARYNAME pattern] [WITHCODE]
+func functionListCommand(args []string, scripts *scripting.Scripts, resp3 bool) ([]byte, error) {
+	pattern := ""
+	withCode := false
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "WITHCODE") && !withCode:
+			withCode = true
+		case strings.EqualFold(args[i], "LIBRARYNAME") && pattern == "":
+			if i+1 >= len(args) {
+				return nil, errors.New("ERR library name argument was not given")
+			}
+			i++
+			pattern = args[i]
+		default:
+			return nil, errors.New("ERR Unknown argument " + args[i])
+		}
+	}
+
+	libraries := scripts.Libraries(pattern)
+	items := make([][]byte, 0, len(libraries))
+	for _, lib := range libraries {
+		functions := make([][]byte, 0, len(lib.Functions))
+		for _, fn := range lib.SortedFunctions() {
+			var description interface{} = nil
+			if fn.Description != "" {
+				description = fn.Description
+			}
+
+			functions = append(functions, resp.EncodeMap([]interface{}{
+				"name", fn.Name,
+				"description", description,
+				"flags", fn.Flags,
+			}, resp3))
+		}
+
+		pairs := []interface{}{
+			"library_name", lib.Name,
+			"engine", lib.Engine,
+			"functions", resp.Raw(resp.EncodeRawArray(functions)),
+		}
+		if withCode {
+			pairs = append(pairs, "library_code", lib.Code)
+		}
+		items = append(items, resp.EncodeMap(pairs, resp3))
+	}
+
+	return resp.EncodeRawArray(items), nil
+}
+
+// FUNCTION RESTORE payload [FLUSH | APPEND | REPLACE]
+func functionRestoreCommand(args []string, scripts *scripting.Scripts) error {
+	if len(args) > 2 {
+		return commons.WrongNumberOfArgumentsErr(eval.FUNCTION + "|" + functionRestore)
+	}
+
+	policy := scripting.RestoreAppend
+	if len(args) == 2 {
+		policy = strings.ToUpper(args[1])
+		if policy != scripting.RestoreAppend && policy != scripting.RestoreReplace && policy != scripting.RestoreFlush {
+			return errors.New("ERR Wrong restore policy given, value should be either FLUSH, APPEND or REPLACE.")
+		}
+	}
+
+	return scripts.RestoreFunctions([]byte(args[0]), policy)
+}
@@ -0,0 +1,72 @@
+package commandhandler_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+var _ = Describe("Hashes", func() {
+	var (
+		s    *store.DataStore
+		c    *client.Client
+		conn *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+	})
+
+	AfterEach(func() {
+		c.Release()
+		s.Reset()
+	})
+
+	It("should set, get and delete the fields of the hashes", func() {
+		Expect(run(s, c, conn, "HSET", "key", "a", "1", "b", "2")).To(Equal(":2\r\n"))
+		Expect(run(s, c, conn, "HSET", "key", "a", "3")).To(Equal(":0\r\n"))
+
+		Expect(run(s, c, conn, "HGET", "key", "a")).To(Equal("$1\r\n3\r\n"))
+		Expect(run(s, c, conn, "HGET", "key", "missing")).To(Equal("$-1\r\n"))
+		Expect(run(s, c, conn, "HEXISTS", "key", "b")).To(Equal(":1\r\n"))
+		Expect(run(s, c, conn, "HLEN", "key")).To(Equal(":2\r\n"))
+		Expect(run(s, c, conn, "TYPE", "key")).To(Equal("+hash\r\n"))
+
+		Expect(run(s, c, conn, "HDEL", "key", "a", "b", "missing")).To(Equal(":2\r\n"))
+		Expect(run(s, c, conn, "EXISTS", "key")).To(Equal(":0\r\n"))
+		Expect(run(s, c, conn, "HLEN", "key")).To(Equal(":0\r\n"))
+	})
+
+	It("should reply with all the fields, as a map in RESP3", func() {
+		Expect(run(s, c, conn, "HSET", "key", "a", "1")).To(Equal(":1\r\n"))
+
+		Expect(run(s, c, conn, "HGETALL", "key")).To(Equal("*2\r\n$1\r\na\r\n$1\r\n1\r\n"))
+		Expect(run(s, c, conn, "HGETALL", "missing")).To(Equal("*0\r\n"))
+
+		c.Protocol = resp.Resp3
+		Expect(run(s, c, conn, "HGETALL", "key")).To(Equal("%1\r\n$1\r\na\r\n$1\r\n1\r\n"))
+	})
+
+	It("should not mix up the hashes with the strings", func() {
+		Expect(run(s, c, conn, "SET", "string", "value")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "HSET", "hash", "a", "1")).To(Equal(":1\r\n"))
+
+		Expect(run(s, c, conn, "HSET", "string", "a", "1")).To(HavePrefix("-WRONGTYPE"))
+		Expect(run(s, c, conn, "HGET", "string", "a")).To(HavePrefix("-WRONGTYPE"))
+		Expect(run(s, c, conn, "GET", "hash")).To(HavePrefix("-WRONGTYPE"))
+
+		// SET replaces the value whatever its type.
+		Expect(run(s, c, conn, "SET", "hash", "value")).To(Equal("+OK\r\n"))
+		Expect(run(s, c, conn, "GET", "hash")).To(Equal("$5\r\nvalue\r\n"))
+	})
+
+	It("should only take the fields along with their values", func() {
+		Expect(run(s, c, conn, "HSET", "key", "a", "1", "b")).To(Equal("-ERR wrong number of arguments for 'hset' command"))
+	})
+})
@@ -0,0 +1,32 @@
+package commandhandler
+
+import (
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+)
+
+// returns true if the command has to wait until the clients are unpaused, see CLIENT PAUSE.
+// while only the writes are paused, EXEC waits if any of the queued commands may write.
+func ShouldPostpone(cmd *eval.RedisCmd, c *client.Client) bool {
+	paused, writesOnly := client.Paused()
+	if !paused {
+		return false
+	}
+	if !writesOnly || mayWrite(cmd.Cmd, cmd.Args) {
+		return true
+	}
+
+	if cmd.Cmd == eval.EXEC && c.Transaction != nil {
+		for _, queued := range c.Transaction.Queue {
+			if mayWrite(queued.Cmd, queued.Args) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// the commands that may modify the dataset or propagate messages are postponed by CLIENT PAUSE WRITE.
+func mayWrite(name string, args []string) bool {
+	return eval.CommandMap[name].Resolve(args).HasFlag(eval.FlagWrite | eval.FlagMayReplicate)
+}
@@ -1,23 +1,43 @@
 package commandhandler
 
 import (
-	"io"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
 	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/stats"
 	"github.com/shashwatrathod/redis-internals/core/store"
 )
 
 // EvalAndRespond processes the specified Redis command and sends the appropriate
-// response over the provided network connection.
-func EvalAndRespond(cmd *eval.RedisCmd, s store.Store, c io.ReadWriter) error {
-	var command *eval.Command = eval.CommandMap[cmd.Cmd]
+// response to the client that issued it. Commands issued by a client in a transaction
+// are queued instead, until EXEC runs them. s is the database selected by the client.
+func EvalAndRespond(cmd *eval.RedisCmd, s store.Store, c *client.Client) error {
+	err := evalAndRespond(cmd, s, c)
+	resetCaching(cmd, c)
+	if err != nil {
+		eval.InstanceOf(c).Stats.RecordErrorReply(err)
+	}
+	return err
+}
 
-	if command == nil || command.Eval == nil {
-		return commons.UnknownCommandErr(cmd.Cmd, cmd.Args)
+func evalAndRespond(cmd *eval.RedisCmd, s store.Store, c *client.Client) error {
+	c.LastCmd = eval.FullCommandName(cmd)
+
+	if eval.InstanceOf(c).Scripts.IsBusy() && !allowedWhileBusy(cmd) {
+		eval.InstanceOf(c).Stats.RecordRejectedCommand(cmd.Cmd)
+		return errors.New("BUSY Redis is busy running a script. You can only call SCRIPT KILL, FUNCTION KILL or SHUTDOWN NOSAVE.")
 	}
 
-	evalResult := command.Eval(cmd.Args, s)
+	if c.Transaction != nil && !eval.TransactionControlCommands[cmd.Cmd] {
+		return queueCommand(cmd, c)
+	}
+
+	evalResult := execute(cmd, s, c)
 
 	if evalResult.Error != nil {
 		return evalResult.Error
@@ -26,3 +46,83 @@ func EvalAndRespond(cmd *eval.RedisCmd, s store.Store, c io.ReadWriter) error {
 	c.Write(evalResult.Response)
 	return nil
 }
+
+// Execute runs the command on behalf of the client and returns its result instead of writing it to
+// the client, eg. for the commands run through an embedded engine. s is the database selected by
+// the client.
+func Execute(cmd *eval.RedisCmd, s store.Store, c *client.Client) *eval.EvalResult {
+	result := execute(cmd, s, c)
+	if result.Error != nil {
+		eval.InstanceOf(c).Stats.RecordErrorReply(result.Error)
+	}
+	return result
+}
+
+// looks up the command and runs it on behalf of the client.
+func execute(cmd *eval.RedisCmd, s store.Store, c *client.Client) *eval.EvalResult {
+	if err := validate(cmd, c); err != nil {
+		return &eval.EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	command := eval.CommandMap[cmd.Cmd]
+	eval.InstanceOf(c).Stats.TotalCommandsProcessed.Add(1)
+
+	if c.NoTouch && cmd.Cmd != eval.TOUCH {
+		s.SetNoTouch(true)
+		defer s.SetNoTouch(false)
+	}
+
+	start := time.Now()
+	var result *eval.EvalResult
+	if command.ClientEval != nil {
+		result = command.ClientEval(cmd.Args, c, s)
+	} else {
+		result = command.Eval(cmd.Args, s)
+	}
+	duration := time.Since(start)
+
+	instance := eval.InstanceOf(c)
+	instance.Stats.RecordCommand(cmd.Cmd, duration, result.Error != nil)
+	instance.LatencyMonitor.Sample(stats.LatencyEventCommand, duration)
+	instance.SlowLog.Record(cmd.Cmd, command.RedactArgs(cmd.Args), duration, c.Addr, c.Name)
+
+	if result.Error == nil {
+		trackReadKeys(cmd, c)
+	}
+	return result
+}
+
+// returns an error if the command can't be run by the client in its current state.
+func validate(cmd *eval.RedisCmd, c *client.Client) error {
+	var command *eval.Command = eval.CommandMap[cmd.Cmd]
+
+	if command == nil || (command.Eval == nil && command.ClientEval == nil) {
+		return commons.UnknownCommandErr(cmd.Cmd, cmd.Args)
+	}
+
+	// the arity counts the command name along with its arguments.
+	if resolved := command.Resolve(cmd.Args); !resolved.AcceptsArgCount(len(cmd.Args) + 1) {
+		return commons.WrongNumberOfArgumentsErr(resolved.Name)
+	}
+
+	if err := checkPermissions(cmd, c); err != nil {
+		return err
+	}
+
+	if c.InSubscriberMode() && !eval.SubscriberModeCommands[cmd.Cmd] {
+		eval.InstanceOf(c).Stats.RecordRejectedCommand(cmd.Cmd)
+		return fmt.Errorf("ERR Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING are allowed in this context",
+			strings.ToLower(cmd.Cmd))
+	}
+
+	return nil
+}
+
+// returns the database selected by the client, for the commands that follow
+// a SELECT within a transaction or a script.
+func selectedDb(c *client.Client) store.Store {
+	return eval.DatabasesOf(c).Get(c.Db)
+}
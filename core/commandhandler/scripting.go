@@ -0,0 +1,226 @@
+package commandhandler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the SCRIPT command.
+const (
+	scriptLoad   = "LOAD"
+	scriptExists = "EXISTS"
+	scriptFlush  = "FLUSH"
+	scriptKill   = "KILL"
+)
+
+func init() {
+	eval.CommandMap[eval.EVAL] = &eval.Command{
+		Name:       eval.EVAL,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagMayReplicate,
+		ClientEval: evalScript(false, false),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyReadWrite}},
+	}
+
+	eval.CommandMap[eval.EVALSHA] = &eval.Command{
+		Name:       eval.EVALSHA,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagMayReplicate,
+		ClientEval: evalScript(true, false),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyReadWrite}},
+	}
+
+	eval.CommandMap[eval.EVAL_RO] = &eval.Command{
+		Name:       eval.EVAL_RO,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagReadOnly,
+		ClientEval: evalScript(false, true),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyRead}},
+	}
+
+	eval.CommandMap[eval.EVALSHA_RO] = &eval.Command{
+		Name:       eval.EVALSHA_RO,
+		Arity:      -3,
+		Flags:      eval.FlagNoScript | eval.FlagStale | eval.FlagReadOnly,
+		ClientEval: evalScript(true, true),
+		Categories: []string{acl.CategorySlow, acl.CategoryScripting},
+		KeySpecs:   []eval.KeySpec{{First: 2, KeyNum: true, Access: eval.KeyRead}},
+	}
+
+	eval.CommandMap[eval.SCRIPT] = &eval.Command{
+		Name:       eval.SCRIPT,
+		Arity:      -2,
+		ClientEval: evalScriptCommand,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: eval.Subcommands(eval.SCRIPT,
+			eval.Subcommand(scriptLoad, 3, eval.FlagNoScript|eval.FlagStale, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(scriptExists, -3, eval.FlagNoScript, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(scriptFlush, -2, eval.FlagNoScript, acl.CategorySlow, acl.CategoryScripting),
+			eval.Subcommand(scriptKill, 2, eval.FlagNoScript|eval.FlagAllowBusy, acl.CategorySlow, acl.CategoryScripting),
+		),
+	}
+}
+
+// returns the eval function of the EVAL family of commands, which run a script
+// given either as its body or as its SHA1 digest: EVAL script numkeys [key ...] [arg ...].
+// Read-only scripts are not allowed to call commands that modify the dataset.
+func evalScript(bySha bool, readOnly bool) func([]string, *client.Client, store.Store) *eval.EvalResult {
+	return func(args []string, c *client.Client, s store.Store) *eval.EvalResult {
+		keys, scriptArgs, err := parseScriptKeys(args[1:])
+		if err != nil {
+			return &eval.EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+
+		sha := args[0]
+		if !bySha {
+			// like Redis, the scripts run with EVAL are cached so that they can be run with EVALSHA later.
+			if sha, err = eval.InstanceOf(c).Scripts.Load(args[0]); err != nil {
+				return &eval.EvalResult{
+					Error:    err,
+					Response: nil,
+				}
+			}
+		}
+
+		response, err := eval.InstanceOf(c).Scripts.Run(sha, keys, scriptArgs, c, scriptCallHandler(s, c, readOnly))
+
+		return &eval.EvalResult{
+			Response: response,
+			Error:    err,
+		}
+	}
+}
+
+// splits the "numkeys [key ...] [arg ...]" arguments of a script into its keys and arguments.
+func parseScriptKeys(args []string) ([]string, []string, error) {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, nil, errors.New("ERR value is not an integer or out of range")
+	}
+	if numKeys < 0 {
+		return nil, nil, errors.New("ERR Number of keys can't be negative")
+	}
+	if numKeys > len(args)-1 {
+		return nil, nil, errors.New("ERR Number of keys can't be greater than number of args")
+	}
+
+	return args[1 : numKeys+1], args[numKeys+1:], nil
+}
+
+// returns the handler that runs the commands called by a script through the same dispatch
+// as the commands received from the network. The script starts out on the database selected
+// by the client c running it, and can only run the commands the user of the client can.
+func scriptCallHandler(s store.Store, c *client.Client, readOnly bool) scripting.CallHandler {
+	instance := eval.InstanceOf(c)
+	scriptClient := instance.ScriptClient
+	scriptClient.Db = c.Db
+	scriptClient.User = c.User
+	scriptClient.Authenticated = true
+
+	return func(cmd string, args []string) ([]byte, error) {
+		command := eval.CommandMap[cmd].Resolve(args)
+		if command.HasFlag(eval.FlagNoScript) {
+			return nil, errors.New("ERR This Redis command is not allowed from script")
+		}
+
+		if command.HasFlag(eval.FlagWrite) {
+			if readOnly {
+				return nil, errors.New("ERR Write commands are not allowed from read-only scripts.")
+			}
+			instance.Scripts.MarkWrite()
+		}
+
+		result := execute(&eval.RedisCmd{Cmd: cmd, Args: args}, s, scriptClient)
+		if result.Error == nil {
+			// the keys read by the script are tracked for the client running it.
+			trackReadKeys(&eval.RedisCmd{Cmd: cmd, Args: args}, c)
+		}
+		if cmd == eval.SELECT && result.Error == nil {
+			s = selectedDb(scriptClient)
+		}
+		return result.Response, result.Error
+	}
+}
+
+// evalScriptCommand processes the SCRIPT command with its LOAD, EXISTS, FLUSH and KILL subcommands.
+func evalScriptCommand(args []string, c *client.Client, s store.Store) *eval.EvalResult {
+	scripts := eval.InstanceOf(c).Scripts
+	subcommand := strings.ToUpper(args[0])
+
+	switch {
+	case subcommand == scriptLoad:
+		sha, err := scripts.Load(args[1])
+		if err != nil {
+			return &eval.EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+		return &eval.EvalResult{
+			Response: resp.Encode(sha, false),
+			Error:    nil,
+		}
+	case subcommand == scriptExists:
+		exists := make([]interface{}, 0, len(args)-1)
+		for _, sha := range args[1:] {
+			if scripts.Exists(sha) {
+				exists = append(exists, 1)
+			} else {
+				exists = append(exists, 0)
+			}
+		}
+		return &eval.EvalResult{
+			Response: resp.Encode(exists, false),
+			Error:    nil,
+		}
+	case subcommand == scriptFlush && len(args) <= 2:
+		// the cache is dropped right away, so ASYNC and SYNC behave the same.
+		if len(args) == 2 && !strings.EqualFold(args[1], "ASYNC") && !strings.EqualFold(args[1], "SYNC") {
+			return &eval.EvalResult{
+				Error:    errors.New("ERR SCRIPT FLUSH only support SYNC|ASYNC option"),
+				Response: nil,
+			}
+		}
+		scripts.Flush()
+		return &eval.EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case subcommand == scriptKill:
+		if err := scripts.Kill(); err != nil {
+			return &eval.EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+		return &eval.EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	default:
+		return &eval.EvalResult{
+			Error:    commons.UnknownSubcommandErr(eval.SCRIPT, args[0]),
+			Response: nil,
+		}
+	}
+}
+
+// returns true if the command can be run while the server is busy running a script, eg. SCRIPT KILL.
+func allowedWhileBusy(cmd *eval.RedisCmd) bool {
+	return eval.CommandMap[cmd.Cmd].Resolve(cmd.Args).HasFlag(eval.FlagAllowBusy)
+}
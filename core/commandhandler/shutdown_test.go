@@ -0,0 +1,61 @@
+package commandhandler_test
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/shutdown"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+var _ = Describe("SHUTDOWN", func() {
+	var (
+		s    *store.DataStore
+		c    *client.Client
+		conn *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		s = store.GetDatabases().Get(0)
+		conn = &bytes.Buffer{}
+		c = client.NewClient(-1, conn)
+	})
+
+	AfterEach(func() {
+		shutdown.Abort()
+	})
+
+	It("should ask the server to shut down without replying", func() {
+		Expect(run(s, c, conn, "SHUTDOWN", "nosave", "now")).To(BeEmpty())
+
+		req := shutdown.Pending()
+		Expect(req).ToNot(BeNil())
+		Expect(req.Client).To(Equal(c))
+		Expect(req.Options).To(Equal(shutdown.Options{NoSave: true, Now: true}))
+	})
+
+	It("should abort the shutdown in progress", func() {
+		Expect(run(s, c, conn, "SHUTDOWN", "ABORT")).To(Equal("-ERR No shutdown in progress."))
+
+		run(s, c, conn, "SHUTDOWN")
+		Expect(run(s, c, conn, "SHUTDOWN", "ABORT")).To(Equal("+OK\r\n"))
+		Expect(shutdown.Pending()).To(BeNil())
+	})
+
+	It("should refuse to save the dataset unless forced", func() {
+		Expect(run(s, c, conn, "SHUTDOWN", "SAVE")).To(Equal("-ERR Errors trying to SHUTDOWN. Check logs."))
+		Expect(shutdown.Pending()).To(BeNil())
+
+		Expect(run(s, c, conn, "SHUTDOWN", "SAVE", "FORCE")).To(BeEmpty())
+		Expect(shutdown.Pending()).ToNot(BeNil())
+	})
+
+	It("should reject the conflicting options", func() {
+		Expect(run(s, c, conn, "SHUTDOWN", "SAVE", "NOSAVE")).To(Equal("-ERR syntax error"))
+		Expect(run(s, c, conn, "SHUTDOWN", "ABORT", "NOW")).To(Equal("-ERR syntax error"))
+		Expect(run(s, c, conn, "SHUTDOWN", "LATER")).To(Equal("-ERR syntax error"))
+		Expect(shutdown.Pending()).To(BeNil())
+	})
+})
@@ -0,0 +1,43 @@
+package commandhandler
+
+import (
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+)
+
+// remembers the keys read by the command for the client, if the client tracks the keys it reads.
+// Only the keys of the read-only commands are remembered.
+func trackReadKeys(cmd *eval.RedisCmd, c *client.Client) {
+	if c.Tracking == nil {
+		return
+	}
+
+	command := eval.CommandMap[cmd.Cmd]
+	if !command.HasFlag(eval.FlagReadOnly) {
+		return
+	}
+
+	keys := []string{}
+	for _, spec := range command.KeySpecs {
+		for _, position := range spec.Positions(cmd.Args) {
+			keys = append(keys, cmd.Args[position])
+		}
+	}
+	if len(keys) > 0 {
+		eval.InstanceOf(c).Tracker.RememberKeys(c, keys)
+	}
+}
+
+// the CACHING yes/no of the OPTIN and OPTOUT modes only applies to the command that follows it,
+// or to all the commands of the transaction that follows it.
+func resetCaching(cmd *eval.RedisCmd, c *client.Client) {
+	if c.Tracking == nil || c.Transaction != nil {
+		return
+	}
+	if cmd.Cmd == eval.CLIENT && len(cmd.Args) > 0 && strings.EqualFold(cmd.Args[0], "CACHING") {
+		return
+	}
+	c.Tracking.Caching = false
+}
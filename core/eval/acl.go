@@ -0,0 +1,435 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the ACL command.
+const (
+	aclCat     = "CAT"
+	aclDelUser = "DELUSER"
+	aclDryRun  = "DRYRUN"
+	aclGetUser = "GETUSER"
+	aclList    = "LIST"
+	aclLoad    = "LOAD"
+	aclLog     = "LOG"
+	aclSave    = "SAVE"
+	aclSetUser = "SETUSER"
+	aclUsers   = "USERS"
+	aclWhoAmI  = "WHOAMI"
+)
+
+// the rules of the ACL users name the commands, which are defined here.
+func init() {
+	acl.CommandExists = func(command string, subcommand string) bool {
+		cmd := CommandMap[strings.ToUpper(command)]
+		if cmd == nil {
+			return false
+		}
+		return subcommand == "" || cmd.Subcommands[strings.ToUpper(subcommand)] != nil
+	}
+}
+
+// the arguments of the pub/sub commands that name channels, which are checked against the channel
+// patterns of the ACL users. The patterns given to PSUBSCRIBE have to be patterns of the users.
+var channelArgs = map[string]struct {
+	all     bool
+	literal bool
+}{
+	PUBLISH:    {all: false, literal: false},
+	SPUBLISH:   {all: false, literal: false},
+	SUBSCRIBE:  {all: true, literal: false},
+	SSUBSCRIBE: {all: true, literal: false},
+	PSUBSCRIBE: {all: true, literal: true},
+}
+
+// returns why the user isn't allowed to run the command, as the reason and the object ACL LOG
+// reports, eg. "key" along with the key the user can't access. Both are empty if the user is
+// allowed to run the command.
+func CheckPermissions(u *acl.User, cmd *RedisCmd) (string, string) {
+	command := CommandMap[cmd.Cmd]
+	if command == nil {
+		return "", ""
+	}
+
+	subcommand := ""
+	categories := command.Categories
+	if sub := command.SubcommandOf(cmd.Args); sub != nil {
+		subcommand = strings.ToLower(cmd.Args[0])
+		categories = sub.Categories
+	}
+
+	if u == nil || !u.CanRunCommand(strings.ToLower(cmd.Cmd), subcommand, categories) {
+		return acl.ReasonCommand, FullCommandName(cmd)
+	}
+
+	for _, spec := range command.KeySpecs {
+		for _, position := range spec.Positions(cmd.Args) {
+			key := cmd.Args[position]
+			if !u.CanAccessKey(key, spec.Access&KeyRead != 0, spec.Access&KeyWrite != 0) {
+				return acl.ReasonKey, key
+			}
+		}
+	}
+
+	if channels, exists := channelArgs[cmd.Cmd]; exists && len(cmd.Args) > 0 {
+		names := cmd.Args[:1]
+		if channels.all {
+			names = cmd.Args
+		}
+		for _, channel := range names {
+			if !u.CanAccessChannel(channel, channels.literal) {
+				return acl.ReasonChannel, channel
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// returns the error replied when the user isn't allowed to run a command, for the reason
+// returned by CheckPermissions.
+func PermissionErr(reason string, object string, username string) error {
+	switch reason {
+	case acl.ReasonKey:
+		return errors.New("NOPERM No permissions to access a key")
+	case acl.ReasonChannel:
+		return errors.New("NOPERM No permissions to access a channel")
+	default:
+		return fmt.Errorf("NOPERM User %s has no permissions to run the '%s' command", username, object)
+	}
+}
+
+// authenticates the client as the user, and records the failed attempts in the ACL log.
+func authenticate(c *client.Client, username string, password string) error {
+	if err := InstanceOf(c).Users.Authenticate(c, username, password); err != nil {
+		InstanceOf(c).AclLog.Record(acl.ReasonAuth, acl.ContextTopLevel, AUTH, username, ClientInfoString(c))
+		return err
+	}
+	return nil
+}
+
+// redacts all the arguments of AUTH, like Redis does, the username included.
+func redactAuth(args []string) []string {
+	redacted := make([]string, len(args))
+	for i := range redacted {
+		redacted[i] = redactedArg
+	}
+	return redacted
+}
+
+// evalAuth processes the AUTH [username] password command, which authenticates the client as the
+// user, or as the default user if no user is given.
+func evalAuth(args []string, c *client.Client, s store.Store) *EvalResult {
+	var username, password string
+	switch len(args) {
+	case 1:
+		if u := InstanceOf(c).Users.GetUser(acl.DefaultUser); u.CheckPassword("") && u.Enabled() {
+			return &EvalResult{
+				Error:    errors.New("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?"),
+				Response: nil,
+			}
+		}
+		username, password = acl.DefaultUser, args[0]
+	case 2:
+		username, password = args[0], args[1]
+	default:
+		return &EvalResult{
+			Error:    commons.WrongNumberOfArgumentsErr(AUTH),
+			Response: nil,
+		}
+	}
+
+	if err := authenticate(c, username, password); err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// redacts the password rules of ACL SETUSER, eg. >password.
+func redactAcl(args []string) []string {
+	if len(args) == 0 || strings.ToUpper(args[0]) != aclSetUser {
+		return args
+	}
+
+	redacted := append([]string{}, args...)
+	for i := 2; i < len(redacted); i++ {
+		if acl.IsPasswordRule(redacted[i]) {
+			redacted[i] = redactedArg
+		}
+	}
+	return redacted
+}
+
+// evalAcl processes the ACL command, which manages the users the clients authenticate as.
+func evalAcl(args []string, c *client.Client, s store.Store) *EvalResult {
+	subcommand := strings.ToUpper(args[0])
+	wrongNumberOfArguments := &EvalResult{
+		Error:    commons.WrongNumberOfArgumentsErr(ACL + "|" + subcommand),
+		Response: nil,
+	}
+
+	switch subcommand {
+	case aclCat:
+		if len(args) > 2 {
+			return wrongNumberOfArguments
+		}
+		if len(args) == 1 {
+			return &EvalResult{
+				Response: resp.Encode(acl.Categories, false),
+				Error:    nil,
+			}
+		}
+		return evalAclCat(strings.ToLower(args[1]))
+	case aclSetUser:
+		if err := InstanceOf(c).Users.SetUser(args[1], args[2:]...); err != nil {
+			return &EvalResult{
+				Error:    errors.New("ERR " + err.Error()),
+				Response: nil,
+			}
+		}
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case aclGetUser:
+		return evalAclGetUser(args[1], c)
+	case aclDelUser:
+		deleted, err := InstanceOf(c).Users.DeleteUsers(args[1:]...)
+		if err != nil {
+			return &EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+		return &EvalResult{
+			Response: resp.Encode(deleted, false),
+			Error:    nil,
+		}
+	case aclList, aclUsers:
+		list := []string{}
+		for _, u := range InstanceOf(c).Users.List() {
+			if subcommand == aclList {
+				list = append(list, u.String())
+			} else {
+				list = append(list, u.Name)
+			}
+		}
+		return &EvalResult{
+			Response: resp.Encode(list, false),
+			Error:    nil,
+		}
+	case aclWhoAmI:
+		return &EvalResult{
+			Response: resp.Encode(c.User, false),
+			Error:    nil,
+		}
+	case aclDryRun:
+		return evalAclDryRun(args[1], &RedisCmd{Cmd: strings.ToUpper(args[2]), Args: args[3:]}, c)
+	case aclLog:
+		if len(args) > 2 {
+			return wrongNumberOfArguments
+		}
+		return evalAclLog(args[1:], c)
+	case aclLoad, aclSave:
+		return evalAclFile(subcommand, c)
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(ACL, args[0]),
+			Response: nil,
+		}
+	}
+}
+
+// handles ACL CAT category, which lists the commands and subcommands in the category.
+func evalAclCat(category string) *EvalResult {
+	if !acl.IsCategory(category) {
+		return &EvalResult{
+			Error:    fmt.Errorf("ERR Unknown category '%s'", category),
+			Response: nil,
+		}
+	}
+
+	names := []string{}
+	for _, command := range CommandMap {
+		for _, cmd := range append([]*Command{command}, subcommandList(command)...) {
+			for _, c := range cmd.Categories {
+				if c == category {
+					names = append(names, strings.ToLower(cmd.Name))
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+
+	return &EvalResult{
+		Response: resp.Encode(names, false),
+		Error:    nil,
+	}
+}
+
+// returns the subcommands of the command, sorted by their names.
+func subcommandList(command *Command) []*Command {
+	list := make([]*Command, 0, len(command.Subcommands))
+	for _, subcommand := range command.Subcommands {
+		list = append(list, subcommand)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// handles ACL GETUSER username, which describes the rules of the user.
+func evalAclGetUser(name string, c *client.Client) *EvalResult {
+	u := InstanceOf(c).Users.GetUser(name)
+	if u == nil {
+		var null interface{} = nil
+		if c.Protocol == resp.Resp3 {
+			null = resp.Raw(resp.Null)
+		}
+		return &EvalResult{
+			Response: resp.Encode(null, false),
+			Error:    nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.EncodeMap([]interface{}{
+			"flags", u.Flags(),
+			"passwords", u.Passwords(),
+			"commands", u.CommandRules(),
+			"keys", u.KeyRules(),
+			"channels", u.ChannelRules(),
+			"selectors", []interface{}{},
+		}, c.Protocol == resp.Resp3),
+		Error: nil,
+	}
+}
+
+// handles ACL DRYRUN username command [arg ...], which tells whether the user is allowed to run the command.
+func evalAclDryRun(username string, cmd *RedisCmd, c *client.Client) *EvalResult {
+	u := InstanceOf(c).Users.GetUser(username)
+	if u == nil {
+		return &EvalResult{
+			Error:    fmt.Errorf("ERR User '%s' not found", username),
+			Response: nil,
+		}
+	}
+	if CommandMap[cmd.Cmd] == nil {
+		return &EvalResult{
+			Error:    fmt.Errorf("ERR Command '%s' not found", strings.ToLower(cmd.Cmd)),
+			Response: nil,
+		}
+	}
+
+	var reply string
+	switch reason, object := CheckPermissions(u, cmd); reason {
+	case "":
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case acl.ReasonCommand:
+		reply = fmt.Sprintf("User %s has no permissions to run the '%s' command", username, object)
+	default:
+		reply = fmt.Sprintf("User %s has no permissions to access the '%s' %s", username, object, reason)
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(reply, false),
+		Error:    nil,
+	}
+}
+
+// handles ACL LOG [count | RESET], which lists the latest denied commands and failed authentications.
+func evalAclLog(args []string, c *client.Client) *EvalResult {
+	count := 10
+	if len(args) == 1 {
+		if strings.ToUpper(args[0]) == "RESET" {
+			InstanceOf(c).AclLog.Reset()
+			return &EvalResult{
+				Response: resp.Encode("OK", true),
+				Error:    nil,
+			}
+		}
+
+		var err error
+		count, err = strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return &EvalResult{
+				Error:    errors.New("ERR value is out of range, must be positive"),
+				Response: nil,
+			}
+		}
+	}
+
+	now := time.Now()
+	entries := [][]byte{}
+	for _, entry := range InstanceOf(c).AclLog.Entries(count) {
+		entries = append(entries, resp.EncodeMap([]interface{}{
+			"count", entry.Count,
+			"reason", entry.Reason,
+			"context", entry.Context,
+			"object", entry.Object,
+			"username", entry.Username,
+			"age-seconds", fmt.Sprintf("%.3f", now.Sub(entry.Created).Seconds()),
+			"client-info", entry.ClientInfo,
+			"entry-id", entry.Id,
+			"timestamp-created", entry.Created.UnixMilli(),
+			"timestamp-last-updated", entry.LastUpdated.UnixMilli(),
+		}, c.Protocol == resp.Resp3))
+	}
+
+	return &EvalResult{
+		Response: resp.EncodeRawArray(entries),
+		Error:    nil,
+	}
+}
+
+// handles ACL LOAD and ACL SAVE, which load the users from the ACL file and save them to it.
+func evalAclFile(subcommand string, c *client.Client) *EvalResult {
+	path := InstanceOf(c).AclFile()
+	if path == "" {
+		return &EvalResult{
+			Error:    errors.New("ERR This Redis instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE (assuming you have a Redis configuration file set) in order to store users in the Redis configuration."),
+			Response: nil,
+		}
+	}
+
+	if subcommand == aclLoad {
+		if err := InstanceOf(c).Users.LoadFile(path); err != nil {
+			return &EvalResult{
+				Error:    errors.New("ERR " + err.Error()),
+				Response: nil,
+			}
+		}
+	} else if err := InstanceOf(c).Users.SaveFile(path); err != nil {
+		return &EvalResult{
+			Error:    fmt.Errorf("ERR There was an error trying to save the ACLs: %s", err),
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
@@ -0,0 +1,460 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the CLIENT command.
+const (
+	clientId      = "ID"
+	clientSetName = "SETNAME"
+	clientGetName = "GETNAME"
+	clientList    = "LIST"
+	clientInfo    = "INFO"
+	clientKill    = "KILL"
+	clientPause   = "PAUSE"
+	clientUnpause = "UNPAUSE"
+	clientNoEvict = "NO-EVICT"
+	clientNoTouch = "NO-TOUCH"
+
+	clientTracking     = "TRACKING"
+	clientCaching      = "CACHING"
+	clientGetRedir     = "GETREDIR"
+	clientTrackingInfo = "TRACKINGINFO"
+)
+
+// types of clients, as filtered by CLIENT LIST TYPE and CLIENT KILL TYPE. the server has no
+// replication, so there are never any master or replica clients.
+const (
+	clientTypeNormal  = "normal"
+	clientTypePubSub  = "pubsub"
+	clientTypeMaster  = "master"
+	clientTypeReplica = "replica"
+	clientTypeSlave   = "slave"
+)
+
+// evalClient processes the CLIENT command, which inspects and manages the connections of the clients.
+func evalClient(args []string, c *client.Client, s store.Store) *EvalResult {
+	subcommand := strings.ToUpper(args[0])
+	wrongNumberOfArguments := &EvalResult{
+		Error:    commons.WrongNumberOfArgumentsErr(CLIENT + "|" + subcommand),
+		Response: nil,
+	}
+
+	switch subcommand {
+	case clientId:
+		return &EvalResult{
+			Response: resp.Encode(c.Id, false),
+			Error:    nil,
+		}
+	case clientSetName:
+		if err := setClientName(c, args[1]); err != nil {
+			return &EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case clientGetName:
+		var name interface{} = nil
+		if c.Name != "" {
+			name = c.Name
+		}
+		return &EvalResult{
+			Response: resp.Encode(name, false),
+			Error:    nil,
+		}
+	case clientList:
+		return evalClientList(args[1:])
+	case clientInfo:
+		return &EvalResult{
+			Response: resp.Encode(ClientInfoString(c)+"\n", false),
+			Error:    nil,
+		}
+	case clientKill:
+		return evalClientKill(args[1:], c)
+	case clientPause:
+		if len(args) > 3 {
+			return wrongNumberOfArguments
+		}
+		return evalClientPause(args[1:])
+	case clientUnpause:
+		client.Unpause()
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case clientNoEvict, clientNoTouch:
+		var on bool
+		switch strings.ToUpper(args[1]) {
+		case "ON":
+			on = true
+		case "OFF":
+			on = false
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+
+		if subcommand == clientNoEvict {
+			c.NoEvict = on
+		} else {
+			c.NoTouch = on
+		}
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	case clientTracking:
+		return evalClientTracking(args[1:], c)
+	case clientCaching:
+		return evalClientCaching(args[1], c)
+	case clientGetRedir:
+		var redirect int64 = -1
+		if c.Tracking != nil {
+			redirect = c.Tracking.Redirect
+		}
+		return &EvalResult{
+			Response: resp.Encode(redirect, false),
+			Error:    nil,
+		}
+	case clientTrackingInfo:
+		return evalClientTrackingInfo(c)
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(CLIENT, args[0]),
+			Response: nil,
+		}
+	}
+}
+
+// names the connection, or clears its name if the name is empty. like Redis, the names can't
+// contain spaces, newlines or other special characters.
+func setClientName(c *client.Client, name string) error {
+	for _, ch := range name {
+		if ch < '!' || ch > '~' {
+			return errors.New("ERR Client names cannot contain spaces, newlines or special characters.")
+		}
+	}
+
+	c.Name = name
+	return nil
+}
+
+// handles CLIENT LIST [TYPE type] [ID id [id ...]], which lists the connected clients.
+func evalClientList(args []string) *EvalResult {
+	var clients []*client.Client
+
+	switch {
+	case len(args) == 0:
+		clients = client.Connected()
+	case len(args) == 2 && strings.EqualFold(args[0], "TYPE"):
+		clientType := strings.ToLower(args[1])
+		if !isClientType(clientType) {
+			return &EvalResult{
+				Error:    fmt.Errorf("ERR Unknown client type '%s'", args[1]),
+				Response: nil,
+			}
+		}
+
+		for _, other := range client.Connected() {
+			if clientTypeOf(other) == clientType {
+				clients = append(clients, other)
+			}
+		}
+	case len(args) >= 2 && strings.EqualFold(args[0], "ID"):
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				return &EvalResult{
+					Error:    errors.New("ERR Invalid client ID"),
+					Response: nil,
+				}
+			}
+
+			if other := client.ById(id); other != nil {
+				clients = append(clients, other)
+			}
+		}
+	default:
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	var list strings.Builder
+	for _, other := range clients {
+		list.WriteString(ClientInfoString(other))
+		list.WriteByte('\n')
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(list.String(), false),
+		Error:    nil,
+	}
+}
+
+// the filters of CLIENT KILL. the zero values match every client.
+type clientKillFilter struct {
+	id         int64
+	addr       string
+	laddr      string
+	user       string
+	clientType string
+	skipMe     bool
+	maxAge     int64
+}
+
+// returns true if the client, other than the one running CLIENT KILL, matches the filter.
+func (f *clientKillFilter) matches(other *client.Client, self *client.Client) bool {
+	return (f.id == 0 || other.Id == f.id) &&
+		(f.addr == "" || other.Addr == f.addr) &&
+		(f.laddr == "" || other.LAddr == f.laddr) &&
+		(f.user == "" || other.User == f.user) &&
+		(f.clientType == "" || clientTypeOf(other) == f.clientType) &&
+		(!f.skipMe || other != self) &&
+		(f.maxAge == 0 || int64(time.Since(other.CreatedAt).Seconds()) >= f.maxAge)
+}
+
+// handles CLIENT KILL addr:port, which kills the client connected from the address, and
+// CLIENT KILL <filter> <value> ..., which kills all the clients matching the filters.
+func evalClientKill(args []string, c *client.Client) *EvalResult {
+	if len(args) == 1 {
+		for _, other := range client.Connected() {
+			if other.Addr == args[0] {
+				other.Kill()
+				return &EvalResult{
+					Response: resp.Encode("OK", true),
+					Error:    nil,
+				}
+			}
+		}
+
+		return &EvalResult{
+			Error:    errors.New("ERR No such client"),
+			Response: nil,
+		}
+	}
+
+	if len(args)%2 != 0 {
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	filter := clientKillFilter{skipMe: true}
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+
+		switch strings.ToUpper(args[i]) {
+		case "ID":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				return &EvalResult{
+					Error:    errors.New("ERR client-id should be greater than 0"),
+					Response: nil,
+				}
+			}
+			filter.id = id
+		case "ADDR":
+			filter.addr = value
+		case "LADDR":
+			filter.laddr = value
+		case "USER":
+			filter.user = value
+		case "TYPE":
+			filter.clientType = strings.ToLower(value)
+			if !isClientType(filter.clientType) {
+				return &EvalResult{
+					Error:    fmt.Errorf("ERR Unknown client type '%s'", value),
+					Response: nil,
+				}
+			}
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				filter.skipMe = true
+			case "no":
+				filter.skipMe = false
+			default:
+				return &EvalResult{
+					Error:    commons.SyntaxErr(),
+					Response: nil,
+				}
+			}
+		case "MAXAGE":
+			maxAge, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || maxAge <= 0 {
+				return &EvalResult{
+					Error:    errors.New("ERR maxage should be greater than 0"),
+					Response: nil,
+				}
+			}
+			filter.maxAge = maxAge
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	killed := 0
+	for _, other := range client.Connected() {
+		if filter.matches(other, c) {
+			other.Kill()
+			killed++
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(killed, false),
+		Error:    nil,
+	}
+}
+
+// handles CLIENT PAUSE timeout [WRITE|ALL], which postpones the commands of the clients for
+// timeout milliseconds, or only the commands that may modify the dataset with WRITE.
+func evalClientPause(args []string) *EvalResult {
+	timeout, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || timeout < 0 {
+		return &EvalResult{
+			Error:    errors.New("ERR timeout is not an integer or out of range"),
+			Response: nil,
+		}
+	}
+
+	writesOnly := false
+	if len(args) == 2 {
+		switch strings.ToUpper(args[1]) {
+		case "WRITE":
+			writesOnly = true
+		case "ALL":
+			writesOnly = false
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	client.Pause(time.Now().Add(time.Duration(timeout)*time.Millisecond), writesOnly)
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// returns true if the name is one of the types of clients.
+func isClientType(name string) bool {
+	switch name {
+	case clientTypeNormal, clientTypePubSub, clientTypeMaster, clientTypeReplica, clientTypeSlave:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns the type of the client, either normal or pubsub.
+func clientTypeOf(c *client.Client) string {
+	if c.SubscriptionCount()+c.ShardSubscriptionCount() > 0 {
+		return clientTypePubSub
+	}
+	return clientTypeNormal
+}
+
+// describes the client in the format of CLIENT LIST and CLIENT INFO, eg.
+// id=3 addr=127.0.0.1:52555 laddr=127.0.0.1:6379 fd=8 name= age=0 idle=0 flags=N db=0 ...
+func ClientInfoString(c *client.Client) string {
+	flags := ""
+	if clientTypeOf(c) == clientTypePubSub {
+		flags += "P"
+	}
+	if c.Transaction != nil {
+		flags += "x"
+	}
+	if c.Postponed != nil {
+		flags += "b"
+	}
+	if c.IsKilled() {
+		flags += "A"
+	}
+	if c.NoEvict {
+		flags += "e"
+	}
+	if c.NoTouch {
+		flags += "T"
+	}
+	if c.Tracking != nil {
+		flags += "t"
+		if c.Tracking.BrokenRedirect {
+			flags += "R"
+		}
+		if c.Tracking.Bcast {
+			flags += "B"
+		}
+	}
+	if c.UnixSocket {
+		flags += "U"
+	}
+	if flags == "" {
+		flags = "N"
+	}
+
+	multi, multiMem := -1, 0
+	if c.Transaction != nil {
+		multi = len(c.Transaction.Queue)
+		for _, queued := range c.Transaction.Queue {
+			multiMem += len(queued.Cmd)
+			for _, arg := range queued.Args {
+				multiMem += len(arg)
+			}
+		}
+	}
+
+	events := ""
+	if c.Postponed == nil {
+		events += "r"
+	}
+	if c.PendingBytes() > 0 {
+		events += "w"
+	}
+
+	cmd := c.LastCmd
+	if cmd == "" {
+		cmd = "NULL"
+	}
+
+	var redirect int64 = -1
+	if c.Tracking != nil {
+		redirect = c.Tracking.Redirect
+	}
+
+	now := time.Now()
+	return fmt.Sprintf("id=%d addr=%s laddr=%s fd=%d name=%s age=%d idle=%d flags=%s db=%d sub=%d psub=%d ssub=%d "+
+		"multi=%d qbuf=0 qbuf-free=0 argv-mem=0 multi-mem=%d rbs=%d rbp=%d obl=0 oll=0 omem=%d tot-mem=%d "+
+		"events=%s cmd=%s user=%s redir=%d resp=%d lib-name= lib-ver=",
+		c.Id, c.Addr, c.LAddr, c.Fd, c.Name,
+		int64(now.Sub(c.CreatedAt).Seconds()), int64(now.Sub(c.LastInteraction).Seconds()),
+		flags, c.Db, len(c.Channels), len(c.Patterns), len(c.ShardChannels),
+		multi, multiMem, client.ReadBufferSize, client.ReadBufferSize, c.PendingBytes(),
+		client.ReadBufferSize+c.PendingBytes()+multiMem,
+		events, cmd, c.User, redirect, c.Protocol)
+}
@@ -0,0 +1,370 @@
+package eval
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// subcommands of the COMMAND command.
+const (
+	commandCount   = "COUNT"
+	commandInfo    = "INFO"
+	commandDocs    = "DOCS"
+	commandGetKeys = "GETKEYS"
+	commandList    = "LIST"
+)
+
+// filters of COMMAND LIST FILTERBY.
+const (
+	filterByModule  = "MODULE"
+	filterByAclCat  = "ACLCAT"
+	filterByPattern = "PATTERN"
+)
+
+// evalCommand processes the COMMAND command, which describes the commands the server supports, so
+// that the client libraries and the cluster proxies can tell eg. where the keys of a command are.
+// Without a subcommand it replies with the description of every command, like COMMAND INFO does.
+func evalCommand(args []string, c *client.Client, s store.Store) *EvalResult {
+	if len(args) == 0 {
+		return &EvalResult{
+			Response: commandInfoReply(sortedCommands(), c.Protocol == resp.Resp3),
+			Error:    nil,
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case commandCount:
+		return &EvalResult{
+			Response: resp.Encode(len(CommandMap), false),
+			Error:    nil,
+		}
+	case commandInfo:
+		if len(args) == 1 {
+			return &EvalResult{
+				Response: commandInfoReply(sortedCommands(), c.Protocol == resp.Resp3),
+				Error:    nil,
+			}
+		}
+
+		commands := make([]*Command, 0, len(args)-1)
+		for _, name := range args[1:] {
+			commands = append(commands, lookupCommand(name))
+		}
+		return &EvalResult{
+			Response: commandInfoReply(commands, c.Protocol == resp.Resp3),
+			Error:    nil,
+		}
+	case commandDocs:
+		commands := sortedCommands()
+		if len(args) > 1 {
+			commands = make([]*Command, 0, len(args)-1)
+			for _, name := range args[1:] {
+				// the unknown commands are left out of the reply.
+				if command := lookupCommand(name); command != nil {
+					commands = append(commands, command)
+				}
+			}
+		}
+		return &EvalResult{
+			Response: commandDocsReply(commands, c.Protocol == resp.Resp3),
+			Error:    nil,
+		}
+	case commandGetKeys:
+		return evalCommandGetKeys(args[1:])
+	case commandList:
+		return evalCommandList(args[1:])
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(COMMAND, args[0]),
+			Response: nil,
+		}
+	}
+}
+
+// returns the commands sorted by their names.
+func sortedCommands() []*Command {
+	commands := make([]*Command, 0, len(CommandMap))
+	for _, command := range CommandMap {
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// returns the command or the subcommand with the full name, eg. get or client|list. nil if there's no such command.
+func lookupCommand(fullName string) *Command {
+	name, subcommand, isSubcommand := strings.Cut(fullName, "|")
+	command := CommandMap[strings.ToUpper(name)]
+	if command == nil || !isSubcommand {
+		return command
+	}
+	return command.Subcommands[strings.ToUpper(subcommand)]
+}
+
+// replies with the descriptions of the commands, or null in place of the ones that don't exist.
+func commandInfoReply(commands []*Command, resp3 bool) []byte {
+	items := make([][]byte, 0, len(commands))
+	for _, command := range commands {
+		if command == nil {
+			items = append(items, resp.NullArray)
+			continue
+		}
+		items = append(items, commandInfoItem(command, resp3))
+	}
+	return resp.EncodeRawArray(items)
+}
+
+// describes the command as COMMAND INFO does: its name, arity, flags, the positions of its first
+// and last keys and the step between them, its ACL categories, tips, key specs and subcommands.
+func commandInfoItem(command *Command, resp3 bool) []byte {
+	flags := command.Flags.Names()
+	first, last, step := legacyKeyPositions(command)
+	if hasMovableKeys(command) {
+		flags = append(flags, "movablekeys")
+	}
+
+	categories := make([]string, 0, len(command.Categories))
+	for _, category := range command.Categories {
+		categories = append(categories, "@"+category)
+	}
+
+	keySpecs := make([][]byte, 0, len(command.KeySpecs))
+	for _, spec := range command.KeySpecs {
+		keySpecs = append(keySpecs, keySpecReply(spec, resp3))
+	}
+
+	subcommands := make([][]byte, 0, len(command.Subcommands))
+	for _, subcommand := range subcommandList(command) {
+		subcommands = append(subcommands, commandInfoItem(subcommand, resp3))
+	}
+
+	tips := command.Tips
+	if tips == nil {
+		tips = []string{}
+	}
+
+	return resp.EncodeArray([]interface{}{
+		strings.ToLower(command.Name),
+		command.Arity,
+		resp.Raw(statusArray(flags)),
+		first,
+		last,
+		step,
+		resp.Raw(statusArray(categories)),
+		tips,
+		resp.Raw(resp.EncodeRawArray(keySpecs)),
+		resp.Raw(resp.EncodeRawArray(subcommands)),
+	})
+}
+
+// encodes the names as an array of simple strings.
+func statusArray(names []string) []byte {
+	items := make([][]byte, 0, len(names))
+	for _, name := range names {
+		items = append(items, resp.Encode(name, true))
+	}
+	return resp.EncodeRawArray(items)
+}
+
+// returns the positions of the first and the last key of the command, and the step between the keys,
+// as reported before the key specs existed. They are all 0 if the command has no keys, or if the
+// number of its keys is one of its arguments.
+func legacyKeyPositions(command *Command) (int, int, int) {
+	if len(command.KeySpecs) == 0 || hasMovableKeys(command) {
+		return 0, 0, 0
+	}
+
+	first := command.KeySpecs[0]
+	last := command.KeySpecs[len(command.KeySpecs)-1]
+	return first.First, last.Last, max(first.Step, 1)
+}
+
+// tells whether the keys of the command can only be found by looking at its arguments, eg. for EVAL.
+func hasMovableKeys(command *Command) bool {
+	for _, spec := range command.KeySpecs {
+		if spec.KeyNum {
+			return true
+		}
+	}
+	return false
+}
+
+// describes where the keys are, and how the command accesses them.
+func keySpecReply(spec KeySpec, resp3 bool) []byte {
+	flags := []string{}
+	switch spec.Access {
+	case KeyRead:
+		flags = append(flags, "RO", "ACCESS")
+	case KeyWrite:
+		flags = append(flags, "OW", "UPDATE")
+	case KeyReadWrite:
+		flags = append(flags, "RW", "ACCESS", "UPDATE")
+	default:
+		flags = append(flags, "RO")
+	}
+
+	var findKeys []byte
+	if spec.KeyNum {
+		findKeys = resp.EncodeMap([]interface{}{
+			"type", "keynum",
+			"spec", resp.Raw(resp.EncodeMap([]interface{}{"keynumidx", 0, "firstkey", 1, "keystep", 1}, resp3)),
+		}, resp3)
+	} else {
+		// the last key is relative to the first one, unless it counts from the end.
+		lastKey := spec.Last
+		if lastKey >= 0 {
+			lastKey -= spec.First
+		}
+		findKeys = resp.EncodeMap([]interface{}{
+			"type", "range",
+			"spec", resp.Raw(resp.EncodeMap([]interface{}{"lastkey", lastKey, "keystep", max(spec.Step, 1), "limit", 0}, resp3)),
+		}, resp3)
+	}
+
+	beginSearch := resp.EncodeMap([]interface{}{
+		"type", "index",
+		"spec", resp.Raw(resp.EncodeMap([]interface{}{"index", spec.First}, resp3)),
+	}, resp3)
+
+	return resp.EncodeMap([]interface{}{
+		"flags", resp.Raw(statusArray(flags)),
+		"begin_search", resp.Raw(beginSearch),
+		"find_keys", resp.Raw(findKeys),
+	}, resp3)
+}
+
+// replies with the documentation of the commands, keyed by their names.
+func commandDocsReply(commands []*Command, resp3 bool) []byte {
+	pairs := make([]interface{}, 0, 2*len(commands))
+	for _, command := range commands {
+		pairs = append(pairs, strings.ToLower(command.Name), resp.Raw(commandDocsItem(command, resp3)))
+	}
+	return resp.EncodeMap(pairs, resp3)
+}
+
+func commandDocsItem(command *Command, resp3 bool) []byte {
+	doc := commandDocTable[strings.ToLower(command.Name)]
+	pairs := []interface{}{
+		"summary", doc.Summary,
+		"since", doc.Since,
+		"group", doc.Group,
+	}
+	if doc.Complexity != "" {
+		pairs = append(pairs, "complexity", doc.Complexity)
+	}
+	if len(command.Subcommands) > 0 {
+		pairs = append(pairs, "subcommands", resp.Raw(commandDocsReply(subcommandList(command), resp3)))
+	}
+	return resp.EncodeMap(pairs, resp3)
+}
+
+// handles COMMAND GETKEYS command [arg ...], which finds the keys among the arguments of the command.
+func evalCommandGetKeys(args []string) *EvalResult {
+	command := CommandMap[strings.ToUpper(args[0])]
+	if command == nil {
+		return &EvalResult{
+			Error:    errors.New("ERR Invalid command specified"),
+			Response: nil,
+		}
+	}
+
+	cmdArgs := args[1:]
+	command = command.Resolve(cmdArgs)
+	if !command.AcceptsArgCount(len(args)) {
+		return &EvalResult{
+			Error:    errors.New("ERR Invalid number of arguments specified for command"),
+			Response: nil,
+		}
+	}
+
+	if len(command.KeySpecs) == 0 {
+		return &EvalResult{
+			Error:    errors.New("ERR The command has no key arguments"),
+			Response: nil,
+		}
+	}
+
+	keys := []string{}
+	for _, spec := range command.KeySpecs {
+		for _, position := range spec.Positions(cmdArgs) {
+			keys = append(keys, cmdArgs[position])
+		}
+	}
+
+	// the commands whose keys are counted by one of their arguments, eg. EVAL, may be given no keys.
+	if len(keys) == 0 && !hasMovableKeys(command) {
+		return &EvalResult{
+			Error:    errors.New("ERR Invalid arguments specified for command"),
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(keys, false),
+		Error:    nil,
+	}
+}
+
+// handles COMMAND LIST [FILTERBY MODULE name | ACLCAT category | PATTERN pattern], which lists the
+// names of the commands and the subcommands that pass the filter.
+func evalCommandList(args []string) *EvalResult {
+	filter := func(command *Command) bool { return true }
+
+	switch {
+	case len(args) == 0:
+	case len(args) == 3 && strings.EqualFold(args[0], "FILTERBY"):
+		value := args[2]
+		switch strings.ToUpper(args[1]) {
+		case filterByModule:
+			// modules aren't supported, so none of the commands belong to one.
+			filter = func(command *Command) bool { return false }
+		case filterByAclCat:
+			filter = func(command *Command) bool { return hasCategory(command, strings.ToLower(value)) }
+		case filterByPattern:
+			filter = func(command *Command) bool {
+				return utils.GlobMatch(strings.ToLower(value), strings.ToLower(command.Name))
+			}
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	default:
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	names := []string{}
+	for _, command := range sortedCommands() {
+		for _, cmd := range append([]*Command{command}, subcommandList(command)...) {
+			if filter(cmd) {
+				names = append(names, strings.ToLower(cmd.Name))
+			}
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(names, false),
+		Error:    nil,
+	}
+}
+
+// tells whether the command belongs to the ACL category.
+func hasCategory(command *Command, category string) bool {
+	for _, c := range command.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
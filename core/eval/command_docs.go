@@ -0,0 +1,144 @@
+package eval
+
+// documentation of a command, as reported by COMMAND DOCS.
+type commandDoc struct {
+	Summary string
+
+	// the version of Redis the command first appeared in.
+	Since string
+
+	// the group the command is listed under in the Redis documentation, eg. string or pubsub.
+	Group string
+
+	// time complexity of the command.
+	Complexity string
+}
+
+// documentation of the commands and the subcommands, keyed by their full names, eg. client|list.
+var commandDocTable = map[string]commandDoc{
+	"ping":   {"Returns the server's liveliness response.", "1.0.0", "connection", "O(1)"},
+	"get":    {"Returns the string value of a key.", "1.0.0", "string", "O(1)"},
+	"set":    {"Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.", "1.0.0", "string", "O(1)"},
+	"ttl":    {"Returns the expiration time in seconds of a key.", "1.0.0", "generic", "O(1)"},
+	"del":    {"Deletes one or more keys.", "1.0.0", "generic", "O(N) where N is the number of keys that will be removed."},
+	"expire": {"Sets the expiration time of a key in seconds.", "1.0.0", "generic", "O(1)"},
+
+	"hset":    {"Creates or modifies the value of a field in a hash.", "2.0.0", "hash", "O(1) for each field/value pair added, so O(N) to add N field/value pairs when the command is called with multiple field/value pairs."},
+	"hget":    {"Returns the value of a field in a hash.", "2.0.0", "hash", "O(1)"},
+	"hdel":    {"Deletes one or more fields and their values from a hash. Deletes the hash if no fields remain.", "2.0.0", "hash", "O(N) where N is the number of fields to be removed."},
+	"hgetall": {"Returns all fields and values in a hash.", "2.0.0", "hash", "O(N) where N is the size of the hash."},
+	"hlen":    {"Returns the number of fields in a hash.", "2.0.0", "hash", "O(1)"},
+	"hexists": {"Determines whether a field exists in a hash.", "2.0.0", "hash", "O(1)"},
+
+	"hello":        {"Handshakes with the Redis server.", "6.0.0", "connection", "O(1)"},
+	"auth":         {"Authenticates the connection.", "1.0.0", "connection", "O(N) where N is the number of passwords defined for the user"},
+	"select":       {"Changes the selected database.", "1.0.0", "connection", "O(1)"},
+	"subscribe":    {"Listens for messages published to channels.", "2.0.0", "pubsub", "O(N) where N is the number of channels to subscribe to."},
+	"unsubscribe":  {"Stops listening to messages posted to channels.", "2.0.0", "pubsub", "O(N) where N is the number of channels to unsubscribe."},
+	"psubscribe":   {"Listens for messages published to channels that match one or more patterns.", "2.0.0", "pubsub", "O(N) where N is the number of patterns to subscribe to."},
+	"punsubscribe": {"Stops listening to messages published to channels that match one or more patterns.", "2.0.0", "pubsub", "O(N) where N is the number of patterns to unsubscribe."},
+	"publish":      {"Posts a message to a channel.", "2.0.0", "pubsub", "O(N+M) where N is the number of clients subscribed to the receiving channel and M is the total number of subscribed patterns (by any client)."},
+	"ssubscribe":   {"Listens for messages published to shard channels.", "7.0.0", "pubsub", "O(N) where N is the number of shard channels to subscribe to."},
+	"sunsubscribe": {"Stops listening to messages posted to shard channels.", "7.0.0", "pubsub", "O(N) where N is the number of shard channels to unsubscribe."},
+	"spublish":     {"Post a message to a shard channel", "7.0.0", "pubsub", "O(N) where N is the number of clients subscribed to the receiving shard channel."},
+
+	"pubsub":               {"A container for Pub/Sub commands.", "2.8.0", "pubsub", "Depends on subcommand."},
+	"pubsub|channels":      {"Returns the active channels.", "2.8.0", "pubsub", "O(N) where N is the number of active channels, and assuming constant time pattern matching (relatively short channels and patterns)"},
+	"pubsub|numsub":        {"Returns a count of subscribers to channels.", "2.8.0", "pubsub", "O(N) for the NUMSUB subcommand, where N is the number of requested channels"},
+	"pubsub|numpat":        {"Returns a count of unique pattern subscriptions.", "2.8.0", "pubsub", "O(1)"},
+	"pubsub|shardchannels": {"Returns the active shard channels.", "7.0.0", "pubsub", "O(N) where N is the number of active shard channels, and assuming constant time pattern matching (relatively short shard channels)."},
+	"pubsub|shardnumsub":   {"Returns the count of subscribers of shard channels.", "7.0.0", "pubsub", "O(N) for the SHARDNUMSUB subcommand, where N is the number of requested shard channels"},
+
+	"multi":   {"Starts a transaction.", "1.2.0", "transactions", "O(1)"},
+	"exec":    {"Executes all commands in a transaction.", "1.2.0", "transactions", "Depends on commands in the transaction"},
+	"discard": {"Discards a transaction.", "2.0.0", "transactions", "O(N), when N is the number of queued commands"},
+	"watch":   {"Monitors changes to keys to determine the execution of a transaction.", "2.2.0", "transactions", "O(1) for every key."},
+	"unwatch": {"Forgets about watched keys of a transaction.", "2.2.0", "transactions", "O(1)"},
+
+	"eval":          {"Executes a server-side Lua script.", "2.6.0", "scripting", "Depends on the script that is executed."},
+	"evalsha":       {"Executes a server-side Lua script by SHA1 digest.", "2.6.0", "scripting", "Depends on the script that is executed."},
+	"eval_ro":       {"Executes a read-only server-side Lua script.", "7.0.0", "scripting", "Depends on the script that is executed."},
+	"evalsha_ro":    {"Executes a read-only server-side Lua script by SHA1 digest.", "7.0.0", "scripting", "Depends on the script that is executed."},
+	"script":        {"A container for Lua scripts management commands.", "2.6.0", "scripting", "Depends on subcommand."},
+	"script|load":   {"Loads a server-side Lua script to the script cache.", "2.6.0", "scripting", "O(N) with N being the length in bytes of the script body."},
+	"script|exists": {"Determines whether server-side Lua scripts exist in the script cache.", "2.6.0", "scripting", "O(N) with N being the number of scripts to check (so checking a single script is an O(1) operation)."},
+	"script|flush":  {"Removes all server-side Lua scripts from the script cache.", "2.6.0", "scripting", "O(N) with N being the number of scripts in cache"},
+	"script|kill":   {"Terminates a server-side Lua script during execution.", "2.6.0", "scripting", "O(1)"},
+
+	"fcall":            {"Invokes a function.", "7.0.0", "scripting", "Depends on the function that is executed."},
+	"fcall_ro":         {"Invokes a read-only function.", "7.0.0", "scripting", "Depends on the function that is executed."},
+	"function":         {"A container for function commands.", "7.0.0", "scripting", "Depends on subcommand."},
+	"function|load":    {"Creates a library.", "7.0.0", "scripting", "O(1) (considering compilation time is redundant)"},
+	"function|list":    {"Returns information about all libraries.", "7.0.0", "scripting", "O(N) where N is the number of functions"},
+	"function|delete":  {"Deletes a library and its functions.", "7.0.0", "scripting", "O(1)"},
+	"function|dump":    {"Dumps all libraries into a serialized binary payload.", "7.0.0", "scripting", "O(N) where N is the number of functions"},
+	"function|restore": {"Restores all libraries from a payload.", "7.0.0", "scripting", "O(N) where N is the number of functions on the payload"},
+	"function|flush":   {"Deletes all libraries and functions.", "7.0.0", "scripting", "O(N) where N is the number of functions deleted"},
+	"function|kill":    {"Terminates a function during execution.", "7.0.0", "scripting", "O(1)"},
+
+	"move":     {"Moves a key to another database.", "1.0.0", "generic", "O(1)"},
+	"swapdb":   {"Swaps two Redis databases.", "4.0.0", "server", "O(N) where N is the count of clients watching or blocking on keys from both databases."},
+	"flushdb":  {"Removes all keys from the current database.", "1.0.0", "server", "O(N) where N is the number of keys in the selected database"},
+	"flushall": {"Removes all keys from all databases.", "1.0.0", "server", "O(N) where N is the total number of keys in all databases"},
+	"dbsize":   {"Returns the number of keys in the database.", "1.0.0", "server", "O(1)"},
+
+	"exists":    {"Determines whether one or more keys exist.", "1.0.0", "generic", "O(N) where N is the number of keys to check."},
+	"type":      {"Determines the type of value stored at a key.", "1.0.0", "generic", "O(1)"},
+	"rename":    {"Renames a key and overwrites the destination.", "1.0.0", "generic", "O(1)"},
+	"renamenx":  {"Renames a key only when the target key name doesn't exist.", "1.0.0", "generic", "O(1)"},
+	"copy":      {"Copies the value of a key to a new key.", "6.2.0", "generic", "O(N) worst case for collections, where N is the number of nested items. O(1) for string values."},
+	"keys":      {"Returns all key names that match a pattern.", "1.0.0", "generic", "O(N) with N being the number of keys in the database, under the assumption that the key names in the database and the given pattern have limited length."},
+	"scan":      {"Iterates over the key names in the database.", "2.8.0", "generic", "O(1) for every call. O(N) for a complete iteration, including enough command calls for the cursor to return back to 0. N is the number of elements inside the collection."},
+	"randomkey": {"Returns a random key name from the database.", "1.0.0", "generic", "O(1)"},
+	"touch":     {"Returns the number of existing keys out of those specified after updating the time they were last accessed.", "3.2.1", "generic", "O(N) where N is the number of keys that will be touched."},
+	"unlink":    {"Asynchronously deletes one or more keys.", "4.0.0", "generic", "O(1) for each key removed regardless of its size. Then the command does O(N) work in a different thread in order to reclaim memory, where N is the number of allocations the deleted objects where composed of."},
+
+	"info":     {"Returns information and statistics about the server.", "1.0.0", "server", "O(1)"},
+	"shutdown": {"Synchronously saves the database(s) to disk and shuts down the Redis server.", "1.0.0", "server", "O(N) when saving, where N is the total number of keys in all databases when saving data, otherwise O(1)"},
+
+	"latency":           {"A container for latency diagnostics commands.", "2.8.13", "server", "Depends on subcommand."},
+	"latency|latest":    {"Returns the latest latency samples for all events.", "2.8.13", "server", "O(1)"},
+	"latency|history":   {"Returns timestamp-latency samples for an event.", "2.8.13", "server", "O(1)"},
+	"latency|reset":     {"Resets the latency data for one or more events.", "2.8.13", "server", "O(1)"},
+	"latency|histogram": {"Returns the cumulative distribution of latencies of a subset or all commands.", "7.0.0", "server", "O(N) where N is the number of commands with latency information being retrieved."},
+	"slowlog":           {"A container for slow log commands.", "2.2.12", "server", "Depends on subcommand."},
+	"slowlog|get":       {"Returns the slow log's entries.", "2.2.12", "server", "O(N) where N is the number of entries returned"},
+	"slowlog|len":       {"Returns the number of entries in the slow log.", "2.2.12", "server", "O(1)"},
+	"slowlog|reset":     {"Clears all entries from the slow log.", "2.2.12", "server", "O(N) where N is the number of entries in the slowlog"},
+
+	"client":              {"A container for client connection commands.", "2.4.0", "connection", "Depends on subcommand."},
+	"client|id":           {"Returns the unique client ID of the connection.", "5.0.0", "connection", "O(1)"},
+	"client|setname":      {"Sets the connection name.", "2.6.9", "connection", "O(1)"},
+	"client|getname":      {"Returns the name of the connection.", "2.6.9", "connection", "O(1)"},
+	"client|list":         {"Lists open connections.", "2.4.0", "connection", "O(N) where N is the number of client connections"},
+	"client|info":         {"Returns information about the connection.", "6.2.0", "connection", "O(1)"},
+	"client|kill":         {"Terminates open connections.", "2.4.0", "connection", "O(N) where N is the number of client connections"},
+	"client|pause":        {"Suspends commands processing.", "3.0.0", "connection", "O(1)"},
+	"client|unpause":      {"Resumes processing commands from paused clients.", "6.2.0", "connection", "O(N) Where N is the number of paused clients"},
+	"client|no-evict":     {"Sets the client eviction mode of the connection.", "7.0.0", "connection", "O(1)"},
+	"client|no-touch":     {"Controls whether commands sent by the client affect the LRU/LFU of accessed keys.", "7.2.0", "connection", "O(1)"},
+	"client|tracking":     {"Controls server-assisted client-side caching for the connection.", "6.0.0", "connection", "O(1). Some options may introduce additional complexity."},
+	"client|caching":      {"Instructs the server whether to track the keys in the next request.", "6.0.0", "connection", "O(1)"},
+	"client|getredir":     {"Returns the client ID to which the connection's tracking notifications are redirected.", "6.0.0", "connection", "O(1)"},
+	"client|trackinginfo": {"Returns information about server-assisted client-side caching for the connection.", "6.2.0", "connection", "O(1)"},
+
+	"acl":         {"A container for Access List Control commands.", "6.0.0", "server", "Depends on subcommand."},
+	"acl|cat":     {"Lists the ACL categories, or the commands inside a category.", "6.0.0", "server", "O(1) since the categories and commands are a fixed set."},
+	"acl|deluser": {"Deletes ACL users, and terminates their connections.", "6.0.0", "server", "O(1) amortized time considering the typical user."},
+	"acl|dryrun":  {"Simulates the execution of a command by a user, without executing the command.", "7.0.0", "server", "O(1)."},
+	"acl|getuser": {"Lists the ACL rules of a user.", "6.0.0", "server", "O(N). Where N is the number of password, command and pattern rules that the user has."},
+	"acl|list":    {"Dumps the effective rules in ACL file format.", "6.0.0", "server", "O(N). Where N is the number of configured users."},
+	"acl|load":    {"Reloads the rules from the configured ACL file.", "6.0.0", "server", "O(N). Where N is the number of configured users."},
+	"acl|log":     {"Lists recent security events generated due to ACL rules.", "6.0.0", "server", "O(N) with N being the number of entries shown."},
+	"acl|save":    {"Saves the effective ACL rules in the configured ACL file.", "6.0.0", "server", "O(N). Where N is the number of configured users."},
+	"acl|setuser": {"Creates and modifies an ACL user and its rules.", "6.0.0", "server", "O(N). Where N is the number of rules provided."},
+	"acl|users":   {"Lists all ACL users.", "6.0.0", "server", "O(N). Where N is the number of configured users."},
+	"acl|whoami":  {"Returns the authenticated username of the current connection.", "6.0.0", "server", "O(1)"},
+
+	"command":         {"Returns detailed information about all commands.", "2.8.13", "server", "O(N) where N is the total number of Redis commands"},
+	"command|count":   {"Returns a count of commands.", "2.8.13", "server", "O(1)"},
+	"command|docs":    {"Returns documentary information about one, multiple or all commands.", "7.0.0", "server", "O(N) where N is the number of commands to look up"},
+	"command|getkeys": {"Extracts the key names from an arbitrary command.", "2.8.13", "server", "O(N) where N is the number of arguments to the command"},
+	"command|info":    {"Returns information about one, multiple or all commands.", "2.8.13", "server", "O(N) where N is the number of commands to look up"},
+	"command|list":    {"Returns a list of command names.", "7.0.0", "server", "O(N) where N is the total number of Redis commands"},
+}
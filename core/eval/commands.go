@@ -2,7 +2,11 @@ package eval
 
 import (
 	"log"
+	"strconv"
+	"strings"
 
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
 	"github.com/shashwatrathod/redis-internals/core/store"
 )
 
@@ -30,22 +34,354 @@ type Command struct {
 	// Evaluates the command by executing the core logic and
 	// returns the results from the execution.
 	Eval func(args []string, s store.Store) *EvalResult
+
+	// Evaluates commands that need access to the state of the client
+	// issuing them (eg. SUBSCRIBE). Used in place of Eval when set.
+	ClientEval func(args []string, c *client.Client, s store.Store) *EvalResult
+
+	// number of arguments the command takes, counting the command name. A negative arity is
+	// the minimum number of arguments, eg. -2 for DEL, which takes one or more keys.
+	Arity int
+
+	// describe the behaviour of the command, eg. whether it writes to the dataset.
+	Flags CommandFlag
+
+	// ACL categories the command belongs to, eg. read and fast.
+	Categories []string
+
+	// where the keys of the command are among its arguments.
+	KeySpecs []KeySpec
+
+	// hints for the clients and the cluster proxies about how to run the command, eg.
+	// nondeterministic_output, as reported by COMMAND INFO.
+	Tips []string
+
+	// subcommands of the command, keyed by their names, eg. LIST for CLIENT LIST. The command
+	// dispatches its subcommands itself, so these only describe them.
+	Subcommands map[string]*Command
+
+	// returns a copy of the arguments with the secrets, eg. the passwords, redacted, so that they
+	// don't end up in the slow log. The arguments are logged as they are when nil.
+	Redact func(args []string) []string
+}
+
+// CommandFlag describes a behaviour of a command. The flags of a command are OR-ed together.
+type CommandFlag int
+
+const (
+	// the command may modify the dataset.
+	FlagWrite CommandFlag = 1 << iota
+	// the command only reads from the dataset.
+	FlagReadOnly
+	// the command may use more memory.
+	FlagDenyOom
+	// the command is an administrative one, eg. CLIENT KILL.
+	FlagAdmin
+	// the command is part of the Pub/Sub.
+	FlagPubSub
+	// the command can't be called from scripts.
+	FlagNoScript
+	// the command can run while the server loads the dataset.
+	FlagLoading
+	// the command can run on a replica with stale data.
+	FlagStale
+	// the command runs in constant or logarithmic time.
+	FlagFast
+	// the command can run before the client authenticates.
+	FlagNoAuth
+	// the command may modify the dataset, or the state that's propagated along with it, eg. PUBLISH.
+	FlagMayReplicate
+	// the command can run while a script or a function is busy.
+	FlagAllowBusy
+)
+
+// names of the flags, as reported by COMMAND INFO.
+var commandFlagNames = []struct {
+	flag CommandFlag
+	name string
+}{
+	{FlagWrite, "write"},
+	{FlagReadOnly, "readonly"},
+	{FlagDenyOom, "denyoom"},
+	{FlagAdmin, "admin"},
+	{FlagPubSub, "pubsub"},
+	{FlagNoScript, "noscript"},
+	{FlagLoading, "loading"},
+	{FlagStale, "stale"},
+	{FlagFast, "fast"},
+	{FlagNoAuth, "no_auth"},
+	{FlagMayReplicate, "may_replicate"},
+	{FlagAllowBusy, "allow_busy"},
+}
+
+// returns the names of the flags.
+func (flags CommandFlag) Names() []string {
+	names := []string{}
+	for _, f := range commandFlagNames {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// replaces the secrets among the arguments of the commands that have some.
+const redactedArg = "(redacted)"
+
+// returns the arguments of the command, with its secrets redacted if it has any.
+func (cmd *Command) RedactArgs(args []string) []string {
+	if cmd == nil || cmd.Redact == nil {
+		return args
+	}
+	return cmd.Redact(args)
+}
+
+// tells whether the command has the flag. A nil command has no flags.
+func (cmd *Command) HasFlag(flag CommandFlag) bool {
+	return cmd != nil && cmd.Flags&flag != 0
+}
+
+// tells whether the command accepts the number of arguments, counting the command name.
+func (cmd *Command) AcceptsArgCount(argc int) bool {
+	if cmd.Arity < 0 {
+		return argc >= -cmd.Arity
+	}
+	return argc == cmd.Arity
+}
+
+// returns the subcommand named by the arguments of the command, nil if the command has no
+// subcommands or there is no such subcommand.
+func (cmd *Command) SubcommandOf(args []string) *Command {
+	if cmd == nil || len(cmd.Subcommands) == 0 || len(args) == 0 {
+		return nil
+	}
+	return cmd.Subcommands[strings.ToUpper(args[0])]
+}
+
+// returns the subcommand named by the arguments, or the command itself if it has no such
+// subcommand. The flags of the subcommand are the ones that apply, eg. noscript for CLIENT KILL.
+func (cmd *Command) Resolve(args []string) *Command {
+	if sub := cmd.SubcommandOf(args); sub != nil {
+		return sub
+	}
+	return cmd
+}
+
+// returns a subcommand of a container command, eg. the LIST of CLIENT LIST, which belongs to the
+// categories. The arity counts the command name and the subcommand name, eg. 2 for CLIENT ID.
+func Subcommand(name string, arity int, flags CommandFlag, categories ...string) *Command {
+	return &Command{
+		Name:       name,
+		Arity:      arity,
+		Flags:      flags,
+		Categories: categories,
+	}
+}
+
+// keys the subcommands of the command by their names, and names them after the command, eg. CLIENT|LIST.
+func Subcommands(command string, subcommands ...*Command) map[string]*Command {
+	m := make(map[string]*Command, len(subcommands))
+	for _, subcommand := range subcommands {
+		m[subcommand.Name] = subcommand
+		subcommand.Name = command + "|" + subcommand.Name
+	}
+	return m
+}
+
+// returns the lowercase name of the command, along with its subcommand if it has any, eg. client|list.
+func FullCommandName(cmd *RedisCmd) string {
+	name := strings.ToLower(cmd.Cmd)
+	if command := CommandMap[cmd.Cmd]; command != nil && len(command.Subcommands) > 0 && len(cmd.Args) > 0 {
+		name += "|" + strings.ToLower(cmd.Args[0])
+	}
+	return name
+}
+
+// ways the commands access their keys, checked against the key patterns of the ACL users.
+// The commands that only look at the metadata of the keys, eg. EXISTS, neither read nor write them.
+type KeyAccess int
+
+const (
+	KeyRead KeyAccess = 1 << iota
+	KeyWrite
+
+	KeyReadWrite = KeyRead | KeyWrite
+)
+
+// KeySpec tells where some of the keys of a command are among its arguments. The positions
+// count the command name as 0, like COMMAND INFO reports them.
+type KeySpec struct {
+	// position of the first key.
+	First int
+
+	// position of the last key. A negative position counts from the end, -1 being the last argument.
+	Last int
+
+	// number of arguments from one key to the next.
+	Step int
+
+	// if set, the argument at First holds the number of keys, which follow it, eg. for EVAL.
+	// Last is unused then.
+	KeyNum bool
+
+	Access KeyAccess
+}
+
+// returns the indexes of the keys in the arguments, which don't include the command name.
+func (spec KeySpec) Positions(args []string) []int {
+	first, last := spec.First, spec.Last
+	if spec.KeyNum {
+		if first > len(args) {
+			return nil
+		}
+		numKeys, err := strconv.Atoi(args[first-1])
+		if err != nil || numKeys <= 0 {
+			return nil
+		}
+		first, last = first+1, first+numKeys
+	} else if last < 0 {
+		last += len(args) + 1
+	}
+
+	step := max(spec.Step, 1)
+	positions := []int{}
+	for position := first; position <= last && position <= len(args); position += step {
+		positions = append(positions, position-1)
+	}
+	return positions
 }
 
 // supported commands
 const (
-	PING   = "PING"
-	GET    = "GET"
-	TTL    = "TTL"
-	SET    = "SET"
-	DEL    = "DEL"
-	EXPIRE = "EXPIRE"
+	PING    = "PING"
+	GET     = "GET"
+	TTL     = "TTL"
+	SET     = "SET"
+	DEL     = "DEL"
+	EXPIRE  = "EXPIRE"
+	PEXPIRE = "PEXPIRE"
+
+	HSET    = "HSET"
+	HGET    = "HGET"
+	HDEL    = "HDEL"
+	HGETALL = "HGETALL"
+	HLEN    = "HLEN"
+	HEXISTS = "HEXISTS"
+
+	HELLO        = "HELLO"
+	SUBSCRIBE    = "SUBSCRIBE"
+	UNSUBSCRIBE  = "UNSUBSCRIBE"
+	PSUBSCRIBE   = "PSUBSCRIBE"
+	PUNSUBSCRIBE = "PUNSUBSCRIBE"
+	PUBLISH      = "PUBLISH"
+	PUBSUB       = "PUBSUB"
+	SSUBSCRIBE   = "SSUBSCRIBE"
+	SUNSUBSCRIBE = "SUNSUBSCRIBE"
+	SPUBLISH     = "SPUBLISH"
+
+	MULTI   = "MULTI"
+	EXEC    = "EXEC"
+	DISCARD = "DISCARD"
+	WATCH   = "WATCH"
+	UNWATCH = "UNWATCH"
+
+	EVAL       = "EVAL"
+	EVALSHA    = "EVALSHA"
+	EVAL_RO    = "EVAL_RO"
+	EVALSHA_RO = "EVALSHA_RO"
+	SCRIPT     = "SCRIPT"
+
+	SELECT   = "SELECT"
+	MOVE     = "MOVE"
+	SWAPDB   = "SWAPDB"
+	FLUSHDB  = "FLUSHDB"
+	FLUSHALL = "FLUSHALL"
+	DBSIZE   = "DBSIZE"
+
+	EXISTS    = "EXISTS"
+	TYPE      = "TYPE"
+	RENAME    = "RENAME"
+	RENAMENX  = "RENAMENX"
+	COPY      = "COPY"
+	KEYS      = "KEYS"
+	SCAN      = "SCAN"
+	RANDOMKEY = "RANDOMKEY"
+	TOUCH     = "TOUCH"
+	UNLINK    = "UNLINK"
+
+	FCALL    = "FCALL"
+	FCALL_RO = "FCALL_RO"
+	FUNCTION = "FUNCTION"
+
+	INFO    = "INFO"
+	LATENCY = "LATENCY"
+	SLOWLOG = "SLOWLOG"
+
+	CLIENT = "CLIENT"
+
+	AUTH = "AUTH"
+	ACL  = "ACL"
+
+	COMMAND = "COMMAND"
+
+	SHUTDOWN = "SHUTDOWN"
 )
 
+// commands that are run right away instead of being queued when the client is in a transaction.
+var TransactionControlCommands = map[string]bool{
+	MULTI:   true,
+	EXEC:    true,
+	DISCARD: true,
+	WATCH:   true,
+}
+
+// commands that a client in the RESP2 subscriber mode is allowed to issue. QUIT and RESET, which
+// Redis allows too, don't exist here.
+var SubscriberModeCommands = map[string]bool{
+	SUBSCRIBE:    true,
+	UNSUBSCRIBE:  true,
+	PSUBSCRIBE:   true,
+	PUNSUBSCRIBE: true,
+	SSUBSCRIBE:   true,
+	SUNSUBSCRIBE: true,
+	PING:         true,
+}
+
+// commands that only make sense on a connection to the server, which the embedded engines don't run:
+// those that change the state of the connection, subscribe it to channels or span several commands.
+var ConnectionCommands = map[string]bool{
+	HELLO:        true,
+	AUTH:         true,
+	CLIENT:       true,
+	SUBSCRIBE:    true,
+	UNSUBSCRIBE:  true,
+	PSUBSCRIBE:   true,
+	PUNSUBSCRIBE: true,
+	SSUBSCRIBE:   true,
+	SUNSUBSCRIBE: true,
+	MULTI:        true,
+	EXEC:         true,
+	DISCARD:      true,
+	WATCH:        true,
+	UNWATCH:      true,
+	SHUTDOWN:     true,
+}
+
 // supported command arguments
 const (
-	EX = "ex"
-	PX = "px"
+	EX        = "ex"
+	PX        = "px"
+	NX        = "nx"
+	XX        = "xx"
+	GT        = "gt"
+	LT        = "lt"
+	ASYNC     = "async"
+	SYNC      = "sync"
+	DB        = "db"
+	REPLACE   = "replace"
+	MATCH     = "match"
+	COUNT     = "count"
+	SCAN_TYPE = "type"
 )
 
 // This map will hold data about all the supported Commands.
@@ -54,38 +390,507 @@ var CommandMap = map[string]*Command{}
 
 func init() {
 	CommandMap[PING] = &Command{
-		Name: PING,
-		Eval: evalPing,
+		Name:       PING,
+		Arity:      -1,
+		Flags:      FlagFast,
+		Tips:       []string{"request_policy:all_shards", "response_policy:all_succeeded"},
+		ClientEval: evalPing,
+		Categories: []string{acl.CategoryFast, acl.CategoryConnection},
 	}
 
 	CommandMap[GET] = &Command{
-		Name: GET,
-		Eval: evalGet,
+		Name:       GET,
+		Arity:      2,
+		Flags:      FlagReadOnly | FlagFast,
+		Eval:       evalGet,
+		Categories: []string{acl.CategoryRead, acl.CategoryString, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyRead}},
 	}
 
 	CommandMap[SET] = &Command{
-		Name: SET,
-		Eval: evalSet,
+		Name:       SET,
+		Arity:      -3,
+		Flags:      FlagWrite | FlagDenyOom,
+		Eval:       evalSet,
+		Categories: []string{acl.CategoryWrite, acl.CategoryString, acl.CategorySlow},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyWrite}},
 	}
 
 	CommandMap[TTL] = &Command{
-		Name: TTL,
-		Eval: evalTtl,
+		Name:       TTL,
+		Arity:      2,
+		Flags:      FlagReadOnly | FlagFast,
+		Tips:       []string{"nondeterministic_output"},
+		Eval:       evalTtl,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1}},
 	}
 
 	CommandMap[DEL] = &Command{
-		Name: DEL,
-		Eval: evalDel,
+		Name:       DEL,
+		Arity:      -2,
+		Flags:      FlagWrite,
+		Tips:       []string{"request_policy:multi_shard", "response_policy:agg_sum"},
+		Eval:       evalDel,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategorySlow},
+		KeySpecs:   []KeySpec{{First: 1, Last: -1, Step: 1, Access: KeyWrite}},
 	}
 
 	CommandMap[EXPIRE] = &Command{
-		Name: EXPIRE,
-		Eval: evalExpire,
+		Name:       EXPIRE,
+		Arity:      -3,
+		Flags:      FlagWrite | FlagFast,
+		Eval:       evalExpire,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[PEXPIRE] = &Command{
+		Name:       PEXPIRE,
+		Arity:      -3,
+		Flags:      FlagWrite | FlagFast,
+		Eval:       evalPExpire,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyWrite}},
 	}
 
+	CommandMap[HELLO] = &Command{
+		Name:       HELLO,
+		Arity:      -1,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagNoAuth | FlagAllowBusy,
+		ClientEval: evalHello,
+		Categories: []string{acl.CategoryFast, acl.CategoryConnection},
+		Redact:     redactHello,
+	}
+
+	CommandMap[SUBSCRIBE] = &Command{
+		Name:       SUBSCRIBE,
+		Arity:      -2,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalSubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[UNSUBSCRIBE] = &Command{
+		Name:       UNSUBSCRIBE,
+		Arity:      -1,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalUnsubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[PSUBSCRIBE] = &Command{
+		Name:       PSUBSCRIBE,
+		Arity:      -2,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalPSubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[PUNSUBSCRIBE] = &Command{
+		Name:       PUNSUBSCRIBE,
+		Arity:      -1,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalPUnsubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[PUBLISH] = &Command{
+		Name:       PUBLISH,
+		Arity:      3,
+		Flags:      FlagPubSub | FlagLoading | FlagStale | FlagFast | FlagMayReplicate,
+		ClientEval: evalPublish,
+		Categories: []string{acl.CategoryPubSub, acl.CategoryFast},
+	}
+
+	CommandMap[PUBSUB] = &Command{
+		Name:       PUBSUB,
+		Arity:      -2,
+		ClientEval: evalPubSub,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: Subcommands(PUBSUB,
+			Subcommand(CHANNELS, -2, FlagPubSub|FlagLoading|FlagStale, acl.CategoryPubSub, acl.CategorySlow),
+			Subcommand(NUMSUB, -2, FlagPubSub|FlagLoading|FlagStale, acl.CategoryPubSub, acl.CategorySlow),
+			Subcommand(NUMPAT, 2, FlagPubSub|FlagLoading|FlagStale, acl.CategoryPubSub, acl.CategorySlow),
+			Subcommand(SHARDCHANNELS, -2, FlagPubSub|FlagLoading|FlagStale, acl.CategoryPubSub, acl.CategorySlow),
+			Subcommand(SHARDNUMSUB, -2, FlagPubSub|FlagLoading|FlagStale, acl.CategoryPubSub, acl.CategorySlow),
+		),
+	}
+
+	CommandMap[SSUBSCRIBE] = &Command{
+		Name:       SSUBSCRIBE,
+		Arity:      -2,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalSSubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[SUNSUBSCRIBE] = &Command{
+		Name:       SUNSUBSCRIBE,
+		Arity:      -1,
+		Flags:      FlagPubSub | FlagNoScript | FlagLoading | FlagStale,
+		ClientEval: evalSUnsubscribe,
+		Categories: []string{acl.CategoryPubSub, acl.CategorySlow},
+	}
+
+	CommandMap[SPUBLISH] = &Command{
+		Name:       SPUBLISH,
+		Arity:      3,
+		Flags:      FlagPubSub | FlagLoading | FlagStale | FlagFast | FlagMayReplicate,
+		ClientEval: evalSPublish,
+		Categories: []string{acl.CategoryPubSub, acl.CategoryFast},
+	}
+
+	CommandMap[MULTI] = &Command{
+		Name:       MULTI,
+		Arity:      1,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagAllowBusy,
+		ClientEval: evalMulti,
+		Categories: []string{acl.CategoryFast, acl.CategoryTransaction},
+	}
+
+	CommandMap[DISCARD] = &Command{
+		Name:       DISCARD,
+		Arity:      1,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagAllowBusy,
+		ClientEval: evalDiscard,
+		Categories: []string{acl.CategoryFast, acl.CategoryTransaction},
+	}
+
+	CommandMap[WATCH] = &Command{
+		Name:       WATCH,
+		Arity:      -2,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagAllowBusy,
+		ClientEval: evalWatch,
+		Categories: []string{acl.CategoryFast, acl.CategoryTransaction},
+		KeySpecs:   []KeySpec{{First: 1, Last: -1, Step: 1}},
+	}
+
+	CommandMap[UNWATCH] = &Command{
+		Name:       UNWATCH,
+		Arity:      1,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagAllowBusy,
+		ClientEval: evalUnwatch,
+		Categories: []string{acl.CategoryFast, acl.CategoryTransaction},
+	}
+
+	CommandMap[SELECT] = &Command{
+		Name:       SELECT,
+		Arity:      2,
+		Flags:      FlagLoading | FlagStale | FlagFast,
+		ClientEval: evalSelect,
+		Categories: []string{acl.CategoryFast, acl.CategoryConnection},
+	}
+
+	CommandMap[MOVE] = &Command{
+		Name:       MOVE,
+		Arity:      3,
+		Flags:      FlagWrite | FlagFast,
+		ClientEval: evalMove,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyReadWrite}},
+	}
+
+	CommandMap[SWAPDB] = &Command{
+		Name:       SWAPDB,
+		Arity:      3,
+		Flags:      FlagWrite | FlagFast,
+		ClientEval: evalSwapDb,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast, acl.CategoryDangerous},
+	}
+
+	CommandMap[FLUSHDB] = &Command{
+		Name:       FLUSHDB,
+		Arity:      -1,
+		Flags:      FlagWrite,
+		Tips:       []string{"request_policy:all_shards", "response_policy:all_succeeded"},
+		Eval:       evalFlushDb,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategorySlow, acl.CategoryDangerous},
+	}
+
+	CommandMap[FLUSHALL] = &Command{
+		Name:       FLUSHALL,
+		Arity:      -1,
+		Flags:      FlagWrite,
+		Tips:       []string{"request_policy:all_shards", "response_policy:all_succeeded"},
+		ClientEval: evalFlushAll,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategorySlow, acl.CategoryDangerous},
+	}
+
+	CommandMap[DBSIZE] = &Command{
+		Name:       DBSIZE,
+		Arity:      1,
+		Flags:      FlagReadOnly | FlagFast,
+		Tips:       []string{"request_policy:all_shards", "response_policy:agg_sum"},
+		Eval:       evalDbSize,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategoryFast},
+	}
+
+	CommandMap[EXISTS] = &Command{
+		Name:       EXISTS,
+		Arity:      -2,
+		Flags:      FlagReadOnly | FlagFast,
+		Tips:       []string{"request_policy:multi_shard", "response_policy:agg_sum"},
+		Eval:       evalExists,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: -1, Step: 1}},
+	}
+
+	CommandMap[TYPE] = &Command{
+		Name:       TYPE,
+		Arity:      2,
+		Flags:      FlagReadOnly | FlagFast,
+		Eval:       evalType,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1}},
+	}
+
+	CommandMap[RENAME] = &Command{
+		Name:       RENAME,
+		Arity:      3,
+		Flags:      FlagWrite,
+		Eval:       evalRename,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategorySlow},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyReadWrite}, {First: 2, Last: 2, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[RENAMENX] = &Command{
+		Name:       RENAMENX,
+		Arity:      3,
+		Flags:      FlagWrite | FlagFast,
+		Eval:       evalRenameNx,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyReadWrite}, {First: 2, Last: 2, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[COPY] = &Command{
+		Name:       COPY,
+		Arity:      -3,
+		Flags:      FlagWrite | FlagDenyOom,
+		ClientEval: evalCopy,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategorySlow},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyRead}, {First: 2, Last: 2, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[KEYS] = &Command{
+		Name:       KEYS,
+		Arity:      2,
+		Flags:      FlagReadOnly,
+		Tips:       []string{"request_policy:all_shards", "nondeterministic_output"},
+		Eval:       evalKeys,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategorySlow, acl.CategoryDangerous},
+	}
+
+	CommandMap[SCAN] = &Command{
+		Name:       SCAN,
+		Arity:      -2,
+		Flags:      FlagReadOnly,
+		Tips:       []string{"nondeterministic_output", "request_policy:special"},
+		Eval:       evalScan,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategorySlow},
+	}
+
+	CommandMap[RANDOMKEY] = &Command{
+		Name:       RANDOMKEY,
+		Arity:      1,
+		Flags:      FlagReadOnly,
+		Tips:       []string{"request_policy:all_shards", "response_policy:special", "nondeterministic_output"},
+		Eval:       evalRandomKey,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategorySlow},
+	}
+
+	CommandMap[TOUCH] = &Command{
+		Name:       TOUCH,
+		Arity:      -2,
+		Flags:      FlagReadOnly | FlagFast,
+		Tips:       []string{"request_policy:multi_shard", "response_policy:agg_sum"},
+		Eval:       evalTouch,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryRead, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: -1, Step: 1}},
+	}
+
+	CommandMap[UNLINK] = &Command{
+		Name:       UNLINK,
+		Arity:      -2,
+		Flags:      FlagWrite | FlagFast,
+		Tips:       []string{"request_policy:multi_shard", "response_policy:agg_sum"},
+		Eval:       evalUnlink,
+		Categories: []string{acl.CategoryKeyspace, acl.CategoryWrite, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: -1, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[HSET] = &Command{
+		Name:       HSET,
+		Arity:      -4,
+		Flags:      FlagWrite | FlagDenyOom | FlagFast,
+		Eval:       evalHSet,
+		Categories: []string{acl.CategoryWrite, acl.CategoryHash, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[HGET] = &Command{
+		Name:       HGET,
+		Arity:      3,
+		Flags:      FlagReadOnly | FlagFast,
+		Eval:       evalHGet,
+		Categories: []string{acl.CategoryRead, acl.CategoryHash, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyRead}},
+	}
+
+	CommandMap[HDEL] = &Command{
+		Name:       HDEL,
+		Arity:      -3,
+		Flags:      FlagWrite | FlagFast,
+		Eval:       evalHDel,
+		Categories: []string{acl.CategoryWrite, acl.CategoryHash, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyWrite}},
+	}
+
+	CommandMap[HGETALL] = &Command{
+		Name:       HGETALL,
+		Arity:      2,
+		Flags:      FlagReadOnly,
+		Tips:       []string{"nondeterministic_output_order"},
+		ClientEval: evalHGetAll,
+		Categories: []string{acl.CategoryRead, acl.CategoryHash, acl.CategorySlow},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1, Access: KeyRead}},
+	}
+
+	CommandMap[HLEN] = &Command{
+		Name:       HLEN,
+		Arity:      2,
+		Flags:      FlagReadOnly | FlagFast,
+		Eval:       evalHLen,
+		Categories: []string{acl.CategoryRead, acl.CategoryHash, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1}},
+	}
+
+	CommandMap[HEXISTS] = &Command{
+		Name:       HEXISTS,
+		Arity:      3,
+		Flags:      FlagReadOnly | FlagFast,
+		Eval:       evalHExists,
+		Categories: []string{acl.CategoryRead, acl.CategoryHash, acl.CategoryFast},
+		KeySpecs:   []KeySpec{{First: 1, Last: 1, Step: 1}},
+	}
+
+	CommandMap[INFO] = &Command{
+		Name:       INFO,
+		Arity:      -1,
+		Flags:      FlagLoading | FlagStale,
+		Tips:       []string{"nondeterministic_output", "request_policy:all_shards", "response_policy:special"},
+		ClientEval: evalInfo,
+		Categories: []string{acl.CategorySlow, acl.CategoryDangerous},
+	}
+
+	CommandMap[LATENCY] = &Command{
+		Name:       LATENCY,
+		Arity:      -2,
+		ClientEval: evalLatency,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: Subcommands(LATENCY,
+			Subcommand(latencyLatest, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(latencyHistory, 3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(latencyReset, -2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(latencyHistogram, -2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+		),
+	}
+
+	CommandMap[SLOWLOG] = &Command{
+		Name:       SLOWLOG,
+		Arity:      -2,
+		ClientEval: evalSlowlog,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: Subcommands(SLOWLOG,
+			Subcommand(slowlogGet, -2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(slowlogLen, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(slowlogReset, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+		),
+	}
+
+	CommandMap[CLIENT] = &Command{
+		Name:       CLIENT,
+		Arity:      -2,
+		ClientEval: evalClient,
+		Categories: []string{acl.CategorySlow},
+		Subcommands: Subcommands(CLIENT,
+			Subcommand(clientId, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientSetName, 3, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientGetName, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientList, -2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous, acl.CategoryConnection),
+			Subcommand(clientInfo, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientKill, -3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous, acl.CategoryConnection),
+			Subcommand(clientPause, -3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous, acl.CategoryConnection),
+			Subcommand(clientUnpause, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous, acl.CategoryConnection),
+			Subcommand(clientNoEvict, 3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous, acl.CategoryConnection),
+			Subcommand(clientNoTouch, 3, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientTracking, -3, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientCaching, 3, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientGetRedir, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(clientTrackingInfo, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+		),
+	}
+
+	CommandMap[AUTH] = &Command{
+		Name:       AUTH,
+		Arity:      -2,
+		Flags:      FlagNoScript | FlagLoading | FlagStale | FlagFast | FlagNoAuth | FlagAllowBusy,
+		ClientEval: evalAuth,
+		Categories: []string{acl.CategoryFast, acl.CategoryConnection},
+		Redact:     redactAuth,
+	}
+
+	CommandMap[ACL] = &Command{
+		Name:       ACL,
+		Arity:      -2,
+		ClientEval: evalAcl,
+		Categories: []string{acl.CategorySlow},
+		Redact:     redactAcl,
+		Subcommands: Subcommands(ACL,
+			Subcommand(aclCat, -2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow),
+			Subcommand(aclDelUser, -3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclDryRun, -4, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclGetUser, 3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclList, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclLoad, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclLog, -2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclSave, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclSetUser, -3, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclUsers, 2, FlagAdmin|FlagNoScript|FlagLoading|FlagStale, acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous),
+			Subcommand(aclWhoAmI, 2, FlagNoScript|FlagLoading|FlagStale, acl.CategorySlow),
+		),
+	}
+
+	CommandMap[COMMAND] = &Command{
+		Name:       COMMAND,
+		Arity:      -1,
+		Flags:      FlagLoading | FlagStale,
+		ClientEval: evalCommand,
+		Categories: []string{acl.CategorySlow, acl.CategoryConnection},
+		Tips:       []string{"nondeterministic_output_order"},
+		Subcommands: Subcommands(COMMAND,
+			Subcommand(commandCount, 2, FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(commandDocs, -2, FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(commandGetKeys, -3, FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(commandInfo, -2, FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+			Subcommand(commandList, -2, FlagLoading|FlagStale, acl.CategorySlow, acl.CategoryConnection),
+		),
+	}
+
+	CommandMap[SHUTDOWN] = &Command{
+		Name:       SHUTDOWN,
+		Arity:      -1,
+		Flags:      FlagAdmin | FlagNoScript | FlagLoading | FlagStale | FlagAllowBusy,
+		ClientEval: evalShutdown,
+		Categories: []string{acl.CategoryAdmin, acl.CategorySlow, acl.CategoryDangerous},
+	}
+
+	// EXEC, EVAL, FCALL and the other scripting commands run commands through the command handler,
+	// which registers them.
+
 	// Validate that all commands have a non-nil Eval function
 	for name, cmd := range CommandMap {
-		if cmd.Eval == nil {
+		if cmd.Eval == nil && cmd.ClientEval == nil {
 			log.Fatalf("Command %s has a nil Eval function", name)
 		}
 	}
@@ -0,0 +1,173 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// DatabasesOf returns the databases the commands of the client run against: those of the embedded
+// engine the client belongs to, if any, else the server's.
+func DatabasesOf(c *client.Client) *store.Databases {
+	return InstanceOf(c).Databases
+}
+
+// parses the index of one of the databases of the client, returning errOnInvalid if it is not an integer.
+func parseDbIndex(c *client.Client, arg string, errOnInvalid error) (int, error) {
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, errOnInvalid
+	}
+	if DatabasesOf(c).Get(index) == nil {
+		return 0, errors.New("ERR DB index is out of range")
+	}
+	return index, nil
+}
+
+// evalSelect processes the SELECT command, which switches the database the
+// client's commands run against.
+func evalSelect(args []string, c *client.Client, s store.Store) *EvalResult {
+	index, err := parseDbIndex(c, args[0], errors.New("ERR value is not an integer or out of range"))
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	c.Db = index
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalMove processes the MOVE command, which moves the key from the selected database into another one.
+// Returns 1 if the key was moved, or 0 if it doesn't exist or already exists in the other database.
+func evalMove(args []string, c *client.Client, s store.Store) *EvalResult {
+	index, err := parseDbIndex(c, args[1], errors.New("ERR value is not an integer or out of range"))
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	if index == c.Db {
+		return &EvalResult{
+			Error:    errors.New("ERR source and destination objects are the same"),
+			Response: nil,
+		}
+	}
+
+	moved := 0
+	if s.Move(args[0], DatabasesOf(c).Get(index)) {
+		moved = 1
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(moved, false),
+		Error:    nil,
+	}
+}
+
+// evalSwapDb processes the SWAPDB command, which swaps the keys of the two databases.
+// The clients connected to either database see the keys of the other one right away.
+func evalSwapDb(args []string, c *client.Client, s store.Store) *EvalResult {
+	first, err := parseDbIndex(c, args[0], errors.New("ERR invalid first DB index"))
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	second, err := parseDbIndex(c, args[1], errors.New("ERR invalid second DB index"))
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	DatabasesOf(c).Swap(first, second)
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// parses the optional ASYNC|SYNC argument of FLUSHDB and FLUSHALL.
+// returns true if the keys are to be freed in the background.
+func parseFlushMode(cmd string, args []string) (bool, error) {
+	if len(args) > 1 {
+		return false, commons.WrongNumberOfArgumentsErr(cmd)
+	}
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case ASYNC:
+		return true, nil
+	case SYNC:
+		return false, nil
+	default:
+		return false, commons.SyntaxErr()
+	}
+}
+
+// evalFlushDb processes the FLUSHDB command, which deletes all the keys of the selected database.
+func evalFlushDb(args []string, s store.Store) *EvalResult {
+	async, err := parseFlushMode(FLUSHDB, args)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	if async {
+		s.ResetAsync()
+	} else {
+		s.Reset()
+	}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalFlushAll processes the FLUSHALL command, which deletes all the keys of all the databases.
+func evalFlushAll(args []string, c *client.Client, s store.Store) *EvalResult {
+	async, err := parseFlushMode(FLUSHALL, args)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	DatabasesOf(c).ResetAll(async)
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalDbSize processes the DBSIZE command and returns the number of keys in the selected database.
+func evalDbSize(args []string, s store.Store) *EvalResult {
+	return &EvalResult{
+		Response: resp.Encode(s.KeyCount(), false),
+		Error:    nil,
+	}
+}
@@ -1,7 +1,6 @@
 package eval
 
 import (
-	"github.com/shashwatrathod/redis-internals/commons"
 	"github.com/shashwatrathod/redis-internals/core/resp"
 	"github.com/shashwatrathod/redis-internals/core/store"
 )
@@ -9,17 +8,20 @@ import (
 // evalDel processes the DEL command and deletes the keys passed in the arguments from the store.
 // Returns the number of keys deleted in the result.
 func evalDel(args []string, s store.Store) *EvalResult {
-	if len(args) == 0 {
-		return &EvalResult{
-			Error:    commons.WrongNumberOfArgumentsErr(DEL),
-			Response: nil,
-		}
-	}
+	return deleteKeys(args, s.Delete)
+}
+
+// evalUnlink processes the UNLINK command, which deletes the keys like DEL does, but frees
+// their values in the background. Returns the number of keys deleted in the result.
+func evalUnlink(args []string, s store.Store) *EvalResult {
+	return deleteKeys(args, s.Unlink)
+}
 
+func deleteKeys(args []string, del func(key string) bool) *EvalResult {
 	nDeleted := 0
 
 	for _, key := range args {
-		if isDeleted := s.Delete(key); isDeleted {
+		if isDeleted := del(key); isDeleted {
 			nDeleted++
 		}
 	}
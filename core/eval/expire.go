@@ -1,7 +1,10 @@
 package eval
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/shashwatrathod/redis-internals/commons"
 	"github.com/shashwatrathod/redis-internals/core/resp"
@@ -26,39 +29,68 @@ func ttlSetResponse() *EvalResult {
 // Evaluates the EXPIRE command by setting the TTL to the given value
 // on the provided key.
 func evalExpire(args []string, s store.Store) *EvalResult {
-	if len(args) < 2 {
+	return expire(EXPIRE, args, s, utils.FromExpiryInSeconds)
+}
+
+// evalPExpire processes the PEXPIRE command, which sets the TTL of the key like EXPIRE, in milliseconds.
+func evalPExpire(args []string, s store.Store) *EvalResult {
+	return expire(PEXPIRE, args, s, utils.FromExpiryInMilliseconds)
+}
+
+// sets the expiry of the key to the TTL in args, in the unit of the command: cmd key ttl [NX | XX | GT | LT].
+// With NX, the expiry is only set if the key has none, with XX only if it has one, and with GT and LT
+// only if it's later or earlier than the one it has. A key without an expiry lives forever as far as
+// GT and LT are concerned. Replies with 1 if the expiry was set, and 0 if it wasn't or the key
+// doesn't exist.
+func expire(cmd string, args []string, s store.Store, expiryIn func(ttl int64) *utils.ExpiryTime) *EvalResult {
+	key := args[0]
+	ttl, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
 		return &EvalResult{
-			Error:    commons.WrongNumberOfArgumentsErr(EXPIRE),
+			Error:    commons.UnknownCommandErr(cmd, args),
 			Response: nil,
 		}
 	}
 
-	key := args[0]
-	expiryInSeconds, err := strconv.ParseInt(args[1], 10, 64)
-	if err != nil {
+	options := make(map[string]bool)
+	for _, arg := range args[2:] {
+		option := strings.ToLower(arg)
+		if option != NX && option != XX && option != GT && option != LT {
+			return &EvalResult{
+				Error:    fmt.Errorf("ERR Unsupported option %s", arg),
+				Response: nil,
+			}
+		}
+		options[option] = true
+	}
+	if options[NX] && (options[XX] || options[GT] || options[LT]) {
 		return &EvalResult{
-			Error:    commons.UnknownCommandErr(EXPIRE, args),
+			Error:    errors.New("ERR NX and XX, GT or LT options at the same time are not compatible"),
+			Response: nil,
+		}
+	}
+	if options[GT] && options[LT] {
+		return &EvalResult{
+			Error:    errors.New("ERR GT and LT options at the same time are not compatible"),
 			Response: nil,
 		}
 	}
 
-	val := s.Get(key)
-
-	if val == nil {
+	// an expired key is deleted as it's looked up.
+	if s.Get(key) == nil {
 		return ttlNotSetResponse()
 	}
 
-	currentExpiry := s.GetExpiry(key)
-
-	if currentExpiry == nil || utils.FromExpiryInUnixTime(*currentExpiry).IsExpired() {
+	expiry := expiryIn(ttl)
+	current := s.GetExpiry(key)
+	switch {
+	case options[NX] && current != nil,
+		options[XX] && current == nil,
+		options[GT] && (current == nil || expiry.ToUnixMilli() <= *current),
+		options[LT] && current != nil && expiry.ToUnixMilli() >= *current:
 		return ttlNotSetResponse()
 	}
 
-	proposedExpiry := utils.FromExpiryInSeconds(expiryInSeconds)
-
-	s.SetExpiry(key, proposedExpiry)
-
-	// TODO: Add support for additional arguments
-
+	s.SetExpiry(key, expiry)
 	return ttlSetResponse()
 }
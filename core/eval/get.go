@@ -11,13 +11,6 @@ import (
 // The GET command returns the value of the specified key. If the key does not exist,
 // it returns a special nil value.
 func evalGet(args []string, s store.Store) *EvalResult {
-	if len(args) != 1 {
-		return &EvalResult{
-			Response: nil,
-			Error:    commons.WrongNumberOfArgumentsErr(GET),
-		}
-	}
-
 	key := args[0]
 
 	val := s.Get(key)
@@ -30,8 +23,15 @@ func evalGet(args []string, s store.Store) *EvalResult {
 		}
 	}
 
+	if val.ValueType != store.String {
+		return &EvalResult{
+			Response: nil,
+			Error:    commons.WrongTypeErr(),
+		}
+	}
+
 	// If the Key exists but the Value is expired. This edge case should techincally never occur.
-	if exp := s.GetExpiry(key); exp != nil && utils.FromExpiryInUnixTime(*exp).IsExpired() {
+	if exp := s.GetExpiry(key); exp != nil && utils.FromExpiryInUnixMilli(*exp).IsExpired() {
 		return &EvalResult{
 			Response: []byte("$-1\r\n"),
 			Error:    nil,
@@ -0,0 +1,154 @@
+package eval
+
+import (
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// evalHSet processes the HSET command, which sets the fields of the hash to the values given as
+// field value pairs, creating the hash if needed. Returns the number of fields that were added.
+func evalHSet(args []string, s store.Store) *EvalResult {
+	if len(args)%2 == 0 {
+		return &EvalResult{
+			Error:    commons.WrongNumberOfArgumentsErr(HSET),
+			Response: nil,
+		}
+	}
+
+	added, err := s.HSet(args[0], args[1:])
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(added, false),
+		Error:    nil,
+	}
+}
+
+// evalHGet processes the HGET command, which returns the value of the field of the hash, or nil
+// if either doesn't exist.
+func evalHGet(args []string, s store.Store) *EvalResult {
+	hash, err := hashOf(args[0], s)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	if hash != nil {
+		if value, exists := hash.Get(args[1]); exists {
+			return &EvalResult{
+				Response: resp.Encode(value, false),
+				Error:    nil,
+			}
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.NullBulkString,
+		Error:    nil,
+	}
+}
+
+// evalHDel processes the HDEL command, which deletes the fields from the hash. Returns the number
+// of fields that were deleted.
+func evalHDel(args []string, s store.Store) *EvalResult {
+	deleted, err := s.HDel(args[0], args[1:])
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(deleted, false),
+		Error:    nil,
+	}
+}
+
+// evalHGetAll processes the HGETALL command, which returns the fields of the hash along with their
+// values, as a map in RESP3 and as a flat array of alternating fields and values in RESP2.
+func evalHGetAll(args []string, c *client.Client, s store.Store) *EvalResult {
+	hash, err := hashOf(args[0], s)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	pairs := []interface{}{}
+	if hash != nil {
+		hash.ForEach(func(field string, value string) bool {
+			pairs = append(pairs, field, value)
+			return true
+		})
+	}
+
+	return &EvalResult{
+		Response: resp.EncodeMap(pairs, c.Protocol == resp.Resp3),
+		Error:    nil,
+	}
+}
+
+// evalHLen processes the HLEN command, which returns the number of fields of the hash.
+func evalHLen(args []string, s store.Store) *EvalResult {
+	hash, err := hashOf(args[0], s)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	n := 0
+	if hash != nil {
+		n = hash.Len()
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(n, false),
+		Error:    nil,
+	}
+}
+
+// evalHExists processes the HEXISTS command, which returns 1 if the hash has the field, else 0.
+func evalHExists(args []string, s store.Store) *EvalResult {
+	hash, err := hashOf(args[0], s)
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	exists := 0
+	if hash != nil {
+		if _, found := hash.Get(args[1]); found {
+			exists = 1
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(exists, false),
+		Error:    nil,
+	}
+}
+
+// returns the fields of the hash at the key, nil if the key doesn't exist, and an error if it
+// holds another type of value.
+func hashOf(key string, s store.Store) (*store.Dict[string], error) {
+	value := s.Get(key)
+	if value == nil {
+		return nil, nil
+	}
+	return value.Hash()
+}
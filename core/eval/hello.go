@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// evalHello processes the HELLO [protover [AUTH username password] [SETNAME clientname]] command,
+// which switches the protocol spoken by the connection to the requested version, optionally
+// authenticates the client and names the connection, and replies with a summary of the server.
+func evalHello(args []string, c *client.Client, s store.Store) *EvalResult {
+	protocol := c.Protocol
+	if len(args) > 0 {
+		var err error
+		protocol, err = strconv.Atoi(args[0])
+		if err != nil {
+			return &EvalResult{
+				Error:    errors.New("ERR Protocol version is not an integer or out of range"),
+				Response: nil,
+			}
+		}
+		if protocol != resp.Resp2 && protocol != resp.Resp3 {
+			return &EvalResult{
+				Error:    errors.New("NOPROTO unsupported protocol version"),
+				Response: nil,
+			}
+		}
+	}
+
+	var name *string = nil
+	var credentials []string = nil
+	for i := 1; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); {
+		case option == "AUTH" && i+2 < len(args):
+			credentials = args[i+1 : i+3]
+			i += 2
+		case option == "SETNAME" && i+1 < len(args):
+			name = &args[i+1]
+			i++
+		default:
+			return &EvalResult{
+				Error:    errors.New("ERR syntax error in HELLO option '" + args[i] + "'"),
+				Response: nil,
+			}
+		}
+	}
+
+	if credentials != nil {
+		if err := authenticate(c, credentials[0], credentials[1]); err != nil {
+			return &EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+	} else if InstanceOf(c).Users.AuthRequired(c) {
+		return &EvalResult{
+			Error:    errors.New("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time"),
+			Response: nil,
+		}
+	}
+
+	if name != nil {
+		if err := setClientName(c, *name); err != nil {
+			return &EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+	}
+	c.Protocol = protocol
+
+	return &EvalResult{
+		Response: resp.EncodeMap([]interface{}{
+			"server", "redis",
+			"version", "7.2.0",
+			"proto", c.Protocol,
+			"id", c.Id,
+			"mode", "standalone",
+			"role", "master",
+			"modules", []interface{}{},
+		}, c.Protocol == resp.Resp3),
+		Error: nil,
+	}
+}
+
+// redacts the username and the password given to HELLO's AUTH option.
+func redactHello(args []string) []string {
+	redacted := append([]string{}, args...)
+	for i := 1; i < len(redacted); i++ {
+		if strings.ToUpper(redacted[i]) == "AUTH" {
+			for j := i + 1; j < len(redacted) && j <= i+2; j++ {
+				redacted[j] = redactedArg
+			}
+			i += 2
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,291 @@
+package eval
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// the version of Redis whose commands and replies the server mimics, reported by INFO for the
+// tools that check it.
+const redisVersion = "7.2.4"
+
+// number of keys with an expiry sampled to estimate the average TTL of a database.
+const avgTtlSampleSize = 20
+
+// a field of the INFO reply, listed as name:value.
+type infoField struct {
+	name  string
+	value interface{}
+}
+
+// a section of the INFO reply. the fields are computed every time the section is listed.
+type infoSection struct {
+	name   string
+	fields func(c *client.Client) []infoField
+	// whether the section is listed by default, as opposed to only with INFO all or when asked for.
+	isDefault bool
+}
+
+// the sections of INFO, in the order they are listed.
+var infoSections = []infoSection{
+	{name: "Server", fields: serverInfo, isDefault: true},
+	{name: "Clients", fields: clientsInfo, isDefault: true},
+	{name: "Memory", fields: memoryInfo, isDefault: true},
+	{name: "Persistence", fields: persistenceInfo, isDefault: true},
+	{name: "Stats", fields: statsInfo, isDefault: true},
+	{name: "Replication", fields: replicationInfo, isDefault: true},
+	{name: "Commandstats", fields: commandStatsInfo},
+	{name: "Errorstats", fields: errorStatsInfo, isDefault: true},
+	{name: "Latencystats", fields: latencyStatsInfo},
+	{name: "Keyspace", fields: keyspaceInfo, isDefault: true},
+}
+
+// arguments of INFO that select all the sections.
+var allInfoSections = map[string]bool{
+	"all":        true,
+	"everything": true,
+}
+
+// percentiles of the latencies of the commands listed by INFO latencystats.
+var latencyStatsPercentiles = []float64{50, 99, 99.9}
+
+// evalInfo processes the INFO [section ...] command, which returns information and statistics
+// about the server. Lists the default sections when none are given, and all of them with
+// INFO all. Unknown sections are ignored.
+func evalInfo(args []string, c *client.Client, s store.Store) *EvalResult {
+	selected := make(map[string]bool)
+	all := false
+	defaults := len(args) == 0
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		all = all || allInfoSections[arg]
+		defaults = defaults || arg == "default"
+		selected[arg] = true
+	}
+
+	var info strings.Builder
+	for _, section := range infoSections {
+		if !all && !(defaults && section.isDefault) && !selected[strings.ToLower(section.name)] {
+			continue
+		}
+
+		if info.Len() > 0 {
+			info.WriteString("\r\n")
+		}
+		fmt.Fprintf(&info, "# %s\r\n", section.name)
+		for _, field := range section.fields(c) {
+			fmt.Fprintf(&info, "%s:%v\r\n", field.name, field.value)
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(info.String(), false),
+		Error:    nil,
+	}
+}
+
+func serverInfo(c *client.Client) []infoField {
+	uptime := InstanceOf(c).Stats.Uptime()
+
+	return []infoField{
+		{"redis_version", redisVersion},
+		{"redis_mode", "standalone"},
+		{"os", runtime.GOOS},
+		{"arch_bits", strconv.IntSize},
+		{"go_version", runtime.Version()},
+		{"process_id", os.Getpid()},
+		{"run_id", InstanceOf(c).Stats.RunId},
+		{"tcp_port", config.Port},
+		{"uptime_in_seconds", int64(uptime.Seconds())},
+		{"uptime_in_days", int64(uptime.Hours() / 24)},
+	}
+}
+
+func clientsInfo(c *client.Client) []infoField {
+	// none of the commands block the client, only CLIENT PAUSE does.
+	blocked := 0
+	for _, other := range InstanceOf(c).ConnectedClients() {
+		if other.Postponed != nil {
+			blocked++
+		}
+	}
+
+	return []infoField{
+		{"connected_clients", InstanceOf(c).Stats.ConnectedClients.Load()},
+		{"maxclients", config.MaxClients},
+		{"blocked_clients", blocked},
+		{"tracking_clients", InstanceOf(c).Tracker.Clients()},
+	}
+}
+
+func memoryInfo(c *client.Client) []infoField {
+	usedMemory := InstanceOf(c).Stats.TrackUsedMemory()
+	peak := InstanceOf(c).Stats.UsedMemoryPeak()
+
+	return []infoField{
+		{"used_memory", usedMemory},
+		{"used_memory_human", bytesToHuman(usedMemory)},
+		{"used_memory_peak", peak},
+		{"used_memory_peak_human", bytesToHuman(peak)},
+		{"lazyfree_pending_objects", store.GetLazyFreer().PendingObjects()},
+	}
+}
+
+// the dataset is never saved, so nothing is ever being loaded or saved.
+func persistenceInfo(*client.Client) []infoField {
+	return []infoField{
+		{"loading", 0},
+		{"async_loading", 0},
+		{"rdb_bgsave_in_progress", 0},
+		{"aof_enabled", 0},
+		{"aof_rewrite_in_progress", 0},
+	}
+}
+
+func statsInfo(c *client.Client) []infoField {
+	serverStats := InstanceOf(c).Stats
+	trackingKeys, trackingItems := InstanceOf(c).Tracker.TotalKeys()
+
+	return []infoField{
+		{"total_connections_received", serverStats.TotalConnectionsReceived.Load()},
+		{"total_commands_processed", serverStats.TotalCommandsProcessed.Load()},
+		{"total_error_replies", serverStats.TotalErrorReplies.Load()},
+		{"instantaneous_ops_per_sec", serverStats.InstantaneousOpsPerSec()},
+		{"rejected_connections", serverStats.RejectedConnections.Load()},
+		{"expired_keys", serverStats.ExpiredKeys.Load()},
+		{"expired_stale_perc", fmt.Sprintf("%.2f", serverStats.ExpiredStalePerc())},
+		{"evicted_keys", serverStats.EvictedKeys.Load()},
+		{"keyspace_hits", serverStats.KeyspaceHits.Load()},
+		{"keyspace_misses", serverStats.KeyspaceMisses.Load()},
+		{"lazyfreed_objects", store.GetLazyFreer().FreedObjects()},
+		{"tracking_total_keys", trackingKeys},
+		{"tracking_total_items", trackingItems},
+		{"tracking_total_prefixes", InstanceOf(c).Tracker.TotalPrefixes()},
+	}
+}
+
+// lists the calls of every command, as cmdstat_get:calls=1,usec=2,usec_per_call=2.00,rejected_calls=0,failed_calls=0
+func commandStatsInfo(c *client.Client) []infoField {
+	commands := InstanceOf(c).Stats.Commands()
+
+	fields := make([]infoField, 0, len(commands))
+	for _, cmd := range commands {
+		fields = append(fields, infoField{
+			"cmdstat_" + cmd.Name,
+			fmt.Sprintf("calls=%d,usec=%d,usec_per_call=%.2f,rejected_calls=%d,failed_calls=%d",
+				cmd.Calls, cmd.Usec, cmd.UsecPerCall(), cmd.RejectedCalls, cmd.FailedCalls),
+		})
+	}
+	return fields
+}
+
+// lists the number of error replies by their prefixes, as errorstat_ERR:count=1
+func errorStatsInfo(c *client.Client) []infoField {
+	prefixes, counts := InstanceOf(c).Stats.ErrorReplies()
+
+	fields := make([]infoField, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		fields = append(fields, infoField{"errorstat_" + prefix, fmt.Sprintf("count=%d", counts[prefix])})
+	}
+	return fields
+}
+
+// lists the latency percentiles of every command, as latency_percentiles_usec_get:p50=1.000,p99=2.000,p99.9=2.000
+func latencyStatsInfo(c *client.Client) []infoField {
+	commands := InstanceOf(c).Stats.Commands()
+
+	fields := make([]infoField, 0, len(commands))
+	for _, cmd := range commands {
+		if cmd.Calls == 0 {
+			continue
+		}
+
+		percentiles := make([]string, len(latencyStatsPercentiles))
+		for i, p := range latencyStatsPercentiles {
+			percentiles[i] = fmt.Sprintf("p%s=%.3f", strconv.FormatFloat(p, 'f', -1, 64), float64(cmd.Latency.Percentile(p)))
+		}
+		fields = append(fields, infoField{"latency_percentiles_usec_" + cmd.Name, strings.Join(percentiles, ",")})
+	}
+	return fields
+}
+
+// the server is always a master without replicas.
+func replicationInfo(c *client.Client) []infoField {
+	return []infoField{
+		{"role", "master"},
+		{"connected_slaves", 0},
+		{"master_replid", InstanceOf(c).Stats.RunId},
+		{"master_repl_offset", 0},
+	}
+}
+
+// lists the keys of the databases of the client that have any, as db0:keys=1,expires=0,avg_ttl=0
+func keyspaceInfo(c *client.Client) []infoField {
+	databases := DatabasesOf(c)
+
+	fields := make([]infoField, 0)
+	for i := 0; i < databases.Count(); i++ {
+		db := databases.Get(i)
+		if db.KeyCount() == 0 {
+			continue
+		}
+
+		fields = append(fields, infoField{
+			fmt.Sprintf("db%d", i),
+			fmt.Sprintf("keys=%d,expires=%d,avg_ttl=%d", db.KeyCount(), db.ExpiryCount(), averageTtl(db).Milliseconds()),
+		})
+	}
+	return fields
+}
+
+// estimates the average TTL of the keys with an expiry from a sample of them.
+func averageTtl(s store.Store) time.Duration {
+	var total time.Duration
+	sampled := 0
+
+	for _, key := range s.SampleKeysWithExpiry(avgTtlSampleSize) {
+		exp := s.GetExpiry(key)
+		if exp == nil {
+			continue
+		}
+
+		if ttl := time.Until(time.UnixMilli(*exp)); ttl > 0 {
+			total += ttl
+			sampled++
+		}
+	}
+
+	if sampled == 0 {
+		return 0
+	}
+	return total / time.Duration(sampled)
+}
+
+// formats a number of bytes the way Redis does, eg. 1.50M
+func bytesToHuman(n uint64) string {
+	units := []string{"K", "M", "G", "T", "P"}
+
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	value := float64(n)
+	unit := ""
+	for _, u := range units {
+		if value < 1024 {
+			break
+		}
+		value /= 1024
+		unit = u
+	}
+	return fmt.Sprintf("%.2f%s", value, unit)
+}
@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"sync"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/core/tracking"
+)
+
+// Instance is the state the commands run against: the databases, along with the stats, the ACL
+// users, the scripts and the rest of the state the commands read and modify. The clients of the
+// server share the one made of the server's, and every embedded engine has one of its own.
+type Instance struct {
+	Databases      *store.Databases
+	Stats          *stats.Stats
+	SlowLog        *stats.SlowLog
+	LatencyMonitor *stats.LatencyMonitor
+	Users          *acl.Users
+	AclLog         *acl.Log
+	PubSub         *pubsub.PubSub
+	Tracker        *tracking.Tracker
+	Scripts        *scripting.Scripts
+
+	// the client the commands called by the scripts run on behalf of.
+	ScriptClient *client.Client
+
+	// whether this is the instance of the server, whose clients are connected to it.
+	server bool
+
+	// the id of the last fake client of the instance, see NewFakeClient.
+	lastClientId int64
+}
+
+// the client the commands called by the scripts run the server's commands on behalf of. It's
+// created along with the package, so that it takes the first of the ids of the clients.
+var serverScriptClient = client.NewFakeClient()
+
+// the instance of the server, created once the config is set.
+var serverInstance = sync.OnceValue(func() *Instance {
+	return &Instance{
+		Databases:      store.GetDatabases(),
+		Stats:          stats.GetStats(),
+		SlowLog:        stats.GetSlowLog(),
+		LatencyMonitor: stats.GetLatencyMonitor(),
+		Users:          acl.GetUsers(),
+		AclLog:         acl.GetLog(),
+		PubSub:         pubsub.GetPubSub(),
+		Tracker:        tracking.GetTracker(),
+		Scripts:        scripting.GetScripts(),
+		ScriptClient:   serverScriptClient,
+		server:         true,
+	}
+})
+
+// returns the instance of the server.
+func ServerInstance() *Instance {
+	return serverInstance()
+}
+
+// returns a new instance with the given databases, slow log and latency monitor, eg. for an embedded
+// engine. The rest of its state is its own: its stats start out empty, its only user is the default
+// one and it has no scripts. Its databases report to its stats and to the latency monitor.
+func NewInstance(databases *store.Databases, slowLog *stats.SlowLog, latencyMonitor *stats.LatencyMonitor) *Instance {
+	i := &Instance{
+		Databases:      databases,
+		Stats:          stats.NewStats(),
+		SlowLog:        slowLog,
+		LatencyMonitor: latencyMonitor,
+		Users:          acl.NewUsers(),
+		AclLog:         acl.NewLog(),
+		PubSub:         pubsub.NewPubSub(),
+		Tracker:        tracking.NewTracker(),
+		Scripts:        scripting.NewScripts(),
+	}
+	for db := 0; db < databases.Count(); db++ {
+		databases.Get(db).SetStats(i.Stats, latencyMonitor)
+	}
+	i.ScriptClient = i.NewFakeClient()
+	return i
+}
+
+// InstanceOf returns the instance the commands of the client run against: the one of the embedded
+// engine the client belongs to, if any, else the server's.
+func InstanceOf(c *client.Client) *Instance {
+	if i, ok := c.Instance.(*Instance); ok {
+		return i
+	}
+	return ServerInstance()
+}
+
+// returns a fake client whose commands run against the instance. Its id is one of the instance's
+// own, so that it doesn't take one of the ids of the clients of the server.
+func (i *Instance) NewFakeClient() *client.Client {
+	i.lastClientId++
+	c := client.NewFakeClientWithId(i.lastClientId)
+	c.Instance = i
+	return c
+}
+
+// returns the clients connected to the instance, sorted by their ids. Only the server has any.
+func (i *Instance) ConnectedClients() []*client.Client {
+	if !i.server {
+		return nil
+	}
+	return client.Connected()
+}
+
+// returns the ACL file the users are loaded from and saved to, empty if there is none. Only the
+// server has one.
+func (i *Instance) AclFile() string {
+	if !i.server {
+		return ""
+	}
+	return config.AclFile
+}
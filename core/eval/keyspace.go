@@ -0,0 +1,252 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// number of keys SCAN visits when no COUNT is given.
+const defaultScanCount = 10
+
+// evalExists processes the EXISTS command and returns the number of the given keys that exist.
+// keys given multiple times are counted multiple times.
+func evalExists(args []string, s store.Store) *EvalResult {
+	nExisting := 0
+	for _, key := range args {
+		if s.Peek(key) != nil {
+			nExisting++
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(nExisting, false),
+		Error:    nil,
+	}
+}
+
+// evalType processes the TYPE command and returns the type of the key's value, or none if it doesn't exist.
+func evalType(args []string, s store.Store) *EvalResult {
+	typeName := "none"
+	if value := s.Peek(args[0]); value != nil {
+		typeName = value.TypeName()
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(typeName, true),
+		Error:    nil,
+	}
+}
+
+// evalRename processes the RENAME command, which renames the key, overwriting the new key if it exists.
+func evalRename(args []string, s store.Store) *EvalResult {
+	if s.Peek(args[0]) == nil {
+		return &EvalResult{
+			Error:    errors.New("ERR no such key"),
+			Response: nil,
+		}
+	}
+
+	s.Rename(args[0], args[1], true)
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalRenameNx processes the RENAMENX command, which renames the key only if the new key doesn't exist.
+// Returns 1 if the key was renamed, else 0.
+func evalRenameNx(args []string, s store.Store) *EvalResult {
+	if s.Peek(args[0]) == nil {
+		return &EvalResult{
+			Error:    errors.New("ERR no such key"),
+			Response: nil,
+		}
+	}
+
+	renamed := 0
+	if s.Rename(args[0], args[1], false) {
+		renamed = 1
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(renamed, false),
+		Error:    nil,
+	}
+}
+
+// evalCopy processes the COPY command: COPY source destination [DB destination-db] [REPLACE].
+// Returns 1 if the key was copied, else 0.
+func evalCopy(args []string, c *client.Client, s store.Store) *EvalResult {
+	db := c.Db
+	replace := false
+
+	for i := 2; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+
+		switch {
+		case arg == REPLACE:
+			replace = true
+		case arg == DB && i+1 < len(args):
+			index, err := parseDbIndex(c, args[i+1], errors.New("ERR value is not an integer or out of range"))
+			if err != nil {
+				return &EvalResult{
+					Error:    err,
+					Response: nil,
+				}
+			}
+			db = index
+			i++
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	if db == c.Db && args[0] == args[1] {
+		return &EvalResult{
+			Error:    errors.New("ERR source and destination objects are the same"),
+			Response: nil,
+		}
+	}
+
+	copied := 0
+	if s.Copy(args[0], DatabasesOf(c).Get(db), args[1], replace) {
+		copied = 1
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(copied, false),
+		Error:    nil,
+	}
+}
+
+// evalKeys processes the KEYS command and returns all the keys matching the glob-style pattern.
+func evalKeys(args []string, s store.Store) *EvalResult {
+	keys := make([]string, 0)
+	s.ForEach(func(key string, value *store.Value) bool {
+		if utils.GlobMatch(args[0], key) && s.Peek(key) != nil {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	return &EvalResult{
+		Response: resp.Encode(keys, false),
+		Error:    nil,
+	}
+}
+
+// evalScan processes the SCAN command: SCAN cursor [MATCH pattern] [COUNT count] [TYPE type].
+// Replies with the cursor to continue from, which is 0 once the scan is complete, and the keys
+// of the batch that pass the filters.
+func evalScan(args []string, s store.Store) *EvalResult {
+	// the options come in pairs after the cursor.
+	if len(args)%2 == 0 {
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return &EvalResult{
+			Error:    errors.New("ERR invalid cursor"),
+			Response: nil,
+		}
+	}
+
+	pattern := ""
+	typeName := ""
+	count := defaultScanCount
+
+	for i := 1; i < len(args); i += 2 {
+		switch strings.ToLower(args[i]) {
+		case MATCH:
+			pattern = args[i+1]
+		case COUNT:
+			count, err = strconv.Atoi(args[i+1])
+			if err != nil {
+				return &EvalResult{
+					Error:    errors.New("ERR value is not an integer or out of range"),
+					Response: nil,
+				}
+			}
+			if count < 1 {
+				return &EvalResult{
+					Error:    commons.SyntaxErr(),
+					Response: nil,
+				}
+			}
+		case SCAN_TYPE:
+			typeName = strings.ToLower(args[i+1])
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	keys := make([]string, 0)
+	next := s.Scan(cursor, count, func(key string, value *store.Value) {
+		if pattern != "" && !utils.GlobMatch(pattern, key) {
+			return
+		}
+		if typeName != "" && value.TypeName() != typeName {
+			return
+		}
+		// expired keys that haven't been purged yet are skipped, and purged along the way.
+		if s.Peek(key) == nil {
+			return
+		}
+		keys = append(keys, key)
+	})
+
+	return &EvalResult{
+		Response: resp.Encode([]interface{}{strconv.FormatUint(next, 10), keys}, false),
+		Error:    nil,
+	}
+}
+
+// evalRandomKey processes the RANDOMKEY command and returns a random key, or null if the database is empty.
+func evalRandomKey(args []string, s store.Store) *EvalResult {
+	key, found := s.RandomKey()
+	if !found {
+		return &EvalResult{
+			Response: resp.NullBulkString,
+			Error:    nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(key, false),
+		Error:    nil,
+	}
+}
+
+// evalTouch processes the TOUCH command, which updates the last access time of the keys.
+// Returns the number of the given keys that exist.
+func evalTouch(args []string, s store.Store) *EvalResult {
+	nTouched := 0
+	for _, key := range args {
+		if s.Get(key) != nil {
+			nTouched++
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode(nTouched, false),
+		Error:    nil,
+	}
+}
@@ -0,0 +1,77 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the LATENCY command.
+const (
+	latencyLatest    = "LATEST"
+	latencyHistory   = "HISTORY"
+	latencyReset     = "RESET"
+	latencyHistogram = "HISTOGRAM"
+)
+
+// evalLatency processes the LATENCY command, which reports the latency spikes recorded by the
+// latency monitor with its LATEST, HISTORY and RESET subcommands, and the latency distribution
+// of the commands with HISTOGRAM.
+func evalLatency(args []string, c *client.Client, s store.Store) *EvalResult {
+	monitor := InstanceOf(c).LatencyMonitor
+
+	var response []byte
+	switch strings.ToUpper(args[0]) {
+	case latencyLatest:
+		events := monitor.Events()
+		items := make([][]byte, 0, len(events))
+		for _, event := range events {
+			latest := event.Latest()
+			items = append(items, resp.Encode([]interface{}{event.Name, latest.Time, latest.Latency, event.Max}, false))
+		}
+		response = resp.EncodeRawArray(items)
+	case latencyHistory:
+		history := monitor.History(args[1])
+		items := make([][]byte, 0, len(history))
+		for _, sample := range history {
+			items = append(items, resp.Encode([]interface{}{sample.Time, sample.Latency}, false))
+		}
+		response = resp.EncodeRawArray(items)
+	case latencyReset:
+		response = resp.Encode(monitor.Reset(args[1:]...), false)
+	case latencyHistogram:
+		response = latencyHistogramReply(InstanceOf(c).Stats, args[1:], c.Protocol == resp.Resp3)
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(LATENCY, args[0]),
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: response,
+		Error:    nil,
+	}
+}
+
+// LATENCY HISTOGRAM [command ...] replies with the calls and the cumulative latency distribution
+// of the given commands (all of them if none are given), over power of two buckets of microseconds.
+func latencyHistogramReply(commandStats *stats.Stats, commands []string, resp3 bool) []byte {
+	pairs := make([]interface{}, 0)
+	for _, cmd := range commandStats.Commands(commands...) {
+		buckets := make([]interface{}, 0)
+		for _, bucket := range cmd.Latency.PowerOfTwoBuckets() {
+			buckets = append(buckets, bucket[0], bucket[1])
+		}
+
+		pairs = append(pairs, cmd.Name, resp.Raw(resp.EncodeMap([]interface{}{
+			"calls", cmd.Calls,
+			"histogram_usec", resp.Raw(resp.EncodeMap(buckets, resp3)),
+		}, resp3)))
+	}
+	return resp.EncodeMap(pairs, resp3)
+}
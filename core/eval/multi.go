@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"errors"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// evalMulti processes the MULTI command and starts a transaction. The commands that follow
+// are queued until EXEC runs them or DISCARD drops them.
+func evalMulti(args []string, c *client.Client, s store.Store) *EvalResult {
+	if c.Transaction != nil {
+		return &EvalResult{
+			Error:    errors.New("ERR MULTI calls can not be nested"),
+			Response: nil,
+		}
+	}
+
+	c.Transaction = &client.Transaction{}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalDiscard processes the DISCARD command, which drops the queued commands of the
+// transaction and unwatches all the keys.
+func evalDiscard(args []string, c *client.Client, s store.Store) *EvalResult {
+	if c.Transaction == nil {
+		return &EvalResult{
+			Error:    errors.New("ERR DISCARD without MULTI"),
+			Response: nil,
+		}
+	}
+
+	c.Transaction = nil
+	UnwatchAllKeys(c)
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalWatch processes the WATCH command. If any of the watched keys gets modified before
+// the client's next EXEC, the transaction is not executed and EXEC replies with null.
+func evalWatch(args []string, c *client.Client, s store.Store) *EvalResult {
+	if c.Transaction != nil {
+		return &EvalResult{
+			Error:    errors.New("ERR WATCH inside MULTI is not allowed"),
+			Response: nil,
+		}
+	}
+
+	for _, key := range args {
+		watchedKey := client.WatchedKey{Db: c.Db, Key: key}
+		if _, watched := c.WatchedKeys[watchedKey]; !watched {
+			c.WatchedKeys[watchedKey] = s.Watch(key)
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// evalUnwatch processes the UNWATCH command and forgets about all the keys watched by the client.
+func evalUnwatch(args []string, c *client.Client, s store.Store) *EvalResult {
+	UnwatchAllKeys(c)
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// UnwatchAllKeys stops watching all the keys watched by the client, in all the databases.
+func UnwatchAllKeys(c *client.Client) {
+	for watchedKey := range c.WatchedKeys {
+		DatabasesOf(c).Get(watchedKey.Db).Unwatch(watchedKey.Key)
+		delete(c.WatchedKeys, watchedKey)
+	}
+}
+
+// WatchedKeysModified returns true if any of the keys watched by the client got
+// modified, expired or evicted since it started watching them.
+func WatchedKeysModified(c *client.Client) bool {
+	for watchedKey, version := range c.WatchedKeys {
+		s := DatabasesOf(c).Get(watchedKey.Db)
+		key := watchedKey.Key
+
+		// keys that went past their expiry after being watched count as modified,
+		// even if they haven't been purged from the store yet.
+		if exp := s.GetExpiry(key); exp != nil && utils.FromExpiryInUnixMilli(*exp).IsExpired() {
+			s.DeleteExpired(key)
+		}
+
+		if s.KeyVersion(key) != version {
+			return true
+		}
+	}
+	return false
+}
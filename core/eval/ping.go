@@ -2,6 +2,7 @@ package eval
 
 import (
 	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
 	"github.com/shashwatrathod/redis-internals/core/resp"
 	"github.com/shashwatrathod/redis-internals/core/store"
 )
@@ -11,7 +12,9 @@ import (
 //
 // Parameters:
 //   - args: Arguments passed to the PING command.
-func evalPing(args []string, s store.Store) *EvalResult {
+//
+// Clients in the subscriber mode get the reply as a ["pong", message] array instead.
+func evalPing(args []string, c *client.Client, s store.Store) *EvalResult {
 	if len(args) >= 2 {
 		return &EvalResult{
 			Error:    commons.WrongNumberOfArgumentsErr(PING),
@@ -21,7 +24,13 @@ func evalPing(args []string, s store.Store) *EvalResult {
 
 	var res []byte
 
-	if len(args) == 0 {
+	if c.InSubscriberMode() {
+		message := ""
+		if len(args) == 1 {
+			message = args[0]
+		}
+		res = resp.Encode([]interface{}{"pong", message}, false)
+	} else if len(args) == 0 {
 		res = resp.Encode("PONG", true)
 	} else {
 		res = resp.Encode(args[0], false)
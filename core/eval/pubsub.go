@@ -0,0 +1,197 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the PUBSUB command.
+const (
+	CHANNELS = "CHANNELS"
+	NUMSUB   = "NUMSUB"
+	NUMPAT   = "NUMPAT"
+
+	SHARDCHANNELS = "SHARDCHANNELS"
+	SHARDNUMSUB   = "SHARDNUMSUB"
+)
+
+// evalSubscribe processes the SUBSCRIBE command and subscribes the client to the given channels.
+// Replies with a subscribe message for every channel, holding the client's subscription count.
+func evalSubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	var res []byte
+	for _, channel := range args {
+		ps.Subscribe(c, channel)
+		res = append(res, pubsub.EncodeMessage(c, pubsub.Subscribe, channel, c.SubscriptionCount())...)
+	}
+
+	return &EvalResult{
+		Response: res,
+		Error:    nil,
+	}
+}
+
+// evalUnsubscribe processes the UNSUBSCRIBE command and unsubscribes the client from the given
+// channels, or from all of its channels if none are given.
+func evalUnsubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	return &EvalResult{
+		Response: unsubscribeFrom(c, pubsub.Unsubscribe, args, c.Channels, ps.Unsubscribe, c.SubscriptionCount),
+		Error:    nil,
+	}
+}
+
+// evalPSubscribe processes the PSUBSCRIBE command and subscribes the client to the given glob-style patterns.
+func evalPSubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	var res []byte
+	for _, pattern := range args {
+		ps.PSubscribe(c, pattern)
+		res = append(res, pubsub.EncodeMessage(c, pubsub.PSubscribe, pattern, c.SubscriptionCount())...)
+	}
+
+	return &EvalResult{
+		Response: res,
+		Error:    nil,
+	}
+}
+
+// evalPUnsubscribe processes the PUNSUBSCRIBE command and unsubscribes the client from the given
+// patterns, or from all of its patterns if none are given.
+func evalPUnsubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	return &EvalResult{
+		Response: unsubscribeFrom(c, pubsub.PUnsubscribe, args, c.Patterns, ps.PUnsubscribe, c.SubscriptionCount),
+		Error:    nil,
+	}
+}
+
+// evalSSubscribe processes the SSUBSCRIBE command and subscribes the client to the given sharded channels.
+// There's no cluster mode, so the channels don't have to hash to the same slot.
+func evalSSubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	var res []byte
+	for _, channel := range args {
+		ps.SSubscribe(c, channel)
+		res = append(res, pubsub.EncodeMessage(c, pubsub.SSubscribe, channel, c.ShardSubscriptionCount())...)
+	}
+
+	return &EvalResult{
+		Response: res,
+		Error:    nil,
+	}
+}
+
+// evalSUnsubscribe processes the SUNSUBSCRIBE command and unsubscribes the client from the given
+// sharded channels, or from all of its sharded channels if none are given.
+func evalSUnsubscribe(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+
+	return &EvalResult{
+		Response: unsubscribeFrom(c, pubsub.SUnsubscribe, args, c.ShardChannels, ps.SUnsubscribe, c.ShardSubscriptionCount),
+		Error:    nil,
+	}
+}
+
+// unsubscribes the client from the given names using the unsubscribe fn, or from all
+// the subscribed names if none are given, and returns the encoded confirmations
+// carrying the client's remaining subscription count.
+func unsubscribeFrom(c *client.Client, kind string, names []string, subscribed map[string]struct{},
+	unsubscribe func(*client.Client, string) bool, count func() int) []byte {
+	if len(names) == 0 {
+		for name := range subscribed {
+			names = append(names, name)
+		}
+	}
+
+	// there is nothing to unsubscribe from, but the client still expects a confirmation.
+	if len(names) == 0 {
+		return pubsub.EncodeMessage(c, kind, nil, count())
+	}
+
+	var res []byte
+	for _, name := range names {
+		unsubscribe(c, name)
+		res = append(res, pubsub.EncodeMessage(c, kind, name, count())...)
+	}
+	return res
+}
+
+// evalPublish processes the PUBLISH command and delivers the message to the subscribers of the channel.
+// Returns the number of clients that received the message.
+func evalPublish(args []string, c *client.Client, s store.Store) *EvalResult {
+	nReceivers := InstanceOf(c).PubSub.Publish(args[0], args[1])
+
+	return &EvalResult{
+		Response: resp.Encode(nReceivers, false),
+		Error:    nil,
+	}
+}
+
+// evalSPublish processes the SPUBLISH command and delivers the message to the subscribers of the sharded channel.
+// Returns the number of clients that received the message.
+func evalSPublish(args []string, c *client.Client, s store.Store) *EvalResult {
+	nReceivers := InstanceOf(c).PubSub.SPublish(args[0], args[1])
+
+	return &EvalResult{
+		Response: resp.Encode(nReceivers, false),
+		Error:    nil,
+	}
+}
+
+// evalPubSub processes the PUBSUB introspection command with its
+// CHANNELS [pattern], NUMSUB [channel ...], NUMPAT, SHARDCHANNELS [pattern]
+// and SHARDNUMSUB [channel ...] subcommands.
+func evalPubSub(args []string, c *client.Client, s store.Store) *EvalResult {
+	ps := InstanceOf(c).PubSub
+	subcommand := strings.ToUpper(args[0])
+
+	switch {
+	case (subcommand == CHANNELS || subcommand == SHARDCHANNELS) && len(args) <= 2:
+		pattern := ""
+		if len(args) == 2 {
+			pattern = args[1]
+		}
+		channels := ps.Channels
+		if subcommand == SHARDCHANNELS {
+			channels = ps.ShardChannels
+		}
+		return &EvalResult{
+			Response: resp.Encode(channels(pattern), false),
+			Error:    nil,
+		}
+	case subcommand == NUMSUB || subcommand == SHARDNUMSUB:
+		numSub := ps.NumSub
+		if subcommand == SHARDNUMSUB {
+			numSub = ps.ShardNumSub
+		}
+		counts := make([]interface{}, 0, 2*(len(args)-1))
+		for _, channel := range args[1:] {
+			counts = append(counts, channel, numSub(channel))
+		}
+		return &EvalResult{
+			Response: resp.Encode(counts, false),
+			Error:    nil,
+		}
+	case subcommand == NUMPAT:
+		return &EvalResult{
+			Response: resp.Encode(ps.NumPat(), false),
+			Error:    nil,
+		}
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(PUBSUB, args[0]),
+			Response: nil,
+		}
+	}
+}
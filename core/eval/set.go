@@ -14,13 +14,6 @@ import (
 // evalSet processes the SET command with optional arguments to control expiry and insertion.
 // Returns an EvalResult with the operation status.
 func evalSet(args []string, s store.Store) *EvalResult {
-	if len(args) < 2 {
-		return &EvalResult{
-			Error:    commons.WrongNumberOfArgumentsErr(SET),
-			Response: nil,
-		}
-	}
-
 	// Key and Value are always the 1st and 2nd arguments.
 	key, value := args[0], args[1]
 
@@ -46,6 +39,12 @@ func evalSet(args []string, s store.Store) *EvalResult {
 					Response: nil,
 				}
 			}
+			if expiryInSeconds <= 0 {
+				return &EvalResult{
+					Error:    errors.New("ERR invalid expire time in 'set' command"),
+					Response: nil,
+				}
+			}
 			expiryTime = utils.FromExpiryInSeconds(expiryInSeconds)
 		case PX:
 			i++
@@ -62,6 +61,12 @@ func evalSet(args []string, s store.Store) *EvalResult {
 					Response: nil,
 				}
 			}
+			if expiryInMs <= 0 {
+				return &EvalResult{
+					Error:    errors.New("ERR invalid expire time in 'set' command"),
+					Response: nil,
+				}
+			}
 			expiryTime = utils.FromExpiryInMilliseconds(expiryInMs)
 		default:
 			return &EvalResult{
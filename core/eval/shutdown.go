@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/shutdown"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// options of the SHUTDOWN command.
+const (
+	shutdownNoSave = "NOSAVE"
+	shutdownSave   = "SAVE"
+	shutdownNow    = "NOW"
+	shutdownForce  = "FORCE"
+	shutdownAbort  = "ABORT"
+)
+
+// evalShutdown processes SHUTDOWN [NOSAVE | SAVE] [NOW] [FORCE] [ABORT], which asks the server to
+// exit once the replies of the clients are written, or cancels the shutdown in progress with ABORT.
+// The server carries out the shutdown, so the client gets no reply unless it fails or is aborted.
+func evalShutdown(args []string, c *client.Client, s store.Store) *EvalResult {
+	var options shutdown.Options
+	abort := false
+
+	for _, arg := range args {
+		switch strings.ToUpper(arg) {
+		case shutdownNoSave:
+			options.NoSave = true
+		case shutdownSave:
+			options.Save = true
+		case shutdownNow:
+			options.Now = true
+		case shutdownForce:
+			options.Force = true
+		case shutdownAbort:
+			abort = true
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	if (options.Save && options.NoSave) || (abort && len(args) > 1) {
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	if abort {
+		if err := shutdown.Abort(); err != nil {
+			return &EvalResult{
+				Error:    err,
+				Response: nil,
+			}
+		}
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	}
+
+	if options.Save && !options.Force {
+		// the dataset is only ever kept in memory, so there's nowhere to save it to.
+		log.Println("Error trying to save the DB before shutting down: the server has no persistence. Use NOSAVE or FORCE to shut down anyway.")
+		return &EvalResult{
+			Error:    errors.New("ERR Errors trying to SHUTDOWN. Check logs."),
+			Response: nil,
+		}
+	}
+
+	shutdown.Start(options, c)
+	return &EvalResult{
+		Response: nil,
+		Error:    nil,
+	}
+}
@@ -0,0 +1,81 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// subcommands of the SLOWLOG command.
+const (
+	slowlogGet   = "GET"
+	slowlogLen   = "LEN"
+	slowlogReset = "RESET"
+)
+
+// number of entries returned by SLOWLOG GET when no count is given.
+const defaultSlowlogCount = 10
+
+// evalSlowlog processes the SLOWLOG command, which reads the commands recorded in the slow log
+// with its GET [count] and LEN subcommands, and clears it with RESET.
+func evalSlowlog(args []string, c *client.Client, s store.Store) *EvalResult {
+	slowLog := InstanceOf(c).SlowLog
+	subcommand := strings.ToUpper(args[0])
+
+	var response []byte
+	switch subcommand {
+	case slowlogGet:
+		if len(args) > 2 {
+			return &EvalResult{
+				Error:    commons.WrongNumberOfArgumentsErr(SLOWLOG + "|" + slowlogGet),
+				Response: nil,
+			}
+		}
+
+		count := defaultSlowlogCount
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < -1 {
+				return &EvalResult{
+					Error:    errors.New("ERR count should be greater than or equal to -1"),
+					Response: nil,
+				}
+			}
+			count = n
+		}
+
+		entries := slowLog.Get(count)
+		items := make([][]byte, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, resp.Encode([]interface{}{
+				entry.Id,
+				entry.Time,
+				entry.Duration,
+				entry.Args,
+				entry.ClientAddr,
+				entry.ClientName,
+			}, false))
+		}
+		response = resp.EncodeRawArray(items)
+	case slowlogLen:
+		response = resp.Encode(slowLog.Len(), false)
+	case slowlogReset:
+		slowLog.Reset()
+		response = resp.Encode("OK", true)
+	default:
+		return &EvalResult{
+			Error:    commons.UnknownSubcommandErr(SLOWLOG, args[0]),
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: response,
+		Error:    nil,
+	}
+}
@@ -0,0 +1,203 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/commons"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+)
+
+// handles CLIENT TRACKING ON|OFF [REDIRECT id] [PREFIX prefix ...] [BCAST] [OPTIN] [OPTOUT] [NOLOOP],
+// which turns the server assisted client side caching on or off for the client.
+func evalClientTracking(args []string, c *client.Client) *EvalResult {
+	var on bool
+	switch strings.ToUpper(args[0]) {
+	case "ON":
+		on = true
+	case "OFF":
+		on = false
+	default:
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	options := client.Tracking{}
+	for i := 1; i < len(args); i++ {
+		hasValue := i+1 < len(args)
+
+		switch strings.ToUpper(args[i]) {
+		case "REDIRECT":
+			if !hasValue {
+				return &EvalResult{
+					Error:    commons.SyntaxErr(),
+					Response: nil,
+				}
+			}
+			i++
+
+			id, err := strconv.ParseInt(args[i], 10, 64)
+			if err != nil {
+				return &EvalResult{
+					Error:    errors.New("ERR value is not an integer or out of range"),
+					Response: nil,
+				}
+			}
+			if client.ById(id) == nil {
+				return &EvalResult{
+					Error:    errors.New("ERR The client ID you want redirect to does not exist"),
+					Response: nil,
+				}
+			}
+			options.Redirect = id
+		case "PREFIX":
+			if !hasValue {
+				return &EvalResult{
+					Error:    commons.SyntaxErr(),
+					Response: nil,
+				}
+			}
+			i++
+			options.Prefixes = append(options.Prefixes, args[i])
+		case "BCAST":
+			options.Bcast = true
+		case "OPTIN":
+			options.OptIn = true
+		case "OPTOUT":
+			options.OptOut = true
+		case "NOLOOP":
+			options.NoLoop = true
+		default:
+			return &EvalResult{
+				Error:    commons.SyntaxErr(),
+				Response: nil,
+			}
+		}
+	}
+
+	tracker := InstanceOf(c).Tracker
+	if !on {
+		tracker.Disable(c)
+		return &EvalResult{
+			Response: resp.Encode("OK", true),
+			Error:    nil,
+		}
+	}
+
+	var err error = nil
+	switch {
+	case len(options.Prefixes) > 0 && !options.Bcast:
+		err = errors.New("ERR PREFIX option requires BCAST mode to be enabled")
+	case options.OptIn && options.OptOut:
+		err = errors.New("ERR You can't use both OPTIN and OPTOUT")
+	case options.Bcast && (options.OptIn || options.OptOut):
+		err = errors.New("ERR OPTIN and OPTOUT are not compatible with BCAST")
+	case c.Tracking != nil && c.Tracking.Bcast != options.Bcast:
+		err = errors.New("ERR You can't switch BCAST mode on/off before disabling tracking for this client, and then re-enabling it with a different mode.")
+	case c.Tracking != nil && (c.Tracking.OptIn != options.OptIn || c.Tracking.OptOut != options.OptOut):
+		err = errors.New("ERR You can't switch OPTIN/OPTOUT mode before disabling tracking for this client, and then re-enabling it with a different mode.")
+	default:
+		err = tracker.Enable(c, options)
+	}
+
+	if err != nil {
+		return &EvalResult{
+			Error:    err,
+			Response: nil,
+		}
+	}
+
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// handles CLIENT CACHING YES|NO, which picks whether the keys read by the next command are
+// tracked, for the clients in the OPTIN or OPTOUT mode.
+func evalClientCaching(arg string, c *client.Client) *EvalResult {
+	if c.Tracking == nil || (!c.Tracking.OptIn && !c.Tracking.OptOut) {
+		return &EvalResult{
+			Error:    errors.New("ERR CLIENT CACHING can be called only when the client is in tracking mode with OPTIN or OPTOUT mode enabled"),
+			Response: nil,
+		}
+	}
+
+	switch strings.ToUpper(arg) {
+	case "YES":
+		if !c.Tracking.OptIn {
+			return &EvalResult{
+				Error:    errors.New("ERR CLIENT CACHING YES is only valid when tracking is enabled in OPTIN mode."),
+				Response: nil,
+			}
+		}
+	case "NO":
+		if !c.Tracking.OptOut {
+			return &EvalResult{
+				Error:    errors.New("ERR CLIENT CACHING NO is only valid when tracking is enabled in OPTOUT mode."),
+				Response: nil,
+			}
+		}
+	default:
+		return &EvalResult{
+			Error:    commons.SyntaxErr(),
+			Response: nil,
+		}
+	}
+
+	c.Tracking.Caching = true
+	return &EvalResult{
+		Response: resp.Encode("OK", true),
+		Error:    nil,
+	}
+}
+
+// handles CLIENT TRACKINGINFO, which describes the tracking options of the client.
+func evalClientTrackingInfo(c *client.Client) *EvalResult {
+	flags := []string{}
+	var redirect int64 = -1
+	prefixes := []string{}
+
+	if t := c.Tracking; t == nil {
+		flags = append(flags, "off")
+	} else {
+		flags = append(flags, "on")
+		if t.Bcast {
+			flags = append(flags, "bcast")
+		}
+		if t.OptIn {
+			flags = append(flags, "optin")
+			if t.Caching {
+				flags = append(flags, "caching-yes")
+			}
+		}
+		if t.OptOut {
+			flags = append(flags, "optout")
+			if t.Caching {
+				flags = append(flags, "caching-no")
+			}
+		}
+		if t.NoLoop {
+			flags = append(flags, "noloop")
+		}
+		if t.BrokenRedirect {
+			flags = append(flags, "broken_redirect")
+		}
+
+		redirect = t.Redirect
+		prefixes = append(prefixes, t.Prefixes...)
+	}
+
+	return &EvalResult{
+		Response: resp.EncodeMap([]interface{}{
+			"flags", flags,
+			"redirect", redirect,
+			"prefixes", prefixes,
+		}, c.Protocol == resp.Resp3),
+		Error: nil,
+	}
+}
@@ -1,7 +1,6 @@
 package eval
 
 import (
-	"github.com/shashwatrathod/redis-internals/commons"
 	"github.com/shashwatrathod/redis-internals/core/resp"
 	"github.com/shashwatrathod/redis-internals/core/store"
 	"github.com/shashwatrathod/redis-internals/utils"
@@ -10,13 +9,6 @@ import (
 // evaluates the TTL (Time to Live) command for a given key in the Redis store.
 // It returns the remaining time to live of a key that has a timeout.
 func evalTtl(args []string, s store.Store) *EvalResult {
-	if len(args) != 1 {
-		return &EvalResult{
-			Error:    commons.WrongNumberOfArgumentsErr(TTL),
-			Response: nil,
-		}
-	}
-
 	key := args[0]
 
 	val := s.Get(key)
@@ -39,7 +31,7 @@ func evalTtl(args []string, s store.Store) *EvalResult {
 		}
 	}
 
-	expiry := utils.FromExpiryInUnixTime(*expTs)
+	expiry := utils.FromExpiryInUnixMilli(*expTs)
 
 	// The expiry has already passed.
 	if expiry != nil && expiry.IsExpired() {
@@ -0,0 +1,232 @@
+package io
+
+import (
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// BufferedConn is a connection that holds some of the data on its own end besides the buffers of
+// the socket, eg. a TLS connection, which decrypts the records it reads ahead and encrypts the
+// replies into records that may not fit into the socket at once. The readiness of the socket
+// doesn't tell about that data, so the event loop has to ask.
+type BufferedConn interface {
+	io.ReadWriter
+
+	// returns the number of bytes that were read from the socket, but not from the connection yet.
+	PendingReads() int
+
+	// writes the bytes held back by the connection to the socket without blocking.
+	// returns true once there are none left.
+	FlushBuffered() (bool, error)
+}
+
+// the size of the chunks the connections are read in.
+const netReadChunkSize = 16 * 1024
+
+// how long a connection that is closed keeps writing what it was handed before it's dropped.
+const netCloseTimeout = 5 * time.Second
+
+// NetComm is a net.Conn served by goroutines of its own, one reading from it ahead of the event
+// loop and one writing to it what the loop hands over, so that the loop never blocks on the
+// client. ready is called once there's something to read, eg. a command or the end of the
+// connection, and once the writer can take more.
+type NetComm struct {
+	conn  net.Conn
+	ready func()
+
+	mu sync.Mutex
+
+	// data read from the connection that the loop didn't read yet, and the error that ended the reads.
+	inbuf   []byte
+	readErr error
+
+	// data handed over to the writer that it didn't take yet, and the error that ended the writes.
+	outbuf   []byte
+	writeErr error
+
+	// set while the loop waits for the writer to take the data it holds.
+	waiting bool
+
+	// set once the connection is closed, which it is once the writer wrote what it holds.
+	closed bool
+
+	// let the reader read the next chunk, and wake the writer up.
+	readMore  chan struct{}
+	writeMore chan struct{}
+}
+
+// returns a connection to be served with Serve.
+func NewNetComm(conn net.Conn, ready func()) *NetComm {
+	n := &NetComm{
+		conn:      conn,
+		ready:     ready,
+		readMore:  make(chan struct{}, 1),
+		writeMore: make(chan struct{}, 1),
+	}
+	n.readMore <- struct{}{}
+	return n
+}
+
+// Serve reads from and writes to the connection until it's closed, and returns once it is.
+func (n *NetComm) Serve() {
+	written := make(chan struct{})
+	go func() {
+		n.writeLoop()
+		close(written)
+	}()
+	n.readLoop()
+	<-written
+}
+
+// reads a chunk at a time, once the loop read the previous one.
+func (n *NetComm) readLoop() {
+	chunk := make([]byte, netReadChunkSize)
+	for range n.readMore {
+		count, err := n.conn.Read(chunk)
+		for count == 0 && err == nil {
+			count, err = n.conn.Read(chunk)
+		}
+
+		n.mu.Lock()
+		n.inbuf = append(n.inbuf, chunk[:count]...)
+		if count == 0 && err != nil {
+			n.readErr = err
+		}
+		closed := n.closed
+		n.mu.Unlock()
+
+		if closed {
+			return
+		}
+		n.ready()
+		if count == 0 && err != nil {
+			return
+		}
+	}
+}
+
+// writes what the loop hands over until the connection is closed, and closes it. The connection
+// is only ever closed here, so that its fd isn't reused before the loop lets go of it.
+func (n *NetComm) writeLoop() {
+	defer n.conn.Close()
+
+	for range n.writeMore {
+		for {
+			n.mu.Lock()
+			batch, waiting, closed := n.outbuf, n.waiting, n.closed
+			n.outbuf, n.waiting = nil, false
+			n.mu.Unlock()
+
+			if len(batch) == 0 {
+				if closed {
+					return
+				}
+				break
+			}
+			if waiting && !closed {
+				// the loop can hand over the next batch while this one is written.
+				n.ready()
+			}
+
+			if _, err := n.conn.Write(batch); err != nil {
+				n.mu.Lock()
+				n.writeErr = err
+				n.mu.Unlock()
+				if !closed {
+					// the loop finds out about the broken connection from its next read.
+					n.ready()
+				}
+			}
+		}
+	}
+}
+
+// Read reads the data the reader read ahead. Returns syscall.EAGAIN if there's none yet, or the
+// error that broke the connection once there's none left.
+func (n *NetComm) Read(b []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.inbuf) == 0 {
+		if n.readErr != nil {
+			return 0, n.readErr
+		}
+		if n.writeErr != nil {
+			return 0, n.writeErr
+		}
+		return 0, syscall.EAGAIN
+	}
+
+	count := copy(b, n.inbuf)
+	n.inbuf = n.inbuf[count:]
+	if len(n.inbuf) == 0 {
+		n.inbuf = nil
+		if n.readErr == nil && !n.closed {
+			select {
+			case n.readMore <- struct{}{}:
+			default:
+			}
+		}
+	}
+	return count, nil
+}
+
+// Write hands the data over to the writer. No more data is taken until the writer takes it, in
+// which case syscall.EAGAIN is returned and ready is called once it does.
+func (n *NetComm) Write(b []byte) (int, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return 0, net.ErrClosed
+	}
+	if n.writeErr != nil {
+		return 0, n.writeErr
+	}
+	if len(n.outbuf) > 0 {
+		n.waiting = true
+		return 0, syscall.EAGAIN
+	}
+
+	n.outbuf = append(n.outbuf, b...)
+	select {
+	case n.writeMore <- struct{}{}:
+	default:
+	}
+	return len(b), nil
+}
+
+func (n *NetComm) PendingReads() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.inbuf)
+}
+
+// FlushBuffered has nothing to do, the writer writes what it's handed on its own.
+func (n *NetComm) FlushBuffered() (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return true, n.writeErr
+}
+
+// Close closes the connection once the writer wrote what it was handed, or once it has been
+// trying to for too long, eg. because the client stopped reading.
+func (n *NetComm) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return nil
+	}
+	n.closed = true
+	close(n.readMore)
+	n.conn.SetWriteDeadline(time.Now().Add(netCloseTimeout))
+	select {
+	case n.writeMore <- struct{}{}:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package io_test
+
+import (
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	redisio "github.com/shashwatrathod/redis-internals/core/io"
+)
+
+func TestIO(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IO Suite")
+}
+
+var _ = Describe("NetComm", func() {
+	var comm *redisio.NetComm
+	var peer net.Conn
+	var ready chan struct{}
+
+	BeforeEach(func() {
+		var conn net.Conn
+		conn, peer = net.Pipe()
+
+		notify := make(chan struct{}, 16)
+		ready = notify
+		comm = redisio.NewNetComm(conn, func() { notify <- struct{}{} })
+
+		served := make(chan struct{})
+		go func() {
+			comm.Serve()
+			close(served)
+		}()
+		DeferCleanup(func() {
+			comm.Close()
+			// the writer may be blocked on the data nobody read from the pipe.
+			peer.Close()
+			Eventually(served).Should(BeClosed())
+		})
+	})
+
+	It("reads what the client sent once it's ready", func() {
+		_, err := comm.Read(make([]byte, 16))
+		Expect(err).To(Equal(syscall.EAGAIN))
+
+		_, err = peer.Write([]byte("PING"))
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(ready).Should(Receive())
+
+		b := make([]byte, 16)
+		n, err := comm.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("PING"))
+
+		_, err = comm.Read(b)
+		Expect(err).To(Equal(syscall.EAGAIN))
+	})
+
+	It("keeps the data that didn't fit into the buffer as pending reads", func() {
+		_, err := peer.Write([]byte("firstsecond"))
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(ready).Should(Receive())
+		Expect(comm.PendingReads()).To(Equal(len("firstsecond")))
+
+		b := make([]byte, 5)
+		n, err := comm.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("first"))
+		Expect(comm.PendingReads()).To(Equal(len("second")))
+	})
+
+	It("takes no more data until the writer takes what it holds", func() {
+		// the writer takes the first batch and blocks writing it, as nothing reads from the pipe.
+		Expect(comm.Write([]byte("first"))).To(Equal(5))
+		Eventually(func() error {
+			_, err := comm.Write([]byte("second"))
+			return err
+		}).Should(Succeed())
+
+		_, err := comm.Write([]byte("third"))
+		Expect(err).To(Equal(syscall.EAGAIN))
+
+		b := make([]byte, 16)
+		n, err := peer.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("first"))
+
+		// the loop is told once the writer takes the second batch, and can hand over the third.
+		Eventually(ready).Should(Receive())
+		Eventually(func() error {
+			_, err := comm.Write([]byte("third"))
+			return err
+		}).Should(Succeed())
+	})
+
+	It("writes what it holds before closing the connection", func() {
+		Expect(comm.Write([]byte("bye"))).To(Equal(3))
+		Expect(comm.Close()).To(Succeed())
+
+		peer.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, err := io.ReadAll(peer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("bye"))
+
+		_, err = comm.Write([]byte("more"))
+		Expect(err).To(MatchError(net.ErrClosed))
+	})
+
+	It("tells about the end of the connection once there's nothing left to read", func() {
+		peer.Close()
+		Eventually(ready).Should(Receive())
+
+		_, err := comm.Read(make([]byte, 16))
+		Expect(err).To(MatchError(io.EOF))
+	})
+})
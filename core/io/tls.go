@@ -0,0 +1,248 @@
+//go:build unix
+
+package io
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// the size of the chunks the decrypted records are read in.
+const tlsReadChunkSize = 16 * 1024
+
+// returned by the reads and writes of the non-blocking socket that would block. it's a temporary
+// net.Error, so that crypto/tls keeps the partial records it read and can be called again.
+var errWouldBlock net.Error = wouldBlockError{}
+
+type wouldBlockError struct{}
+
+func (wouldBlockError) Error() string   { return "the operation would block" }
+func (wouldBlockError) Timeout() bool   { return true }
+func (wouldBlockError) Temporary() bool { return true }
+
+// TLSComm is a TLS connection over the non-blocking socket of a client. The records are read as
+// the socket becomes readable, and the ones that don't fit into the socket are held back until
+// it becomes writable, so that the event loop never blocks on the client.
+type TLSComm struct {
+	conn *tls.Conn
+	raw  *socketConn
+
+	// decrypted bytes read ahead from the connection.
+	inbuf []byte
+}
+
+// returns a TLS connection over the socket, which has to complete the handshake before it's used.
+func NewTLSComm(fd int, config *tls.Config) *TLSComm {
+	raw := &socketConn{fd: fd}
+	return &TLSComm{
+		conn: tls.Server(raw, config),
+		raw:  raw,
+	}
+}
+
+// performs the TLS handshake with the client, waiting for up to the timeout for it to complete.
+// The socket is blocking for the duration of the handshake, so it must not be watched by the event
+// loop in the meantime, eg. by running the handshake in its own goroutine.
+// The records the client sent right after its part of the handshake may have been read along with
+// it, in which case they're left in the connection, where PendingReads tells about them.
+func (t *TLSComm) Handshake(timeout time.Duration) error {
+	if err := t.raw.setBlocking(timeout); err != nil {
+		return err
+	}
+
+	err := t.conn.Handshake()
+
+	if e := t.raw.setNonBlocking(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	// the socket won't become readable for the records that were read already. the errors, eg. the
+	// client going away, are left for the next read.
+	t.fill()
+	return nil
+}
+
+// returns the state of the TLS connection, eg. the certificates of the client.
+func (t *TLSComm) ConnectionState() tls.ConnectionState {
+	return t.conn.ConnectionState()
+}
+
+// Read reads the decrypted data. Everything the socket holds is read at once, so that the data
+// that doesn't fit into b is left in the connection, where PendingReads tells about it.
+// Returns syscall.EAGAIN if there's no full record to decrypt yet.
+func (t *TLSComm) Read(b []byte) (int, error) {
+	if len(t.inbuf) == 0 {
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, t.inbuf)
+	t.inbuf = t.inbuf[n:]
+	if len(t.inbuf) == 0 {
+		t.inbuf = nil
+	}
+	return n, nil
+}
+
+// reads and decrypts all the records the socket holds.
+func (t *TLSComm) fill() error {
+	chunk := make([]byte, tlsReadChunkSize)
+	for {
+		n, err := t.conn.Read(chunk)
+		t.inbuf = append(t.inbuf, chunk[:n]...)
+
+		if err == nil {
+			continue
+		}
+		if len(t.inbuf) > 0 {
+			// the data that was read is returned first, and the error, if it persists, with the next read.
+			return nil
+		}
+		if errors.Is(err, errWouldBlock) {
+			return syscall.EAGAIN
+		}
+		return err
+	}
+}
+
+// Write encrypts the data and writes it to the socket. The records that don't fit into the socket
+// are held back, and no more data is taken while they are, in which case syscall.EAGAIN is returned.
+func (t *TLSComm) Write(b []byte) (int, error) {
+	drained, err := t.raw.flush()
+	if err != nil {
+		return 0, err
+	}
+	if !drained {
+		return 0, syscall.EAGAIN
+	}
+
+	return t.conn.Write(b)
+}
+
+func (t *TLSComm) PendingReads() int {
+	return len(t.inbuf)
+}
+
+func (t *TLSComm) FlushBuffered() (bool, error) {
+	return t.raw.flush()
+}
+
+// socketConn is the net.Conn the TLS connection runs over. Its reads return errWouldBlock when the
+// socket has nothing to read, and its writes never block, holding back what the socket can't take.
+type socketConn struct {
+	fd int
+
+	// encrypted bytes the socket couldn't take yet.
+	outbuf []byte
+
+	// set while the socket is blocking, eg. during the handshake.
+	blocking bool
+}
+
+func (s *socketConn) Read(b []byte) (int, error) {
+	for {
+		n, err := syscall.Read(s.fd, b)
+		switch {
+		case err == syscall.EINTR:
+			continue
+		case err == syscall.EAGAIN && s.blocking:
+			// the receive timeout of the socket ran out.
+			return 0, errors.New("timed out waiting for the client")
+		case err == syscall.EAGAIN:
+			return 0, errWouldBlock
+		case err != nil:
+			return 0, err
+		case n == 0:
+			return 0, io.EOF
+		}
+		return n, nil
+	}
+}
+
+func (s *socketConn) Write(b []byte) (int, error) {
+	s.outbuf = append(s.outbuf, b...)
+
+	if s.blocking {
+		for len(s.outbuf) > 0 {
+			drained, err := s.flush()
+			if err != nil {
+				return 0, err
+			}
+			if !drained {
+				// the send timeout of the socket ran out.
+				return 0, errors.New("timed out waiting for the client")
+			}
+		}
+		return len(b), nil
+	}
+
+	// crypto/tls gives up on the connection after any failed write, so the bytes are taken even
+	// if the socket is full, and written once it becomes writable.
+	if _, err := s.flush(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writes the held back bytes to the socket. returns true once there are none left.
+func (s *socketConn) flush() (bool, error) {
+	for len(s.outbuf) > 0 {
+		n, err := syscall.Write(s.fd, s.outbuf)
+		if n > 0 {
+			s.outbuf = s.outbuf[n:]
+		}
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.EAGAIN {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	s.outbuf = nil
+	return true, nil
+}
+
+// makes the reads and writes of the socket wait for up to the timeout.
+func (s *socketConn) setBlocking(timeout time.Duration) error {
+	tv := syscall.NsecToTimeval(timeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(s.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return err
+	}
+	if err := syscall.SetsockoptTimeval(s.fd, syscall.SOL_SOCKET, syscall.SO_SNDTIMEO, &tv); err != nil {
+		return err
+	}
+	s.blocking = true
+	return syscall.SetNonblock(s.fd, false)
+}
+
+func (s *socketConn) setNonBlocking() error {
+	s.blocking = false
+	var tv syscall.Timeval
+	if err := syscall.SetsockoptTimeval(s.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		return err
+	}
+	if err := syscall.SetsockoptTimeval(s.fd, syscall.SOL_SOCKET, syscall.SO_SNDTIMEO, &tv); err != nil {
+		return err
+	}
+	return syscall.SetNonblock(s.fd, true)
+}
+
+// the addresses and the deadlines aren't used by crypto/tls on the server side.
+
+func (s *socketConn) Close() error                       { return nil }
+func (s *socketConn) LocalAddr() net.Addr                { return &net.TCPAddr{} }
+func (s *socketConn) RemoteAddr() net.Addr               { return &net.TCPAddr{} }
+func (s *socketConn) SetDeadline(t time.Time) error      { return nil }
+func (s *socketConn) SetReadDeadline(t time.Time) error  { return nil }
+func (s *socketConn) SetWriteDeadline(t time.Time) error { return nil }
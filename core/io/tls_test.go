@@ -0,0 +1,246 @@
+//go:build unix
+
+package io_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	redisio "github.com/shashwatrathod/redis-internals/core/io"
+)
+
+// returns a self-signed certificate, along with a pool trusting it.
+func selfSignedCertificate() (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	parsed, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// reads from the connection until there's something to read, or the timeout runs out.
+func readEventually(comm *redisio.TLSComm, b []byte) (int, error) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n, err := comm.Read(b)
+		if err != syscall.EAGAIN || time.Now().After(deadline) {
+			return n, err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// a connection that holds back what's written to it until it's read from or flushed, so that the
+// writes in between get to the peer at once.
+type coalescingConn struct {
+	net.Conn
+	held []byte
+}
+
+func (c *coalescingConn) Write(b []byte) (int, error) {
+	c.held = append(c.held, b...)
+	return len(b), nil
+}
+
+func (c *coalescingConn) Read(b []byte) (int, error) {
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *coalescingConn) flush() error {
+	_, err := c.Conn.Write(c.held)
+	c.held = nil
+	return err
+}
+
+var _ = Describe("TLSComm", func() {
+	var comm *redisio.TLSComm
+	var peer *tls.Conn
+	var certificate tls.Certificate
+	var pool *x509.CertPool
+
+	BeforeEach(func() {
+		certificate, pool = selfSignedCertificate()
+
+		fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(syscall.Close, fds[0])
+
+		file := os.NewFile(uintptr(fds[1]), "peer")
+		raw, err := net.FileConn(file)
+		Expect(err).NotTo(HaveOccurred())
+		file.Close()
+		DeferCleanup(raw.Close)
+
+		comm = redisio.NewTLSComm(fds[0], &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		})
+		peer = tls.Client(raw, &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      pool,
+			ServerName:   "localhost",
+		})
+	})
+
+	handshake := func() {
+		done := make(chan error, 1)
+		go func() { done <- peer.Handshake() }()
+		Expect(comm.Handshake(5 * time.Second)).To(Succeed())
+		Expect(<-done).To(Succeed())
+	}
+
+	It("reads and writes once the handshake completes", func() {
+		handshake()
+		Expect(comm.ConnectionState().PeerCertificates).To(HaveLen(1))
+
+		_, err := peer.Write([]byte("PING"))
+		Expect(err).NotTo(HaveOccurred())
+
+		b := make([]byte, 16)
+		n, err := readEventually(comm, b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("PING"))
+
+		_, err = comm.Write([]byte("PONG"))
+		Expect(err).NotTo(HaveOccurred())
+		n, err = peer.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("PONG"))
+	})
+
+	It("doesn't block when there's nothing to read", func() {
+		handshake()
+
+		_, err := comm.Read(make([]byte, 16))
+		Expect(err).To(Equal(syscall.EAGAIN))
+	})
+
+	It("keeps the data that didn't fit into the buffer as pending reads", func() {
+		handshake()
+
+		_, err := peer.Write([]byte("first"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = peer.Write([]byte("second"))
+		Expect(err).NotTo(HaveOccurred())
+
+		b := make([]byte, 5)
+		Eventually(func() int {
+			comm.Read(b[:0])
+			return comm.PendingReads()
+		}).Should(Equal(len("firstsecond")))
+
+		n, err := comm.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("first"))
+		Expect(comm.PendingReads()).To(Equal(len("second")))
+	})
+
+	It("holds back the records the socket can't take", func() {
+		handshake()
+
+		// more than the buffers of the socket pair can take.
+		payload := make([]byte, 4*1024*1024)
+		_, err := comm.Write(payload)
+		Expect(err).NotTo(HaveOccurred())
+
+		drained, err := comm.FlushBuffered()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(drained).To(BeFalse())
+
+		_, err = comm.Write([]byte("more"))
+		Expect(err).To(Equal(syscall.EAGAIN))
+
+		received := make(chan int, 1)
+		go func() {
+			total := 0
+			b := make([]byte, 64*1024)
+			for total < len(payload) {
+				n, err := peer.Read(b)
+				if err != nil {
+					break
+				}
+				total += n
+			}
+			received <- total
+		}()
+
+		Eventually(func() bool {
+			drained, _ := comm.FlushBuffered()
+			return drained
+		}, 5*time.Second).Should(BeTrue())
+		Eventually(received, 5*time.Second).Should(Receive(Equal(len(payload))))
+	})
+
+	It("keeps the data read along with the handshake as pending reads", func() {
+		raw := &coalescingConn{Conn: peer.NetConn()}
+		peer = tls.Client(raw, &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      pool,
+			ServerName:   "localhost",
+		})
+
+		// the end of the client's handshake and its first record reach the socket together.
+		done := make(chan error, 1)
+		go func() {
+			if err := peer.Handshake(); err != nil {
+				done <- err
+				return
+			}
+			peer.Write([]byte("PING"))
+			done <- raw.flush()
+		}()
+		Expect(comm.Handshake(5 * time.Second)).To(Succeed())
+		Expect(<-done).To(Succeed())
+
+		Expect(comm.PendingReads()).To(Equal(len("PING")))
+		b := make([]byte, 16)
+		n, err := comm.Read(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("PING"))
+	})
+
+	It("fails the handshake with a client without a certificate", func() {
+		peer = tls.Client(peer.NetConn(), &tls.Config{RootCAs: pool, ServerName: "localhost"})
+
+		go peer.Handshake()
+		Expect(comm.Handshake(5 * time.Second)).NotTo(Succeed())
+	})
+
+	It("times out the handshake with a client that doesn't start it", func() {
+		Expect(comm.Handshake(100 * time.Millisecond)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// the upper bounds of the buckets of the command latency histograms, in microseconds. powers of
+// two from 1us to ~16s, like the buckets of LATENCY HISTOGRAM.
+const (
+	latencyBucketMinExp = 0
+	latencyBucketMaxExp = 24
+)
+
+// Listen serves the latest published snapshot on /metrics at the address, in the Prometheus text
+// exposition format, over HTTPS if tlsConfig isn't nil. Returns the HTTP server once it listens,
+// to be shut down along with the server.
+func Listen(addr string, tlsConfig *tls.Config) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+	server := &http.Server{Addr: addr, Handler: mux, TLSConfig: tlsConfig}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		var err error
+		if tlsConfig == nil {
+			log.Printf("Serving metrics on http://%s/metrics...\n", l.Addr())
+			err = server.Serve(l)
+		} else {
+			log.Printf("Serving metrics on https://%s/metrics...\n", l.Addr())
+			err = server.ServeTLS(l, "", "")
+		}
+		if err != http.ErrServerClosed {
+			log.Println("Error while serving the metrics: ", err)
+		}
+	}()
+	return server, nil
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshot := Latest()
+	if snapshot == nil {
+		http.Error(w, "no metrics have been published yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WriteTo(w, snapshot)
+}
+
+// writes the metrics of the snapshot in the Prometheus text exposition format.
+func WriteTo(w io.Writer, snapshot *Snapshot) error {
+	var buf bytes.Buffer
+
+	family(&buf, "redis_uptime_in_seconds", "gauge", "Number of seconds since the server started.")
+	sample(&buf, "redis_uptime_in_seconds", "", int64(snapshot.Uptime.Seconds()))
+
+	family(&buf, "redis_db_keys", "gauge", "Number of keys in the database.")
+	for i, db := range snapshot.Dbs {
+		sample(&buf, "redis_db_keys", labels("db", "db"+strconv.Itoa(i)), db.Keys)
+	}
+	family(&buf, "redis_db_keys_expiring", "gauge", "Number of keys with an expiry in the database.")
+	for i, db := range snapshot.Dbs {
+		sample(&buf, "redis_db_keys_expiring", labels("db", "db"+strconv.Itoa(i)), db.Expires)
+	}
+
+	family(&buf, "redis_memory_used_bytes", "gauge", "Number of bytes allocated by the server.")
+	sample(&buf, "redis_memory_used_bytes", "", snapshot.UsedMemory)
+
+	family(&buf, "redis_connected_clients", "gauge", "Number of client connections.")
+	sample(&buf, "redis_connected_clients", "", snapshot.ConnectedClients)
+	family(&buf, "redis_connections_received_total", "counter", "Number of connections accepted by the server.")
+	sample(&buf, "redis_connections_received_total", "", snapshot.TotalConnectionsReceived)
+	family(&buf, "redis_rejected_connections_total", "counter", "Number of connections rejected by the server.")
+	sample(&buf, "redis_rejected_connections_total", "", snapshot.RejectedConnections)
+
+	writeCommands(&buf, snapshot)
+
+	family(&buf, "redis_keyspace_hits_total", "counter", "Number of successful lookups of keys.")
+	sample(&buf, "redis_keyspace_hits_total", "", snapshot.KeyspaceHits)
+	family(&buf, "redis_keyspace_misses_total", "counter", "Number of failed lookups of keys.")
+	sample(&buf, "redis_keyspace_misses_total", "", snapshot.KeyspaceMisses)
+
+	family(&buf, "redis_expired_keys_total", "counter", "Number of expired keys, found by the expire cycles (active) or on access (passive).")
+	sample(&buf, "redis_expired_keys_total", labels("type", "active"), snapshot.ActiveExpiredKeys)
+	sample(&buf, "redis_expired_keys_total", labels("type", "passive"), snapshot.PassiveExpiredKeys)
+
+	family(&buf, "redis_evicted_keys_total", "counter", "Number of keys evicted, by eviction strategy.")
+	strategies := make([]string, 0, len(snapshot.EvictedKeys))
+	for strategy := range snapshot.EvictedKeys {
+		strategies = append(strategies, strategy)
+	}
+	sort.Strings(strategies)
+	for _, strategy := range strategies {
+		sample(&buf, "redis_evicted_keys_total", labels("strategy", strategy), snapshot.EvictedKeys[strategy])
+	}
+
+	family(&buf, "redis_master_repl_offset", "gauge", "Offset of the replication stream of the server.")
+	sample(&buf, "redis_master_repl_offset", "", snapshot.MasterReplOffset)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writes the calls of every command, and their latency histograms.
+func writeCommands(buf *bytes.Buffer, snapshot *Snapshot) {
+	family(buf, "redis_commands_total", "counter", "Number of calls of the command.")
+	for _, cmd := range snapshot.Commands {
+		sample(buf, "redis_commands_total", labels("cmd", cmd.Name), cmd.Calls)
+	}
+	family(buf, "redis_commands_rejected_calls_total", "counter", "Number of calls of the command refused before it ran.")
+	for _, cmd := range snapshot.Commands {
+		sample(buf, "redis_commands_rejected_calls_total", labels("cmd", cmd.Name), cmd.RejectedCalls)
+	}
+	family(buf, "redis_commands_failed_calls_total", "counter", "Number of calls of the command that replied with an error.")
+	for _, cmd := range snapshot.Commands {
+		sample(buf, "redis_commands_failed_calls_total", labels("cmd", cmd.Name), cmd.FailedCalls)
+	}
+
+	family(buf, "redis_commands_latency_seconds", "histogram", "Time spent running the command.")
+	for _, cmd := range snapshot.Commands {
+		for exp := latencyBucketMinExp; exp <= latencyBucketMaxExp; exp++ {
+			usec := uint64(1) << exp
+			le := strconv.FormatFloat(float64(usec)/1e6, 'g', -1, 64)
+			sample(buf, "redis_commands_latency_seconds_bucket", labels("cmd", cmd.Name, "le", le), cmd.Latency.CountUpTo(usec))
+		}
+		sample(buf, "redis_commands_latency_seconds_bucket", labels("cmd", cmd.Name, "le", "+Inf"), cmd.Latency.Count())
+		sample(buf, "redis_commands_latency_seconds_sum", labels("cmd", cmd.Name), float64(cmd.Usec)/1e6)
+		sample(buf, "redis_commands_latency_seconds_count", labels("cmd", cmd.Name), cmd.Latency.Count())
+	}
+}
+
+// writes the HELP and TYPE lines of a metric family.
+func family(buf *bytes.Buffer, name string, kind string, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writes a sample of the metric.
+func sample(buf *bytes.Buffer, name string, labels string, value any) {
+	fmt.Fprintf(buf, "%s%s %v\n", name, labels, value)
+}
+
+// formats the label pairs, given as alternating names and values, eg. {cmd="get"}.
+func labels(pairs ...string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%s=%s", pairs[i], strconv.Quote(pairs[i+1]))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
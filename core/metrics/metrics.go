@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// DbSnapshot is the size of a logical database at the time of a snapshot.
+type DbSnapshot struct {
+	Keys    int
+	Expires int
+}
+
+// Snapshot holds the metrics of the server at a point in time. Snapshots are taken by the event
+// loop, which owns the keyspace, and are never modified once published, so that they can be
+// served to the scrapers from other goroutines without locking the loop out.
+type Snapshot struct {
+	Time   time.Time
+	Uptime time.Duration
+
+	// the databases, by their indexes.
+	Dbs []DbSnapshot
+
+	UsedMemory       uint64
+	ConnectedClients int64
+
+	TotalConnectionsReceived int64
+	RejectedConnections      int64
+
+	// copies of the stats of the commands that have been called, sorted by name.
+	Commands []stats.CommandStats
+
+	KeyspaceHits   int64
+	KeyspaceMisses int64
+
+	ActiveExpiredKeys  int64
+	PassiveExpiredKeys int64
+
+	// evicted keys by the names of the eviction strategies that evicted them.
+	EvictedKeys map[string]int64
+
+	// the server doesn't replicate, so the offset of its replication stream is always 0.
+	MasterReplOffset int64
+}
+
+// takes a snapshot of the metrics of the server. must be called from the event loop.
+func TakeSnapshot(databases *store.Databases) *Snapshot {
+	serverStats := stats.GetStats()
+
+	dbs := make([]DbSnapshot, databases.Count())
+	for i := range dbs {
+		db := databases.Get(i)
+		dbs[i] = DbSnapshot{
+			Keys:    db.KeyCount(),
+			Expires: db.ExpiryCount(),
+		}
+	}
+
+	return &Snapshot{
+		Time:                     time.Now(),
+		Uptime:                   serverStats.Uptime(),
+		Dbs:                      dbs,
+		UsedMemory:               serverStats.TrackUsedMemory(),
+		ConnectedClients:         serverStats.ConnectedClients.Load(),
+		TotalConnectionsReceived: serverStats.TotalConnectionsReceived.Load(),
+		RejectedConnections:      serverStats.RejectedConnections.Load(),
+		Commands:                 serverStats.Commands(),
+		KeyspaceHits:             serverStats.KeyspaceHits.Load(),
+		KeyspaceMisses:           serverStats.KeyspaceMisses.Load(),
+		ActiveExpiredKeys:        serverStats.ActiveExpiredKeys.Load(),
+		PassiveExpiredKeys:       serverStats.PassiveExpiredKeys.Load(),
+		EvictedKeys:              serverStats.EvictedKeysByStrategy(),
+		MasterReplOffset:         0,
+	}
+}
+
+var latest atomic.Pointer[Snapshot]
+
+// makes the snapshot the one served to the scrapers.
+func Publish(snapshot *Snapshot) {
+	latest.Store(snapshot)
+}
+
+// returns the latest published snapshot, nil if none has been published yet.
+func Latest() *Snapshot {
+	return latest.Load()
+}
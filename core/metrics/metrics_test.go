@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/metrics"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
+
+var _ = Describe("Metrics", func() {
+	It("should snapshot the keyspace of every database", func() {
+		databases := store.NewDatabases(2)
+		databases.Get(1).Put("key", "value", nil)
+
+		snapshot := metrics.TakeSnapshot(databases)
+
+		Expect(snapshot.Dbs).To(Equal([]metrics.DbSnapshot{{Keys: 0}, {Keys: 1}}))
+		Expect(snapshot.UsedMemory).To(BeNumerically(">", 0))
+	})
+
+	It("should serve the latest published snapshot", func() {
+		first, second := &metrics.Snapshot{}, &metrics.Snapshot{}
+
+		metrics.Publish(first)
+		metrics.Publish(second)
+
+		Expect(metrics.Latest()).To(BeIdenticalTo(second))
+	})
+
+	It("should write the snapshot in the Prometheus text format", func() {
+		get := stats.CommandStats{Name: "get", Calls: 3, Usec: 12}
+		get.Latency.Record(2)
+		get.Latency.Record(2)
+		get.Latency.Record(8)
+
+		snapshot := &metrics.Snapshot{
+			Uptime:             90 * time.Second,
+			Dbs:                []metrics.DbSnapshot{{Keys: 5, Expires: 2}},
+			ConnectedClients:   4,
+			Commands:           []stats.CommandStats{get},
+			ActiveExpiredKeys:  7,
+			PassiveExpiredKeys: 1,
+			EvictedKeys:        map[string]int64{"allkeys-lru": 9},
+		}
+
+		var buf bytes.Buffer
+		Expect(metrics.WriteTo(&buf, snapshot)).To(Succeed())
+
+		lines := buf.String()
+		Expect(lines).To(ContainSubstring("# TYPE redis_db_keys gauge\n"))
+		Expect(lines).To(ContainSubstring("redis_uptime_in_seconds 90\n"))
+		Expect(lines).To(ContainSubstring("redis_db_keys{db=\"db0\"} 5\n"))
+		Expect(lines).To(ContainSubstring("redis_db_keys_expiring{db=\"db0\"} 2\n"))
+		Expect(lines).To(ContainSubstring("redis_connected_clients 4\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_total{cmd=\"get\"} 3\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_latency_seconds_bucket{cmd=\"get\",le=\"1e-06\"} 0\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_latency_seconds_bucket{cmd=\"get\",le=\"2e-06\"} 2\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_latency_seconds_bucket{cmd=\"get\",le=\"+Inf\"} 3\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_latency_seconds_sum{cmd=\"get\"} 1.2e-05\n"))
+		Expect(lines).To(ContainSubstring("redis_commands_latency_seconds_count{cmd=\"get\"} 3\n"))
+		Expect(lines).To(ContainSubstring("redis_expired_keys_total{type=\"active\"} 7\n"))
+		Expect(lines).To(ContainSubstring("redis_expired_keys_total{type=\"passive\"} 1\n"))
+		Expect(lines).To(ContainSubstring("redis_evicted_keys_total{strategy=\"allkeys-lru\"} 9\n"))
+		Expect(lines).To(ContainSubstring("redis_master_repl_offset 0\n"))
+	})
+})
@@ -0,0 +1,121 @@
+package pubsub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// flags that pick the channels the keyspace events are published to.
+// they share the bitmap with the store.KeyspaceEventClass flags.
+const (
+	// publish to __keyspace@<db>__:<key> with the event name as the message.
+	KeyspaceChannel store.KeyspaceEventClass = 1 << (iota + 16)
+	// publish to __keyevent@<db>__:<event> with the key as the message.
+	KeyeventChannel
+)
+
+// all the event classes that are enabled by the 'A' flag.
+const allEventClasses = store.GenericEvent | store.StringEvent | store.ListEvent | store.SetEvent |
+	store.HashEvent | store.ZSetEvent | store.StreamEvent | store.ExpiredEvent | store.EvictedEvent
+
+// characters of the notify-keyspace-events setting along with the flags they enable.
+var keyspaceEventFlagChars = []struct {
+	char byte
+	flag store.KeyspaceEventClass
+}{
+	{'g', store.GenericEvent},
+	{'$', store.StringEvent},
+	{'l', store.ListEvent},
+	{'s', store.SetEvent},
+	{'h', store.HashEvent},
+	{'z', store.ZSetEvent},
+	{'x', store.ExpiredEvent},
+	{'e', store.EvictedEvent},
+	{'t', store.StreamEvent},
+	{'m', store.KeyMissEvent},
+	{'n', store.NewKeyEvent},
+	{'K', KeyspaceChannel},
+	{'E', KeyeventChannel},
+}
+
+// the keyspace events that are currently enabled. no events are published by default.
+var keyspaceEventFlags store.KeyspaceEventClass = 0
+
+// SetKeyspaceEvents enables the keyspace event classes described by the given
+// notify-keyspace-events string, eg. "KEx" or "AKE". Returns an error if the string
+// contains an unknown class.
+func SetKeyspaceEvents(classes string) error {
+	var flags store.KeyspaceEventClass = 0
+
+	for i := 0; i < len(classes); i++ {
+		if classes[i] == 'A' {
+			flags |= allEventClasses
+			continue
+		}
+
+		known := false
+		for _, fc := range keyspaceEventFlagChars {
+			if fc.char == classes[i] {
+				flags |= fc.flag
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return fmt.Errorf("invalid keyspace event class '%c'", classes[i])
+		}
+	}
+
+	keyspaceEventFlags = flags
+	return nil
+}
+
+// returns the notify-keyspace-events string describing the enabled event classes.
+func KeyspaceEvents() string {
+	var sb strings.Builder
+
+	flags := keyspaceEventFlags
+	if flags&allEventClasses == allEventClasses {
+		sb.WriteByte('A')
+		flags &^= allEventClasses
+	}
+
+	for _, fc := range keyspaceEventFlagChars {
+		if flags&fc.flag != 0 {
+			sb.WriteByte(fc.char)
+		}
+	}
+
+	return sb.String()
+}
+
+// KeyspaceNotifier publishes the keyspace events of a database to the
+// __keyspace@<db>__ and __keyevent@<db>__ channels.
+type KeyspaceNotifier struct {
+	ps *PubSub
+	db int
+}
+
+func NewKeyspaceNotifier(ps *PubSub, db int) *KeyspaceNotifier {
+	return &KeyspaceNotifier{
+		ps: ps,
+		db: db,
+	}
+}
+
+func (n *KeyspaceNotifier) OnKeyspaceEvent(event store.KeyspaceEvent) {
+	if keyspaceEventFlags&event.Class == 0 {
+		return
+	}
+
+	if keyspaceEventFlags&KeyspaceChannel != 0 {
+		n.ps.Publish(fmt.Sprintf("__keyspace@%d__:%s", n.db, event.Key), event.Name)
+	}
+
+	if keyspaceEventFlags&KeyeventChannel != 0 {
+		n.ps.Publish(fmt.Sprintf("__keyevent@%d__:%s", n.db, event.Name), event.Key)
+	}
+}
@@ -0,0 +1,83 @@
+package pubsub_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+var _ = Describe("KeyspaceNotifier", func() {
+	var (
+		ps        *pubsub.PubSub
+		dataStore *store.DataStore
+	)
+
+	BeforeEach(func() {
+		ps = pubsub.NewPubSub()
+		dataStore = store.NewDataStore()
+		dataStore.AddKeyspaceObserver(pubsub.NewKeyspaceNotifier(ps, 0))
+	})
+
+	AfterEach(func() {
+		Expect(pubsub.SetKeyspaceEvents("")).To(Succeed())
+	})
+
+	It("should not publish anything by default", func() {
+		c, conn := newTestClient()
+		ps.PSubscribe(c, "*")
+
+		dataStore.Put("key", "value", nil)
+
+		Expect(flushed(c, conn)).To(BeEmpty())
+	})
+
+	It("should publish to the keyspace and keyevent channels", func() {
+		Expect(pubsub.SetKeyspaceEvents("KE$")).To(Succeed())
+		keyspace, keyspaceConn := newTestClient()
+		keyevent, keyeventConn := newTestClient()
+		ps.Subscribe(keyspace, "__keyspace@0__:key")
+		ps.Subscribe(keyevent, "__keyevent@0__:set")
+
+		dataStore.Put("key", "value", nil)
+
+		Expect(flushed(keyspace, keyspaceConn)).To(Equal("*3\r\n$7\r\nmessage\r\n$18\r\n__keyspace@0__:key\r\n$3\r\nset\r\n"))
+		Expect(flushed(keyevent, keyeventConn)).To(Equal("*3\r\n$7\r\nmessage\r\n$18\r\n__keyevent@0__:set\r\n$3\r\nkey\r\n"))
+	})
+
+	It("should only publish the enabled classes", func() {
+		Expect(pubsub.SetKeyspaceEvents("Ex")).To(Succeed())
+		c, conn := newTestClient()
+		ps.PSubscribe(c, "__keyevent@0__:*")
+
+		dataStore.Put("key", "value", utils.FromExpiryInSeconds(100))
+		dataStore.Delete("key")
+		dataStore.Put("expiring", "value", utils.FromExpiryInMilliseconds(-1000))
+
+		Expect(flushed(c, conn)).To(Equal("*4\r\n$8\r\npmessage\r\n$16\r\n__keyevent@0__:*\r\n$22\r\n__keyevent@0__:expired\r\n$8\r\nexpiring\r\n"))
+	})
+
+	It("should publish evicted events", func() {
+		Expect(pubsub.SetKeyspaceEvents("Ee")).To(Succeed())
+		c, conn := newTestClient()
+		ps.Subscribe(c, "__keyevent@0__:evicted")
+
+		dataStore.Put("key", "value", nil)
+		dataStore.Evict()
+
+		Expect(flushed(c, conn)).To(ContainSubstring("$3\r\nkey\r\n"))
+	})
+
+	It("should round trip the notify-keyspace-events string", func() {
+		Expect(pubsub.SetKeyspaceEvents("KEA")).To(Succeed())
+		Expect(pubsub.KeyspaceEvents()).To(Equal("AKE"))
+
+		Expect(pubsub.SetKeyspaceEvents("Kxe")).To(Succeed())
+		Expect(pubsub.KeyspaceEvents()).To(Equal("xeK"))
+	})
+
+	It("should reject unknown classes", func() {
+		Expect(pubsub.SetKeyspaceEvents("KQ")).ToNot(Succeed())
+	})
+})
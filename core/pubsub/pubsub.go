@@ -0,0 +1,238 @@
+package pubsub
+
+import (
+	"sort"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// kinds of messages delivered to the subscribers.
+const (
+	Message      = "message"
+	PMessage     = "pmessage"
+	Subscribe    = "subscribe"
+	Unsubscribe  = "unsubscribe"
+	PSubscribe   = "psubscribe"
+	PUnsubscribe = "punsubscribe"
+	SMessage     = "smessage"
+	SSubscribe   = "ssubscribe"
+	SUnsubscribe = "sunsubscribe"
+)
+
+// PubSub keeps track of the channel and pattern subscriptions of all the clients
+// and delivers the published messages to them.
+//
+// Sharded channels are kept apart from the classic ones. They are hashed to slots
+// like keys are, and their messages are only delivered to the subscribers of the
+// exact channel, never to pattern subscribers.
+type PubSub struct {
+	// subscribers of every channel.
+	channels map[string]map[*client.Client]struct{}
+	// subscribers of every pattern.
+	patterns map[string]map[*client.Client]struct{}
+	// subscribers of every sharded channel.
+	shardChannels map[string]map[*client.Client]struct{}
+}
+
+func NewPubSub() *PubSub {
+	return &PubSub{
+		channels:      make(map[string]map[*client.Client]struct{}),
+		patterns:      make(map[string]map[*client.Client]struct{}),
+		shardChannels: make(map[string]map[*client.Client]struct{}),
+	}
+}
+
+var pubSubInstance *PubSub
+
+func GetPubSub() *PubSub {
+	if pubSubInstance == nil {
+		pubSubInstance = NewPubSub()
+	}
+
+	return pubSubInstance
+}
+
+// subscribes the client to the channel. returns false if the client was already subscribed.
+func (ps *PubSub) Subscribe(c *client.Client, channel string) bool {
+	if _, exists := c.Channels[channel]; exists {
+		return false
+	}
+
+	c.Channels[channel] = struct{}{}
+	addSubscriber(ps.channels, channel, c)
+	return true
+}
+
+// unsubscribes the client from the channel. returns false if the client wasn't subscribed to it.
+func (ps *PubSub) Unsubscribe(c *client.Client, channel string) bool {
+	if _, exists := c.Channels[channel]; !exists {
+		return false
+	}
+
+	delete(c.Channels, channel)
+	removeSubscriber(ps.channels, channel, c)
+	return true
+}
+
+// subscribes the client to the pattern. returns false if the client was already subscribed.
+func (ps *PubSub) PSubscribe(c *client.Client, pattern string) bool {
+	if _, exists := c.Patterns[pattern]; exists {
+		return false
+	}
+
+	c.Patterns[pattern] = struct{}{}
+	addSubscriber(ps.patterns, pattern, c)
+	return true
+}
+
+// unsubscribes the client from the pattern. returns false if the client wasn't subscribed to it.
+func (ps *PubSub) PUnsubscribe(c *client.Client, pattern string) bool {
+	if _, exists := c.Patterns[pattern]; !exists {
+		return false
+	}
+
+	delete(c.Patterns, pattern)
+	removeSubscriber(ps.patterns, pattern, c)
+	return true
+}
+
+// subscribes the client to the sharded channel. returns false if the client was already subscribed.
+func (ps *PubSub) SSubscribe(c *client.Client, channel string) bool {
+	if _, exists := c.ShardChannels[channel]; exists {
+		return false
+	}
+
+	c.ShardChannels[channel] = struct{}{}
+	addSubscriber(ps.shardChannels, channel, c)
+	return true
+}
+
+// unsubscribes the client from the sharded channel. returns false if the client wasn't subscribed to it.
+func (ps *PubSub) SUnsubscribe(c *client.Client, channel string) bool {
+	if _, exists := c.ShardChannels[channel]; !exists {
+		return false
+	}
+
+	delete(c.ShardChannels, channel)
+	removeSubscriber(ps.shardChannels, channel, c)
+	return true
+}
+
+// removes all the subscriptions of the client. must be called when the client disconnects.
+func (ps *PubSub) UnsubscribeAll(c *client.Client) {
+	for channel := range c.Channels {
+		ps.Unsubscribe(c, channel)
+	}
+	for pattern := range c.Patterns {
+		ps.PUnsubscribe(c, pattern)
+	}
+	for channel := range c.ShardChannels {
+		ps.SUnsubscribe(c, channel)
+	}
+}
+
+// delivers the message to every client subscribed to the channel, or to a pattern
+// matching the channel. returns the number of clients that received the message.
+func (ps *PubSub) Publish(channel string, message string) int {
+	nReceivers := 0
+
+	for c := range ps.channels[channel] {
+		c.Write(EncodeMessage(c, Message, channel, message))
+		nReceivers++
+	}
+
+	for pattern, subscribers := range ps.patterns {
+		if !utils.GlobMatch(pattern, channel) {
+			continue
+		}
+		for c := range subscribers {
+			c.Write(EncodeMessage(c, PMessage, pattern, channel, message))
+			nReceivers++
+		}
+	}
+
+	return nReceivers
+}
+
+// delivers the message to every client subscribed to the sharded channel.
+// returns the number of clients that received the message.
+func (ps *PubSub) SPublish(channel string, message string) int {
+	nReceivers := 0
+
+	for c := range ps.shardChannels[channel] {
+		c.Write(EncodeMessage(c, SMessage, channel, message))
+		nReceivers++
+	}
+
+	return nReceivers
+}
+
+// returns the channels with at least one subscriber, filtered by the
+// glob-style pattern if it is non-empty.
+func (ps *PubSub) Channels(pattern string) []string {
+	return activeNames(ps.channels, pattern)
+}
+
+// returns the number of subscribers of the channel.
+func (ps *PubSub) NumSub(channel string) int {
+	return len(ps.channels[channel])
+}
+
+// returns the sharded channels with at least one subscriber, filtered by the
+// glob-style pattern if it is non-empty.
+func (ps *PubSub) ShardChannels(pattern string) []string {
+	return activeNames(ps.shardChannels, pattern)
+}
+
+// returns the number of subscribers of the sharded channel.
+func (ps *PubSub) ShardNumSub(channel string) int {
+	return len(ps.shardChannels[channel])
+}
+
+// returns the number of unique patterns that the clients are subscribed to.
+func (ps *PubSub) NumPat() int {
+	return len(ps.patterns)
+}
+
+// encodes the given pub/sub message for the client. RESP3 clients get the message
+// as a push frame, since it can be interleaved with the replies to their commands.
+func EncodeMessage(c *client.Client, kind string, fields ...interface{}) []byte {
+	msg := append([]interface{}{kind}, fields...)
+	if c.Protocol == resp.Resp3 {
+		return resp.EncodePush(msg)
+	}
+	return resp.EncodeArray(msg)
+}
+
+func addSubscriber(subscriptions map[string]map[*client.Client]struct{}, name string, c *client.Client) {
+	subscribers, exists := subscriptions[name]
+	if !exists {
+		subscribers = make(map[*client.Client]struct{})
+		subscriptions[name] = subscribers
+	}
+	subscribers[c] = struct{}{}
+}
+
+func removeSubscriber(subscriptions map[string]map[*client.Client]struct{}, name string, c *client.Client) {
+	subscribers, exists := subscriptions[name]
+	if !exists {
+		return
+	}
+	delete(subscribers, c)
+	if len(subscribers) == 0 {
+		delete(subscriptions, name)
+	}
+}
+
+func activeNames(subscriptions map[string]map[*client.Client]struct{}, pattern string) []string {
+	names := make([]string, 0)
+	for name := range subscriptions {
+		if pattern == "" || utils.GlobMatch(pattern, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,124 @@
+package pubsub_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+)
+
+func TestPubSub(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PubSub Suite")
+}
+
+// returns a new client along with the buffer its replies get flushed to.
+func newTestClient() (*client.Client, *bytes.Buffer) {
+	conn := &bytes.Buffer{}
+	return client.NewClient(-1, conn), conn
+}
+
+// flushes the client's replies and returns everything written so far.
+func flushed(c *client.Client, conn *bytes.Buffer) string {
+	_, err := c.Flush()
+	Expect(err).ToNot(HaveOccurred())
+	return conn.String()
+}
+
+var _ = Describe("PubSub", func() {
+	var ps *pubsub.PubSub
+
+	BeforeEach(func() {
+		ps = pubsub.NewPubSub()
+	})
+
+	It("should deliver messages to the channel subscribers", func() {
+		c1, conn1 := newTestClient()
+		c2, conn2 := newTestClient()
+		ps.Subscribe(c1, "news")
+		ps.Subscribe(c2, "sports")
+
+		Expect(ps.Publish("news", "hello")).To(Equal(1))
+
+		Expect(flushed(c1, conn1)).To(Equal("*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n"))
+		Expect(flushed(c2, conn2)).To(BeEmpty())
+	})
+
+	It("should deliver messages to the subscribers of matching patterns", func() {
+		c, conn := newTestClient()
+		ps.PSubscribe(c, "news.*")
+
+		Expect(ps.Publish("news.tech", "hi")).To(Equal(1))
+		Expect(ps.Publish("weather", "sunny")).To(Equal(0))
+
+		Expect(flushed(c, conn)).To(Equal("*4\r\n$8\r\npmessage\r\n$6\r\nnews.*\r\n$9\r\nnews.tech\r\n$2\r\nhi\r\n"))
+	})
+
+	It("should send push frames to RESP3 clients", func() {
+		c, conn := newTestClient()
+		c.Protocol = resp.Resp3
+		ps.Subscribe(c, "news")
+
+		ps.Publish("news", "hello")
+
+		Expect(flushed(c, conn)).To(HavePrefix(">3\r\n"))
+	})
+
+	It("should stop delivering messages after unsubscribing", func() {
+		c, _ := newTestClient()
+		ps.Subscribe(c, "news")
+
+		Expect(ps.Unsubscribe(c, "news")).To(BeTrue())
+		Expect(ps.Unsubscribe(c, "news")).To(BeFalse())
+		Expect(ps.Publish("news", "hello")).To(Equal(0))
+		Expect(c.SubscriptionCount()).To(Equal(0))
+	})
+
+	It("should drop all the subscriptions of a client", func() {
+		c, _ := newTestClient()
+		ps.Subscribe(c, "a")
+		ps.Subscribe(c, "b")
+		ps.PSubscribe(c, "c*")
+
+		ps.UnsubscribeAll(c)
+
+		Expect(c.SubscriptionCount()).To(Equal(0))
+		Expect(ps.Channels("")).To(BeEmpty())
+		Expect(ps.NumPat()).To(Equal(0))
+	})
+
+	It("should report the active channels and subscriber counts", func() {
+		c1, _ := newTestClient()
+		c2, _ := newTestClient()
+		ps.Subscribe(c1, "news")
+		ps.Subscribe(c2, "news")
+		ps.Subscribe(c2, "sports")
+		ps.PSubscribe(c1, "*")
+
+		Expect(ps.Channels("")).To(Equal([]string{"news", "sports"}))
+		Expect(ps.Channels("n*")).To(Equal([]string{"news"}))
+		Expect(ps.NumSub("news")).To(Equal(2))
+		Expect(ps.NumSub("unknown")).To(Equal(0))
+		Expect(ps.NumPat()).To(Equal(1))
+	})
+
+	It("should deliver sharded messages only to the subscribers of the sharded channel", func() {
+		shardSubscriber, shardConn := newTestClient()
+		patternSubscriber, patternConn := newTestClient()
+		ps.SSubscribe(shardSubscriber, "orders")
+		ps.PSubscribe(patternSubscriber, "*")
+
+		Expect(ps.SPublish("orders", "created")).To(Equal(1))
+		Expect(ps.Publish("orders", "created")).To(Equal(1))
+
+		Expect(flushed(shardSubscriber, shardConn)).To(Equal("*3\r\n$8\r\nsmessage\r\n$6\r\norders\r\n$7\r\ncreated\r\n"))
+		Expect(flushed(patternSubscriber, patternConn)).To(HavePrefix("*4\r\n$8\r\npmessage\r\n"))
+		Expect(ps.ShardChannels("")).To(Equal([]string{"orders"}))
+		Expect(ps.Channels("")).To(BeEmpty())
+		Expect(ps.ShardNumSub("orders")).To(Equal(1))
+	})
+})
@@ -6,8 +6,28 @@ const (
 	RespIntegerIdentifier      byte = ':'
 	RespBulkStringIdentifier   byte = '$'
 	RespArrayIdentifier        byte = '*'
+
+	// RESP3 only identifiers.
+	RespNullIdentifier byte = '_'
+	RespMapIdentifier  byte = '%'
+	RespPushIdentifier byte = '>'
+)
+
+// Protocol versions that a client can negotiate through HELLO.
+const (
+	Resp2 = 2
+	Resp3 = 3
 )
 
+// RESP-encoded null bulk string, the RESP2 representation of a missing value.
+var NullBulkString = []byte("$-1\r\n")
+
+// RESP-encoded null array, the RESP2 representation of a missing aggregate.
+var NullArray = []byte("*-1\r\n")
+
+// RESP3-encoded null.
+var Null = []byte("_\r\n")
+
 type RespDataTypes int
 
 const (
@@ -3,6 +3,7 @@ package resp
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Decodes a RESP encoded simple string
@@ -179,6 +180,8 @@ func EncodeWithDatatype(val interface{}, dataType RespDataTypes) []byte {
 	case RespInteger:
 		return []byte(fmt.Sprintf("%c%s\r\n", RespIntegerIdentifier, valStr))
 	case SimpleError:
+		// errors are single line replies, so multi-line messages (eg. from scripts) are flattened.
+		valStr = strings.NewReplacer("\r", " ", "\n", " ").Replace(strings.TrimSpace(valStr))
 		return []byte(fmt.Sprintf("%c%s\r\n", RespSimpleErrorIdentifier, valStr))
 	default:
 		return EncodeWithDatatype(valStr, SimpleError)
@@ -205,7 +208,61 @@ func Encode(val interface{}, isSimpleStr bool) []byte {
 		return EncodeWithDatatype(value, RespInteger)
 	case error:
 		return EncodeWithDatatype(value, SimpleError)
+	case nil:
+		return NullBulkString
+	case Raw:
+		return value
+	case []string:
+		items := make([]interface{}, len(value))
+		for i, v := range value {
+			items[i] = v
+		}
+		return EncodeArray(items)
+	case []interface{}:
+		return EncodeArray(value)
 	default:
 		return EncodeWithDatatype(value, SimpleError)
 	}
 }
+
+// An already RESP-encoded value, which gets embedded as is when encoding
+// aggregates, eg. a map nested inside an array.
+type Raw []byte
+
+// Encodes the given values into a RESP array. Every element is encoded
+// using Encode, with strings being treated as bulk strings.
+func EncodeArray(vals []interface{}) []byte {
+	return encodeAggregate(RespArrayIdentifier, len(vals), vals)
+}
+
+// Wraps the given already encoded items into a RESP array.
+func EncodeRawArray(items [][]byte) []byte {
+	buf := []byte(fmt.Sprintf("%c%d\r\n", RespArrayIdentifier, len(items)))
+	for _, item := range items {
+		buf = append(buf, item...)
+	}
+	return buf
+}
+
+// Encodes the given values into a RESP3 push frame. Push frames carry
+// out-of-band data such as pub/sub messages that the client did not ask for.
+func EncodePush(vals []interface{}) []byte {
+	return encodeAggregate(RespPushIdentifier, len(vals), vals)
+}
+
+// Encodes the given flattened key-value pairs as a RESP3 map if resp3 is set,
+// else as a flat RESP2 array of alternating keys and values.
+func EncodeMap(pairs []interface{}, resp3 bool) []byte {
+	if !resp3 {
+		return EncodeArray(pairs)
+	}
+	return encodeAggregate(RespMapIdentifier, len(pairs)/2, pairs)
+}
+
+func encodeAggregate(identifier byte, length int, vals []interface{}) []byte {
+	buf := []byte(fmt.Sprintf("%c%d\r\n", identifier, length))
+	for _, v := range vals {
+		buf = append(buf, Encode(v, false)...)
+	}
+	return buf
+}
@@ -0,0 +1,97 @@
+package resp
+
+import (
+	"errors"
+	"strconv"
+)
+
+// returned by DecodeTyped when the data ends before the value does, eg. while the rest of it is
+// yet to arrive.
+var ErrIncomplete = errors.New("incomplete data")
+
+// A decoded RESP value that remembers its RESP type. Unlike Decode, it tells
+// simple strings from bulk strings and errors, and it represents nulls.
+type TypedValue struct {
+	// identifier of the RESP type of the value, eg. RespBulkStringIdentifier.
+	Type byte
+	// set for simple strings, errors and bulk strings.
+	Str string
+	// set for integers.
+	Int int64
+	// set for arrays, maps (flattened into alternating keys and values) and push frames.
+	Items []TypedValue
+	// set for null bulk strings, null arrays and the RESP3 null.
+	IsNull bool
+}
+
+// DecodeTyped decodes a single RESP value starting at startPos.
+// Returns the decoded value and the position right after it.
+func DecodeTyped(data []byte, startPos int) (TypedValue, int, error) {
+	if startPos >= len(data) {
+		return TypedValue{}, startPos, errors.New("no data")
+	}
+
+	identifier := data[startPos]
+	lineEnd := findLineEnd(data, startPos+1)
+	if lineEnd == -1 {
+		return TypedValue{}, startPos, ErrIncomplete
+	}
+	line := string(data[startPos+1 : lineEnd])
+	next := lineEnd + 2
+
+	switch identifier {
+	case RespSimpleStringIdentifier, RespSimpleErrorIdentifier:
+		return TypedValue{Type: identifier, Str: line}, next, nil
+	case RespIntegerIdentifier:
+		num, err := strconv.ParseInt(line, 10, 64)
+		return TypedValue{Type: identifier, Int: num}, next, err
+	case RespNullIdentifier:
+		return TypedValue{Type: identifier, IsNull: true}, next, nil
+	case RespBulkStringIdentifier:
+		length, err := strconv.Atoi(line)
+		if err != nil {
+			return TypedValue{}, startPos, err
+		}
+		if length < 0 {
+			return TypedValue{Type: identifier, IsNull: true}, next, nil
+		}
+		if next+length+2 > len(data) {
+			return TypedValue{}, startPos, ErrIncomplete
+		}
+		return TypedValue{Type: identifier, Str: string(data[next : next+length])}, next + length + 2, nil
+	case RespArrayIdentifier, RespMapIdentifier, RespPushIdentifier:
+		length, err := strconv.Atoi(line)
+		if err != nil {
+			return TypedValue{}, startPos, err
+		}
+		if length < 0 {
+			return TypedValue{Type: identifier, IsNull: true}, next, nil
+		}
+		if identifier == RespMapIdentifier {
+			length *= 2
+		}
+		items := make([]TypedValue, 0, length)
+		for i := 0; i < length; i++ {
+			item, pos, err := DecodeTyped(data, next)
+			if err != nil {
+				return TypedValue{}, startPos, err
+			}
+			items = append(items, item)
+			next = pos
+		}
+		return TypedValue{Type: identifier, Items: items}, next, nil
+	default:
+		return TypedValue{}, startPos, errors.New("unknown datatype: " + string(identifier))
+	}
+}
+
+// returns the index of the "\r\n" terminating the line that starts at startPos,
+// or -1 if the line is incomplete.
+func findLineEnd(data []byte, startPos int) int {
+	for i := startPos; i+1 < len(data); i++ {
+		if data[i] == '\r' && data[i+1] == '\n' {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,503 @@
+package scripting
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/utils"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// the only engine function libraries can be written in.
+const LuaEngine = "LUA"
+
+// how long a library may take to register its functions when it is loaded.
+const libraryLoadTimeout = 500 * time.Millisecond
+
+// version of the payload format produced by DumpFunctions.
+const functionsDumpVersion byte = 1
+
+// flags functions can be registered with.
+const (
+	FlagNoWrites           = "no-writes"
+	FlagAllowOOM           = "allow-oom"
+	FlagAllowStale         = "allow-stale"
+	FlagNoCluster          = "no-cluster"
+	FlagAllowCrossSlotKeys = "allow-cross-slot-keys"
+)
+
+var functionFlags = map[string]bool{
+	FlagNoWrites:           true,
+	FlagAllowOOM:           true,
+	FlagAllowStale:         true,
+	FlagNoCluster:          true,
+	FlagAllowCrossSlotKeys: true,
+}
+
+// policies for restoring the libraries of a payload created by DumpFunctions.
+const (
+	// fail if a restored library already exists.
+	RestoreAppend = "APPEND"
+	// replace the existing libraries with the restored ones.
+	RestoreReplace = "REPLACE"
+	// delete all the existing libraries before restoring.
+	RestoreFlush = "FLUSH"
+)
+
+var errBadPayload = errors.New("ERR payload version or checksum are wrong")
+
+// A library of functions, loaded with FUNCTION LOAD.
+type Library struct {
+	Name   string
+	Engine string
+	Code   string
+	// functions registered by the library, keyed by their names.
+	Functions map[string]*Function
+	proto     *lua.FunctionProto
+}
+
+// A function registered by a library with redis.register_function.
+type Function struct {
+	Name        string
+	Library     string
+	Description string
+	Flags       []string
+}
+
+// returns the functions of the library sorted by their names.
+func (l *Library) SortedFunctions() []*Function {
+	functions := make([]*Function, 0, len(l.Functions))
+	for _, fn := range l.Functions {
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		return functions[i].Name < functions[j].Name
+	})
+	return functions
+}
+
+// returns true if the function was registered with the given flag.
+func (f *Function) HasFlag(flag string) bool {
+	for _, fl := range f.Flags {
+		if fl == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// the loaded libraries and the functions registered by them.
+type functionRegistry struct {
+	libraries map[string]*Library
+	functions map[string]*Function
+}
+
+func newFunctionRegistry() *functionRegistry {
+	return &functionRegistry{
+		libraries: make(map[string]*Library),
+		functions: make(map[string]*Function),
+	}
+}
+
+// adds the library to the registry. An existing library with the same name is
+// only replaced if replace is set. Fails if any of the library's functions is
+// registered by another library.
+func (r *functionRegistry) add(lib *Library, replace bool) error {
+	if _, exists := r.libraries[lib.Name]; exists && !replace {
+		return fmt.Errorf("ERR Library '%s' already exists", lib.Name)
+	}
+
+	for name := range lib.Functions {
+		if fn, exists := r.functions[name]; exists && fn.Library != lib.Name {
+			return fmt.Errorf("ERR Function %s already exists", name)
+		}
+	}
+
+	r.remove(lib.Name)
+	r.libraries[lib.Name] = lib
+	for name, fn := range lib.Functions {
+		r.functions[name] = fn
+	}
+	return nil
+}
+
+// removes the library and its functions from the registry.
+// returns false if there is no such library.
+func (r *functionRegistry) remove(name string) bool {
+	lib, exists := r.libraries[name]
+	if !exists {
+		return false
+	}
+
+	for fnName := range lib.Functions {
+		delete(r.functions, fnName)
+	}
+	delete(r.libraries, name)
+	return true
+}
+
+func (r *functionRegistry) clone() *functionRegistry {
+	cloned := newFunctionRegistry()
+	for name, lib := range r.libraries {
+		cloned.libraries[name] = lib
+	}
+	for name, fn := range r.functions {
+		cloned.functions[name] = fn
+	}
+	return cloned
+}
+
+// LoadLibrary compiles the library, whose code starts with a "#!lua name=<library>" line,
+// and registers its functions. An existing library with the same name is only replaced if
+// replace is set. Returns the name of the loaded library.
+func (s *Scripts) LoadLibrary(code string, replace bool) (string, error) {
+	lib, err := compileLibrary(code)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.registry.add(lib, replace); err != nil {
+		return "", err
+	}
+	return lib.Name, nil
+}
+
+// DeleteLibrary removes the library and all of its functions.
+func (s *Scripts) DeleteLibrary(name string) error {
+	if !s.registry.remove(name) {
+		return errors.New("ERR Library not found")
+	}
+	return nil
+}
+
+// FlushFunctions removes all the libraries.
+func (s *Scripts) FlushFunctions() {
+	s.registry = newFunctionRegistry()
+}
+
+// returns the function with the given name, nil if there is none.
+func (s *Scripts) GetFunction(name string) *Function {
+	return s.registry.functions[name]
+}
+
+// returns the libraries whose names match the glob-style pattern, sorted by their names.
+// all the libraries are returned if the pattern is empty.
+func (s *Scripts) Libraries(pattern string) []*Library {
+	libraries := make([]*Library, 0, len(s.registry.libraries))
+	for name, lib := range s.registry.libraries {
+		if pattern == "" || utils.GlobMatch(pattern, name) {
+			libraries = append(libraries, lib)
+		}
+	}
+	sort.Slice(libraries, func(i, j int) bool {
+		return libraries[i].Name < libraries[j].Name
+	})
+	return libraries
+}
+
+// DumpFunctions serializes the code of all the libraries into a payload that can be
+// restored with RestoreFunctions, eg. on another server. The payload holds a version
+// byte, the length-prefixed code of every library and a CRC32 checksum.
+func (s *Scripts) DumpFunctions() []byte {
+	payload := []byte{functionsDumpVersion}
+	for _, lib := range s.Libraries("") {
+		payload = binary.AppendUvarint(payload, uint64(len(lib.Code)))
+		payload = append(payload, lib.Code...)
+	}
+	return binary.BigEndian.AppendUint32(payload, crc32.ChecksumIEEE(payload))
+}
+
+// RestoreFunctions loads the libraries of a payload created by DumpFunctions, following
+// the given restore policy. Either all the libraries are restored, or none of them is.
+func (s *Scripts) RestoreFunctions(payload []byte, policy string) error {
+	if len(payload) < 5 || payload[0] != functionsDumpVersion {
+		return errBadPayload
+	}
+
+	body, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(checksum) {
+		return errBadPayload
+	}
+
+	restored := s.registry.clone()
+	if policy == RestoreFlush {
+		restored = newFunctionRegistry()
+	}
+
+	for pos := 1; pos < len(body); {
+		length, n := binary.Uvarint(body[pos:])
+		if n <= 0 || uint64(len(body)-pos-n) < length {
+			return errBadPayload
+		}
+		pos += n
+
+		lib, err := compileLibrary(string(body[pos : pos+int(length)]))
+		if err != nil {
+			return err
+		}
+		pos += int(length)
+
+		if err := restored.add(lib, policy == RestoreReplace); err != nil {
+			return err
+		}
+	}
+
+	s.registry = restored
+	return nil
+}
+
+// CallFunction runs the function on behalf of the client, with its keys and args passed
+// as arguments to it. The commands called by the function are run with the call handler.
+// see Run for how the function is run.
+//
+// Every call runs in a fresh Lua state, so the library's code is run again to register
+// its functions before the called one runs, and no state leaks between calls.
+func (s *Scripts) CallFunction(name string, keys []string, args []string, c *client.Client, call CallHandler) ([]byte, error) {
+	fn, exists := s.registry.functions[name]
+	if !exists {
+		return nil, errors.New("ERR Function not found")
+	}
+	lib := s.registry.libraries[fn.Library]
+
+	return s.run(name, c, call, func(ctx context.Context, calls chan<- callRequest) ([]byte, error) {
+		L, registered, err := runLibrary(ctx, lib, calls)
+		defer L.Close()
+		if err != nil {
+			return nil, luaErrorToError(err)
+		}
+
+		err = L.CallByParam(lua.P{
+			Fn:      registered.callbacks[name],
+			NRet:    1,
+			Protect: true,
+		}, toLuaArray(L, keys), toLuaArray(L, args))
+		if err != nil {
+			return nil, luaErrorToError(err)
+		}
+
+		ret := L.Get(-1)
+		L.Pop(1)
+		return luaToResp(ret), nil
+	})
+}
+
+// compiles the library's code and runs it to find the functions it registers.
+func compileLibrary(code string) (*Library, error) {
+	engine, name, body, err := parseLibraryMetadata(code)
+	if err != nil {
+		return nil, err
+	}
+
+	proto, err := compile(body, "user_function")
+	if err != nil {
+		return nil, fmt.Errorf("ERR Error compiling function: %s", err)
+	}
+
+	lib := &Library{
+		Name:   name,
+		Engine: engine,
+		Code:   code,
+		proto:  proto,
+	}
+
+	// the library is loaded without a calls channel, so that it can't call any commands.
+	ctx, cancel := context.WithTimeout(context.Background(), libraryLoadTimeout)
+	defer cancel()
+
+	L, registered, err := runLibrary(ctx, lib, nil)
+	L.Close()
+	if ctx.Err() != nil {
+		return nil, errors.New("ERR FUNCTION LOAD timeout")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ERR Error registering functions: %s", strings.TrimPrefix(luaErrorToError(err).Error(), "ERR "))
+	}
+
+	if len(registered.functions) == 0 {
+		return nil, errors.New("ERR No functions registered")
+	}
+
+	for _, fn := range registered.functions {
+		fn.Library = name
+	}
+	lib.Functions = registered.functions
+	return lib, nil
+}
+
+// parses the "#!<engine> name=<library>" line the library's code starts with.
+// returns the engine, the name of the library and its code with the line blanked out.
+func parseLibraryMetadata(code string) (string, string, string, error) {
+	if !strings.HasPrefix(code, "#!") {
+		return "", "", "", errors.New("ERR Missing library metadata")
+	}
+
+	line, body, _ := strings.Cut(code, "\n")
+	parts := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(parts) == 0 {
+		return "", "", "", errors.New("ERR Missing library metadata")
+	}
+
+	engine := strings.ToUpper(parts[0])
+	if engine != LuaEngine {
+		return "", "", "", fmt.Errorf("ERR Engine '%s' not found", parts[0])
+	}
+
+	name := ""
+	for _, part := range parts[1:] {
+		value, found := strings.CutPrefix(part, "name=")
+		if !found {
+			return "", "", "", fmt.Errorf("ERR Invalid metadata value given: %s", part)
+		}
+		name = value
+	}
+
+	if name == "" {
+		return "", "", "", errors.New("ERR Library name was not given")
+	}
+	if !isValidName(name) {
+		return "", "", "", errors.New("ERR Library names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+	}
+
+	// the metadata line is kept as an empty line, so that errors point to the right lines.
+	return engine, name, "\n" + body, nil
+}
+
+// returns true if the name is made of letters, numbers and underscores only.
+func isValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if !(ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// the functions registered by a run of a library's code.
+type registration struct {
+	functions map[string]*Function
+	callbacks map[string]*lua.LFunction
+}
+
+// runs the library's code in a fresh Lua state, collecting the functions it registers with
+// redis.register_function. The returned state must be closed by the caller.
+func runLibrary(ctx context.Context, lib *Library, calls chan<- callRequest) (*lua.LState, *registration, error) {
+	L := newLuaState(calls)
+	L.SetContext(ctx)
+
+	registered := &registration{
+		functions: make(map[string]*Function),
+		callbacks: make(map[string]*lua.LFunction),
+	}
+
+	redis := L.GetGlobal("redis").(*lua.LTable)
+	redis.RawSetString("register_function", L.NewFunction(func(L *lua.LState) int {
+		return registerFunction(L, registered)
+	}))
+
+	L.Push(L.NewFunctionFromProto(lib.proto))
+	if err := L.PCall(0, 0, nil); err != nil {
+		return L, nil, err
+	}
+	return L, registered, nil
+}
+
+// implements redis.register_function, called either with the name and the callback of the function,
+// or with a table of named arguments: function_name, callback, flags and description.
+func registerFunction(L *lua.LState, registered *registration) int {
+	var name, callback, flags, description lua.LValue = lua.LNil, lua.LNil, lua.LNil, lua.LNil
+
+	switch L.GetTop() {
+	case 1:
+		args, ok := L.Get(1).(*lua.LTable)
+		if !ok {
+			L.RaiseError("calling redis.register_function with a single argument is only applicable to Lua table (representing named arguments).")
+			return 0
+		}
+
+		unknown := false
+		args.ForEach(func(k lua.LValue, v lua.LValue) {
+			switch k.String() {
+			case "function_name":
+				name = v
+			case "callback":
+				callback = v
+			case "flags":
+				flags = v
+			case "description":
+				description = v
+			default:
+				unknown = true
+			}
+		})
+		if unknown {
+			L.RaiseError("unknown argument given to redis.register_function")
+			return 0
+		}
+	case 2:
+		name, callback = L.Get(1), L.Get(2)
+	default:
+		L.RaiseError("wrong number of arguments to redis.register_function")
+		return 0
+	}
+
+	fnName, ok := name.(lua.LString)
+	if !ok {
+		L.RaiseError("function_name argument given to redis.register_function must be a string")
+		return 0
+	}
+	if !isValidName(string(fnName)) {
+		L.RaiseError("Function names can only contain letters, numbers, or underscores(_) and must be at least one character long")
+		return 0
+	}
+	if _, exists := registered.functions[string(fnName)]; exists {
+		L.RaiseError("Function already exists in the library")
+		return 0
+	}
+
+	fn, ok := callback.(*lua.LFunction)
+	if !ok {
+		L.RaiseError("callback argument given to redis.register_function must be a function")
+		return 0
+	}
+
+	function := &Function{Name: string(fnName)}
+
+	switch desc := description.(type) {
+	case *lua.LNilType:
+	case lua.LString:
+		function.Description = string(desc)
+	default:
+		L.RaiseError("description argument given to redis.register_function must be a string")
+		return 0
+	}
+
+	switch fl := flags.(type) {
+	case *lua.LNilType:
+	case *lua.LTable:
+		for i := 1; i <= fl.Len(); i++ {
+			flag, ok := fl.RawGetInt(i).(lua.LString)
+			if !ok || !functionFlags[string(flag)] {
+				L.RaiseError("unknown flag given")
+				return 0
+			}
+			function.Flags = append(function.Flags, string(flag))
+		}
+	default:
+		L.RaiseError("flags argument to redis.register_function must be a table representing function flags")
+		return 0
+	}
+
+	registered.functions[function.Name] = function
+	registered.callbacks[function.Name] = fn
+	return 0
+}
@@ -0,0 +1,131 @@
+package scripting_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+)
+
+const library = `#!lua name=mylib
+redis.register_function('echo', function(keys, args) return redis.call('ECHO', keys[1], args[1]) end)
+redis.register_function{
+	function_name = 'ro',
+	callback = function() return 1 end,
+	flags = {'no-writes'},
+	description = 'read only',
+}`
+
+var _ = Describe("Functions", func() {
+	BeforeEach(func() {
+		scripts = scripting.NewScripts()
+	})
+
+	It("should register the functions of the loaded library", func() {
+		name, err := scripts.LoadLibrary(library, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("mylib"))
+
+		libraries := scripts.Libraries("")
+		Expect(libraries).To(HaveLen(1))
+		Expect(libraries[0].Engine).To(Equal(scripting.LuaEngine))
+
+		functions := libraries[0].SortedFunctions()
+		Expect(functions).To(HaveLen(2))
+		Expect(functions[0].Name).To(Equal("echo"))
+		Expect(functions[1].Name).To(Equal("ro"))
+		Expect(functions[1].Description).To(Equal("read only"))
+		Expect(functions[1].HasFlag(scripting.FlagNoWrites)).To(BeTrue())
+		Expect(functions[1].Library).To(Equal("mylib"))
+	})
+
+	It("should call the function with its keys and args", func() {
+		_, err := scripts.LoadLibrary(library, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		reply, err := scripts.CallFunction("echo", []string{"key"}, []string{"arg"}, client.NewFakeClient(), echo)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(reply)).To(Equal("*3\r\n$4\r\nECHO\r\n$3\r\nkey\r\n$3\r\narg\r\n"))
+	})
+
+	It("should only replace an existing library if asked to", func() {
+		_, err := scripts.LoadLibrary(library, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = scripts.LoadLibrary(library, false)
+		Expect(err).To(MatchError("ERR Library 'mylib' already exists"))
+
+		_, err = scripts.LoadLibrary(library, true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should not allow libraries to register functions of other libraries", func() {
+		_, err := scripts.LoadLibrary(library, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = scripts.LoadLibrary("#!lua name=other\nredis.register_function('echo', function() end)", false)
+		Expect(err).To(MatchError("ERR Function echo already exists"))
+	})
+
+	DescribeTable("rejecting invalid libraries",
+		func(code string, expected string) {
+			_, err := scripts.LoadLibrary(code, false)
+			Expect(err).To(MatchError(HavePrefix(expected)))
+			Expect(scripts.Libraries("")).To(BeEmpty())
+		},
+		Entry("without metadata", "return 1", "ERR Missing library metadata"),
+		Entry("with an unknown engine", "#!js name=lib\n", "ERR Engine 'js' not found"),
+		Entry("without a name", "#!lua\n", "ERR Library name was not given"),
+		Entry("with an invalid name", "#!lua name=my-lib\n", "ERR Library names can only contain"),
+		Entry("without functions", "#!lua name=lib\nreturn 1", "ERR No functions registered"),
+		Entry("calling commands while loading", "#!lua name=lib\nredis.call('PING')", "ERR Error registering functions"),
+		Entry("with unknown flags", "#!lua name=lib\nredis.register_function{function_name='f', callback=function() end, flags={'bad'}}", "ERR Error registering functions"),
+		Entry("running past the load timeout", "#!lua name=lib\nwhile true do end", "ERR FUNCTION LOAD timeout"),
+	)
+
+	It("should delete the library along with its functions", func() {
+		_, err := scripts.LoadLibrary(library, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(scripts.DeleteLibrary("mylib")).To(Succeed())
+
+		Expect(scripts.GetFunction("echo")).To(BeNil())
+		Expect(scripts.DeleteLibrary("mylib")).To(MatchError("ERR Library not found"))
+	})
+
+	Describe("dumping and restoring the libraries", func() {
+		var payload []byte
+
+		BeforeEach(func() {
+			_, err := scripts.LoadLibrary(library, false)
+			Expect(err).ToNot(HaveOccurred())
+			payload = scripts.DumpFunctions()
+		})
+
+		It("should restore the dumped libraries", func() {
+			scripts.FlushFunctions()
+
+			Expect(scripts.RestoreFunctions(payload, scripting.RestoreAppend)).To(Succeed())
+
+			Expect(scripts.GetFunction("echo")).ToNot(BeNil())
+		})
+
+		It("should follow the restore policy for existing libraries", func() {
+			Expect(scripts.RestoreFunctions(payload, scripting.RestoreAppend)).To(MatchError("ERR Library 'mylib' already exists"))
+			Expect(scripts.RestoreFunctions(payload, scripting.RestoreReplace)).To(Succeed())
+
+			_, err := scripts.LoadLibrary("#!lua name=other\nredis.register_function('other', function() end)", false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(scripts.RestoreFunctions(payload, scripting.RestoreFlush)).To(Succeed())
+			Expect(scripts.GetFunction("other")).To(BeNil())
+		})
+
+		It("should reject corrupted payloads", func() {
+			payload[len(payload)-1]++
+
+			Expect(scripts.RestoreFunctions(payload, scripting.RestoreAppend)).To(MatchError("ERR payload version or checksum are wrong"))
+		})
+	})
+})
@@ -0,0 +1,288 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// log levels accepted by redis.log.
+const (
+	logDebug = iota
+	logVerbose
+	logNotice
+	logWarning
+)
+
+// executes the compiled script in a fresh Lua state and returns its RESP-encoded reply.
+// the commands called by the script are sent to the calls channel to be run.
+func execute(ctx context.Context, proto *lua.FunctionProto, keys []string, args []string, calls chan<- callRequest) ([]byte, error) {
+	L := newLuaState(calls)
+	defer L.Close()
+	L.SetContext(ctx)
+
+	L.SetGlobal("KEYS", toLuaArray(L, keys))
+	L.SetGlobal("ARGV", toLuaArray(L, args))
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err := L.PCall(0, 1, nil); err != nil {
+		return nil, luaErrorToError(err)
+	}
+
+	ret := L.Get(-1)
+	L.Pop(1)
+	return luaToResp(ret), nil
+}
+
+// creates a Lua state with the sandboxed standard libraries and the redis library.
+// redis.call and redis.pcall are only available if a calls channel is given.
+func newLuaState(calls chan<- callRequest) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// scripts must not be able to reach the filesystem.
+	L.SetGlobal("dofile", lua.LNil)
+	L.SetGlobal("loadfile", lua.LNil)
+
+	redis := L.NewTable()
+	L.SetFuncs(redis, map[string]lua.LGFunction{
+		"error_reply":  redisErrorReply,
+		"status_reply": redisStatusReply,
+		"sha1hex":      redisSha1Hex,
+		"log":          redisLog,
+	})
+	// commands can't be called while loading function libraries, which is done without a calls channel.
+	if calls != nil {
+		L.SetFuncs(redis, map[string]lua.LGFunction{
+			"call": func(L *lua.LState) int {
+				return redisCall(L, calls, true)
+			},
+			"pcall": func(L *lua.LState) int {
+				return redisCall(L, calls, false)
+			},
+		})
+	}
+	redis.RawSetString("LOG_DEBUG", lua.LNumber(logDebug))
+	redis.RawSetString("LOG_VERBOSE", lua.LNumber(logVerbose))
+	redis.RawSetString("LOG_NOTICE", lua.LNumber(logNotice))
+	redis.RawSetString("LOG_WARNING", lua.LNumber(logWarning))
+	L.SetGlobal("redis", redis)
+
+	return L
+}
+
+// implements redis.call and redis.pcall. The command is sent over to be run on the main goroutine.
+// redis.call raises the errors returned by the command, while redis.pcall returns them as error tables.
+func redisCall(L *lua.LState, calls chan<- callRequest, raiseErrors bool) int {
+	if L.GetTop() == 0 {
+		return raiseOrReturn(L, "ERR Please specify at least one argument for this redis lib call", raiseErrors)
+	}
+
+	argv := make([]string, 0, L.GetTop())
+	for i := 1; i <= L.GetTop(); i++ {
+		switch arg := L.Get(i).(type) {
+		case lua.LString:
+			argv = append(argv, string(arg))
+		case lua.LNumber:
+			argv = append(argv, formatLuaNumber(arg))
+		default:
+			return raiseOrReturn(L, "ERR Lua redis lib command arguments must be strings or integers", raiseErrors)
+		}
+	}
+
+	reply := make(chan callReply)
+	calls <- callRequest{
+		cmd:   strings.ToUpper(argv[0]),
+		args:  argv[1:],
+		reply: reply,
+	}
+	result := <-reply
+
+	if result.err != nil {
+		return raiseOrReturn(L, result.err.Error(), raiseErrors)
+	}
+
+	value, _, err := resp.DecodeTyped(result.response, 0)
+	if err != nil {
+		return raiseOrReturn(L, "ERR "+err.Error(), raiseErrors)
+	}
+
+	if value.Type == resp.RespSimpleErrorIdentifier && raiseErrors {
+		L.Error(errorTable(L, value.Str), 0)
+		return 0
+	}
+
+	L.Push(respToLua(L, value))
+	return 1
+}
+
+// raises the error if raiseErrors is set, else returns it as an error table.
+func raiseOrReturn(L *lua.LState, message string, raiseErrors bool) int {
+	if raiseErrors {
+		L.Error(errorTable(L, message), 0)
+		return 0
+	}
+	L.Push(errorTable(L, message))
+	return 1
+}
+
+// implements redis.error_reply, which returns an error table that gets converted into an error reply.
+func redisErrorReply(L *lua.LState) int {
+	L.Push(errorTable(L, strings.TrimPrefix(L.CheckString(1), "-")))
+	return 1
+}
+
+// implements redis.status_reply, which returns a status table that gets converted into a simple string reply.
+func redisStatusReply(L *lua.LState) int {
+	t := L.NewTable()
+	t.RawSetString("ok", lua.LString(L.CheckString(1)))
+	L.Push(t)
+	return 1
+}
+
+// implements redis.sha1hex.
+func redisSha1Hex(L *lua.LState) int {
+	L.Push(lua.LString(Sha1Hex(L.CheckString(1))))
+	return 1
+}
+
+// implements redis.log, which writes the message to the server's log.
+func redisLog(L *lua.LState) int {
+	level := L.CheckInt(1)
+	if level < logDebug || level > logWarning {
+		L.RaiseError("Invalid debug level.")
+		return 0
+	}
+
+	parts := make([]string, 0, L.GetTop()-1)
+	for i := 2; i <= L.GetTop(); i++ {
+		parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+	}
+	log.Println(strings.Join(parts, " "))
+	return 0
+}
+
+func errorTable(L *lua.LState, message string) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("err", lua.LString(message))
+	return t
+}
+
+func toLuaArray(L *lua.LState, items []string) *lua.LTable {
+	t := L.CreateTable(len(items), 0)
+	for _, item := range items {
+		t.Append(lua.LString(item))
+	}
+	return t
+}
+
+// Lua numbers are floats, but commands expect integers wherever possible.
+func formatLuaNumber(n lua.LNumber) string {
+	f := float64(n)
+	if f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', 17, 64)
+}
+
+// converts the reply of a command into a Lua value, following the same rules as Redis.
+//   - integers become numbers and bulk strings become strings.
+//   - simple strings become {ok=...} tables and errors become {err=...} tables.
+//   - arrays become Lua arrays.
+//   - nulls become false.
+func respToLua(L *lua.LState, value resp.TypedValue) lua.LValue {
+	if value.IsNull {
+		return lua.LFalse
+	}
+
+	switch value.Type {
+	case resp.RespIntegerIdentifier:
+		return lua.LNumber(value.Int)
+	case resp.RespBulkStringIdentifier:
+		return lua.LString(value.Str)
+	case resp.RespSimpleStringIdentifier:
+		t := L.NewTable()
+		t.RawSetString("ok", lua.LString(value.Str))
+		return t
+	case resp.RespSimpleErrorIdentifier:
+		return errorTable(L, value.Str)
+	default:
+		t := L.CreateTable(len(value.Items), 0)
+		for _, item := range value.Items {
+			t.Append(respToLua(L, item))
+		}
+		return t
+	}
+}
+
+// converts the value returned by a script into a RESP reply, following the same rules as Redis.
+//   - numbers become integers, truncating the fractional part.
+//   - strings become bulk strings.
+//   - true becomes the integer 1, while false and nil become null.
+//   - {ok=...} tables become simple strings and {err=...} tables become errors.
+//   - other tables become arrays, up to their first nil.
+func luaToResp(value lua.LValue) []byte {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return resp.Encode(int64(v), false)
+	case lua.LString:
+		return resp.Encode(string(v), false)
+	case lua.LBool:
+		if v {
+			return resp.Encode(1, false)
+		}
+		return resp.NullBulkString
+	case *lua.LTable:
+		if errMsg, ok := v.RawGetString("err").(lua.LString); ok {
+			return resp.Encode(errors.New(string(errMsg)), false)
+		}
+		if status, ok := v.RawGetString("ok").(lua.LString); ok {
+			return resp.Encode(string(status), true)
+		}
+		items := make([][]byte, 0, v.Len())
+		for i := 1; ; i++ {
+			item := v.RawGetInt(i)
+			if item == lua.LNil {
+				break
+			}
+			items = append(items, luaToResp(item))
+		}
+		return resp.EncodeRawArray(items)
+	default:
+		return resp.NullBulkString
+	}
+}
+
+// converts an error raised by a script into the error replied to the client.
+// errors raised with error tables, like the ones raised by redis.call, are replied as is.
+func luaErrorToError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		if t, ok := apiErr.Object.(*lua.LTable); ok {
+			if errMsg, ok := t.RawGetString("err").(lua.LString); ok {
+				return errors.New(string(errMsg))
+			}
+		}
+		return fmt.Errorf("ERR %s", apiErr.Object.String())
+	}
+	return fmt.Errorf("ERR %s", err)
+}
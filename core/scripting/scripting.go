@@ -0,0 +1,253 @@
+package scripting
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Runs a command called by a script through redis.call or redis.pcall.
+// returns the RESP-encoded reply of the command, or the error it failed with.
+type CallHandler func(cmd string, args []string) ([]byte, error)
+
+// A script that was compiled and added to the script cache.
+type cachedScript struct {
+	body  string
+	proto *lua.FunctionProto
+}
+
+// state of the script that is currently running.
+type runningScript struct {
+	client    *client.Client
+	cancel    context.CancelFunc
+	startedAt time.Time
+	// set once the script is past the busy threshold.
+	busy bool
+	// set once the script runs a write command, after which it can't be killed.
+	wroteData bool
+	killed    bool
+}
+
+// Scripts are the cached scripts and the functions of an instance, along with the script
+// or the function that is running.
+type Scripts struct {
+	// compiled scripts, keyed by the SHA1 digest of their bodies.
+	cache map[string]*cachedScript
+
+	registry *functionRegistry
+
+	// the script that is currently running, nil if there is none.
+	running *runningScript
+
+	// Processes the network events while a script runs past the busy threshold, so that
+	// the other clients can be told that the server is busy and the script can be killed.
+	// The given client is the one running the script, which must not be read from.
+	// Set by the server; scripts simply block the instance if it is not set.
+	ProcessEventsWhileBusy func(c *client.Client)
+}
+
+// returns an instance's scripts, without any cached script or function.
+func NewScripts() *Scripts {
+	return &Scripts{
+		cache:    make(map[string]*cachedScript),
+		registry: newFunctionRegistry(),
+	}
+}
+
+var scriptsInstance *Scripts
+
+// returns the scripts of the server.
+func GetScripts() *Scripts {
+	if scriptsInstance == nil {
+		scriptsInstance = NewScripts()
+	}
+
+	return scriptsInstance
+}
+
+// a command called by a script, waiting to be run on the main goroutine.
+type callRequest struct {
+	cmd   string
+	args  []string
+	reply chan callReply
+}
+
+type callReply struct {
+	response []byte
+	err      error
+}
+
+// returns the hex-encoded SHA1 digest of the script body, which identifies the script in the cache.
+func Sha1Hex(body string) string {
+	digest := sha1.Sum([]byte(body))
+	return hex.EncodeToString(digest[:])
+}
+
+// compiles the script and adds it to the script cache, if it isn't cached already.
+// returns the SHA1 digest of the script.
+func (s *Scripts) Load(body string) (string, error) {
+	sha := Sha1Hex(body)
+	if _, exists := s.cache[sha]; exists {
+		return sha, nil
+	}
+
+	proto, err := compile(body, "user_script")
+	if err != nil {
+		return "", fmt.Errorf("ERR Error compiling script (new function): %s", err)
+	}
+
+	s.cache[sha] = &cachedScript{
+		body:  body,
+		proto: proto,
+	}
+	return sha, nil
+}
+
+// returns true if the script with the given SHA1 digest is in the script cache.
+func (s *Scripts) Exists(sha string) bool {
+	_, exists := s.cache[strings.ToLower(sha)]
+	return exists
+}
+
+// removes all the scripts from the script cache.
+func (s *Scripts) Flush() {
+	s.cache = make(map[string]*cachedScript)
+}
+
+// returns true if a script is running past the busy threshold. Only the commands
+// that can stop it should be accepted while the server is busy.
+func (s *Scripts) IsBusy() bool {
+	return s.running != nil && s.running.busy
+}
+
+// marks the running script as having modified the dataset, after which it can't be killed.
+func (s *Scripts) MarkWrite() {
+	if s.running != nil {
+		s.running.wroteData = true
+	}
+}
+
+// Kill stops the running script, unless it has already modified the dataset.
+func (s *Scripts) Kill() error {
+	if s.running == nil {
+		return errors.New("NOTBUSY No scripts in execution right now.")
+	}
+
+	if s.running.wroteData {
+		return errors.New("UNKILLABLE Sorry the script already executed write commands against the dataset. " +
+			"You can either wait the script termination or kill the server in a hard way using the SHUTDOWN NOSAVE command.")
+	}
+
+	s.running.killed = true
+	s.running.cancel()
+	return nil
+}
+
+// Terminate stops the running script even if it has already modified the dataset, eg. when the
+// server shuts down.
+func (s *Scripts) Terminate() {
+	if s.running != nil {
+		s.running.killed = true
+		s.running.cancel()
+	}
+}
+
+// Run runs the cached script with the given SHA1 digest on behalf of the client, with the
+// KEYS and ARGV tables set to keys and args. The commands called by the script are run with
+// the call handler. Returns the RESP-encoded reply of the script.
+//
+// The script runs on its own goroutine, but every command it calls is run on the calling
+// goroutine, so that the dataset is only ever accessed from one goroutine. If the script
+// runs for longer than config.BusyReplyThreshold, the network events are processed while
+// waiting for it, so that it can be stopped with SCRIPT KILL.
+func (s *Scripts) Run(sha string, keys []string, args []string, c *client.Client, call CallHandler) ([]byte, error) {
+	script, exists := s.cache[strings.ToLower(sha)]
+	if !exists {
+		return nil, errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	}
+
+	return s.run(sha, c, call, func(ctx context.Context, calls chan<- callRequest) ([]byte, error) {
+		return execute(ctx, script.proto, keys, args, calls)
+	})
+}
+
+// runs the Lua code with exec on behalf of the client, see Run.
+// name identifies the running code in the errors raised by it.
+func (s *Scripts) run(name string, c *client.Client, call CallHandler, exec func(context.Context, chan<- callRequest) ([]byte, error)) ([]byte, error) {
+	if s.running != nil {
+		return nil, errors.New("ERR scripts can't be run from within another script")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.running = &runningScript{
+		client:    c,
+		cancel:    cancel,
+		startedAt: time.Now(),
+	}
+	defer func() {
+		s.running = nil
+	}()
+
+	calls := make(chan callRequest)
+	done := make(chan callReply, 1)
+
+	go func() {
+		response, err := exec(ctx, calls)
+		done <- callReply{response: response, err: err}
+	}()
+
+	busyTimer := time.NewTimer(config.BusyReplyThreshold)
+	defer busyTimer.Stop()
+
+	for {
+		if s.running.busy && s.ProcessEventsWhileBusy != nil {
+			s.ProcessEventsWhileBusy(c)
+		}
+
+		var timeout <-chan time.Time = busyTimer.C
+		if s.running.busy {
+			timeout = time.After(time.Millisecond)
+		}
+
+		select {
+		case req := <-calls:
+			response, err := call(req.cmd, req.args)
+			req.reply <- callReply{response: response, err: err}
+		case result := <-done:
+			if s.running.killed {
+				return nil, errors.New("ERR Script killed by user with SCRIPT KILL...")
+			}
+			if result.err != nil {
+				return nil, fmt.Errorf("%s script: %s", result.err, name)
+			}
+			return result.response, nil
+		case <-timeout:
+			if !s.running.busy {
+				s.running.busy = true
+				log.Printf("Slow script detected: still in execution after %d milliseconds. "+
+					"You can try killing the script using the SCRIPT KILL command.\n", time.Since(s.running.startedAt).Milliseconds())
+			}
+		}
+	}
+}
+
+// compiles the Lua source into a function prototype.
+func compile(body string, name string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(body), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
@@ -0,0 +1,125 @@
+package scripting_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+)
+
+func TestScripting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scripting Suite")
+}
+
+// the scripts the specs load and run.
+var scripts *scripting.Scripts
+
+// loads and runs the script, with the commands it calls being answered by the call handler.
+func run(body string, keys []string, args []string, call scripting.CallHandler) (string, error) {
+	sha, err := scripts.Load(body)
+	if err != nil {
+		return "", err
+	}
+	reply, err := scripts.Run(sha, keys, args, client.NewFakeClient(), call)
+	return string(reply), err
+}
+
+// a call handler that echoes the called command back as an array.
+func echo(cmd string, args []string) ([]byte, error) {
+	return resp.Encode(append([]string{cmd}, args...), false), nil
+}
+
+var _ = Describe("Scripting", func() {
+	BeforeEach(func() {
+		scripts = scripting.NewScripts()
+	})
+
+	It("should pass KEYS and ARGV to the script", func() {
+		reply, err := run("return {KEYS[1], ARGV[1], ARGV[2]}", []string{"key"}, []string{"a", "b"}, echo)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply).To(Equal("*3\r\n$3\r\nkey\r\n$1\r\na\r\n$1\r\nb\r\n"))
+	})
+
+	DescribeTable("converting the values returned by scripts into replies",
+		func(body string, expected string) {
+			reply, err := run(body, nil, nil, echo)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reply).To(Equal(expected))
+		},
+		Entry("numbers are truncated into integers", "return 3.99", ":3\r\n"),
+		Entry("strings become bulk strings", "return 'hello'", "$5\r\nhello\r\n"),
+		Entry("true becomes 1", "return true", ":1\r\n"),
+		Entry("false becomes null", "return false", "$-1\r\n"),
+		Entry("nil becomes null", "return nil", "$-1\r\n"),
+		Entry("arrays stop at the first nil", "return {1, 2, nil, 4}", "*2\r\n:1\r\n:2\r\n"),
+		Entry("status tables become simple strings", "return redis.status_reply('FINE')", "+FINE\r\n"),
+		Entry("error tables become errors", "return redis.error_reply('ERR bad')", "-ERR bad\r\n"),
+	)
+
+	It("should convert the replies of the called commands into Lua values", func() {
+		call := func(cmd string, args []string) ([]byte, error) {
+			return []byte("*4\r\n:1\r\n$3\r\nbar\r\n+OK\r\n$-1\r\n"), nil
+		}
+
+		reply, err := run("local r = redis.call('ANY') return {type(r[1]), r[2], r[3].ok, tostring(r[4])}", nil, nil, call)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply).To(Equal("*4\r\n$6\r\nnumber\r\n$3\r\nbar\r\n$2\r\nOK\r\n$5\r\nfalse\r\n"))
+	})
+
+	It("should upper case the called command and stringify numeric arguments", func() {
+		reply, err := run("return redis.call('set', 'key', 10)", nil, nil, echo)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply).To(Equal("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$2\r\n10\r\n"))
+	})
+
+	It("should raise command errors from redis.call and return them from redis.pcall", func() {
+		call := func(cmd string, args []string) ([]byte, error) {
+			return nil, errors.New("ERR failed")
+		}
+
+		_, err := run("return redis.call('ANY')", nil, nil, call)
+		Expect(err).To(MatchError(HavePrefix("ERR failed script:")))
+
+		reply, err := run("return redis.pcall('ANY')", nil, nil, call)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reply).To(Equal("-ERR failed\r\n"))
+	})
+
+	It("should report compilation errors", func() {
+		_, err := scripts.Load("return (")
+		Expect(err).To(MatchError(HavePrefix("ERR Error compiling script")))
+	})
+
+	It("should only run cached scripts by their SHA1 digest", func() {
+		sha, err := scripts.Load("return 1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sha).To(Equal("e0e1f9fabfc9d4800c877a703b823ac0578ff8db"))
+		Expect(scripts.Exists(sha)).To(BeTrue())
+
+		scripts.Flush()
+
+		Expect(scripts.Exists(sha)).To(BeFalse())
+		_, err = scripts.Run(sha, nil, nil, client.NewFakeClient(), echo)
+		Expect(err).To(MatchError(HavePrefix("NOSCRIPT")))
+	})
+
+	It("should keep the scripts of every instance apart", func() {
+		sha, err := scripts.Load("return 1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(scripting.NewScripts().Exists(sha)).To(BeFalse())
+		Expect(scripting.GetScripts().Exists(sha)).To(BeFalse())
+	})
+
+	It("should reply NOTBUSY to SCRIPT KILL when no script is running", func() {
+		Expect(scripts.Kill()).To(MatchError(HavePrefix("NOTBUSY")))
+	})
+})
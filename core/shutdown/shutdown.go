@@ -0,0 +1,50 @@
+package shutdown
+
+import (
+	"errors"
+
+	"github.com/shashwatrathod/redis-internals/core/client"
+)
+
+// options of a shutdown, set with the arguments of SHUTDOWN.
+type Options struct {
+	// SAVE and NOSAVE, which ask for the dataset to be saved before exiting, or not to be.
+	Save   bool
+	NoSave bool
+
+	// set with NOW, to exit without waiting for the replies of the clients to be written.
+	Now bool
+
+	// set with FORCE, to exit even if the dataset can't be saved.
+	Force bool
+}
+
+// a shutdown asked for with SHUTDOWN or by a signal, which the event loop carries out.
+type Request struct {
+	Options
+
+	// the client that ran SHUTDOWN, which is told if the shutdown is aborted. nil for the signals.
+	Client *client.Client
+}
+
+// the shutdown in progress, if any.
+var pending *Request
+
+// asks the server to shut down. a shutdown that is already in progress takes the new options.
+func Start(options Options, c *client.Client) {
+	pending = &Request{Options: options, Client: c}
+}
+
+// returns the shutdown in progress, or nil if there's none.
+func Pending() *Request {
+	return pending
+}
+
+// Abort cancels the shutdown in progress, eg. while it waits for the replies of the clients to be written.
+func Abort() error {
+	if pending == nil {
+		return errors.New("ERR No shutdown in progress.")
+	}
+	pending = nil
+	return nil
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandStats are the statistics of a command, as reported by INFO commandstats and latencystats.
+type CommandStats struct {
+	Name string
+
+	Calls int64
+	// total time spent running the command, in microseconds.
+	Usec int64
+	// calls refused before the command ran, eg. while the server is busy running a script.
+	RejectedCalls int64
+	// calls that ran and replied with an error.
+	FailedCalls int64
+
+	Latency Histogram
+}
+
+// returns the average time spent per call, in microseconds.
+func (c *CommandStats) UsecPerCall() float64 {
+	if c.Calls == 0 {
+		return 0
+	}
+	return float64(c.Usec) / float64(c.Calls)
+}
+
+// the statistics of the commands and of the error replies, keyed by the lowercase names of the
+// commands and by the error prefixes (eg. ERR, WRONGTYPE).
+type commandTable struct {
+	mu       sync.Mutex
+	commands map[string]*CommandStats
+	errors   map[string]int64
+}
+
+// returns the stats of the command, creating them on its first call. must hold the lock.
+func (t *commandTable) get(name string) *CommandStats {
+	if t.commands == nil {
+		t.commands = make(map[string]*CommandStats)
+	}
+
+	name = strings.ToLower(name)
+	cmd, exists := t.commands[name]
+	if !exists {
+		cmd = &CommandStats{Name: name}
+		t.commands[name] = cmd
+	}
+	return cmd
+}
+
+// records a call of the command that took the given time, and whether it failed.
+func (s *Stats) RecordCommand(name string, duration time.Duration, failed bool) {
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+
+	usec := duration.Microseconds()
+	cmd := s.commands.get(name)
+	cmd.Calls++
+	cmd.Usec += usec
+	cmd.Latency.Record(uint64(usec))
+	if failed {
+		cmd.FailedCalls++
+	}
+}
+
+// records a call of the command that was refused before it ran.
+func (s *Stats) RecordRejectedCommand(name string) {
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+
+	s.commands.get(name).RejectedCalls++
+}
+
+// records an error reply sent to a client, by the prefix of the error, eg. ERR or WRONGTYPE.
+func (s *Stats) RecordErrorReply(err error) {
+	s.TotalErrorReplies.Add(1)
+
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+
+	if s.commands.errors == nil {
+		s.commands.errors = make(map[string]int64)
+	}
+	s.commands.errors[errorPrefix(err.Error())]++
+}
+
+// returns the code an error message starts with, eg. ERR or WRONGTYPE. defaults to ERR.
+func errorPrefix(message string) string {
+	prefix, _, _ := strings.Cut(message, " ")
+	if prefix == "" || strings.ToUpper(prefix) != prefix {
+		return "ERR"
+	}
+	return prefix
+}
+
+// returns a copy of the stats of the commands that have been called, sorted by name.
+// commands that were never called are left out.
+func (s *Stats) Commands(names ...string) []CommandStats {
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[strings.ToLower(name)] = true
+	}
+
+	commands := make([]CommandStats, 0, len(s.commands.commands))
+	for name, cmd := range s.commands.commands {
+		if len(selected) == 0 || selected[name] {
+			commands = append(commands, *cmd)
+		}
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// returns the number of error replies by their prefixes, along with the prefixes in sorted order.
+func (s *Stats) ErrorReplies() ([]string, map[string]int64) {
+	s.commands.mu.Lock()
+	defer s.commands.mu.Unlock()
+
+	prefixes := make([]string, 0, len(s.commands.errors))
+	counts := make(map[string]int64, len(s.commands.errors))
+	for prefix, count := range s.commands.errors {
+		prefixes = append(prefixes, prefix)
+		counts[prefix] = count
+	}
+
+	sort.Strings(prefixes)
+	return prefixes, counts
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// every power of two range of latencies is split into 2^histogramSubBucketBits linear
+	// buckets, which keeps the percentiles accurate to within ~6%.
+	histogramSubBucketBits = 4
+	histogramSubBuckets    = 1 << histogramSubBucketBits
+
+	histogramBuckets = (64 - histogramSubBucketBits + 1) * histogramSubBuckets
+)
+
+// Histogram counts latencies, in microseconds, into log-linear buckets, like the HdrHistogram
+// Redis uses for its latency percentiles. It takes a fixed amount of memory regardless of the
+// number of latencies recorded. Not safe for concurrent use.
+type Histogram struct {
+	counts [histogramBuckets]int64
+	total  int64
+}
+
+// returns the bucket the value is counted in.
+func histogramBucket(usec uint64) int {
+	if usec < histogramSubBuckets {
+		return int(usec)
+	}
+
+	exp := bits.Len64(usec) - 1
+	sub := (usec >> (exp - histogramSubBucketBits)) & (histogramSubBuckets - 1)
+	return (exp-histogramSubBucketBits+1)*histogramSubBuckets + int(sub)
+}
+
+// returns the lowest and the highest values counted in the bucket.
+func histogramBucketRange(bucket int) (uint64, uint64) {
+	if bucket < histogramSubBuckets {
+		return uint64(bucket), uint64(bucket)
+	}
+
+	exp := bucket/histogramSubBuckets + histogramSubBucketBits - 1
+	sub := uint64(bucket % histogramSubBuckets)
+	lowest := (histogramSubBuckets + sub) << (exp - histogramSubBucketBits)
+	return lowest, lowest + (1 << (exp - histogramSubBucketBits)) - 1
+}
+
+// counts a latency of usec microseconds.
+func (h *Histogram) Record(usec uint64) {
+	h.counts[histogramBucket(usec)]++
+	h.total++
+}
+
+// returns the number of latencies counted.
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// returns the latency below which the given percentage of the latencies fall, eg. 99 for p99.
+// the latency is rounded up to the highest value of its bucket.
+func (h *Histogram) Percentile(percentile float64) uint64 {
+	if h.total == 0 {
+		return 0
+	}
+
+	target := int64(math.Ceil(percentile / 100 * float64(h.total)))
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64 = 0
+	for bucket, count := range h.counts {
+		seen += count
+		if seen >= target {
+			_, highest := histogramBucketRange(bucket)
+			return highest
+		}
+	}
+	return 0
+}
+
+// returns the cumulative counts of the latencies up to every power of two of microseconds,
+// skipping the powers of two without any new latencies, as [upper bound, cumulative count] pairs.
+func (h *Histogram) PowerOfTwoBuckets() [][2]int64 {
+	buckets := make([][2]int64, 0)
+
+	var seen int64 = 0
+	for bucket, count := range h.counts {
+		if count == 0 {
+			continue
+		}
+		seen += count
+
+		lowest, _ := histogramBucketRange(bucket)
+		var bound uint64 = 1
+		for bound < lowest {
+			bound <<= 1
+		}
+
+		if n := len(buckets); n > 0 && buckets[n-1][0] == int64(bound) {
+			buckets[n-1][1] = seen
+		} else {
+			buckets = append(buckets, [2]int64{int64(bound), seen})
+		}
+	}
+	return buckets
+}
+
+// returns the number of latencies up to usec microseconds. a bucket is only counted once its
+// highest value is within the bound, so that no latency above it is, at the cost of leaving out
+// the ones of the bucket straddling it.
+func (h *Histogram) CountUpTo(usec uint64) int64 {
+	var seen int64 = 0
+	for bucket, count := range h.counts {
+		if _, highest := histogramBucketRange(bucket); highest > usec {
+			break
+		}
+		seen += count
+	}
+	return seen
+}
+
+// forgets all the latencies counted.
+func (h *Histogram) Reset() {
+	*h = Histogram{}
+}
@@ -0,0 +1,94 @@
+package stats_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+)
+
+var _ = Describe("Histogram", func() {
+	var h *stats.Histogram
+
+	BeforeEach(func() {
+		h = &stats.Histogram{}
+	})
+
+	It("should report no latencies when empty", func() {
+		Expect(h.Count()).To(BeZero())
+		Expect(h.Percentile(99)).To(BeZero())
+		Expect(h.PowerOfTwoBuckets()).To(BeEmpty())
+	})
+
+	It("should count small latencies exactly", func() {
+		for usec := uint64(1); usec <= 10; usec++ {
+			h.Record(usec)
+		}
+
+		Expect(h.Count()).To(Equal(int64(10)))
+		Expect(h.Percentile(50)).To(Equal(uint64(5)))
+		Expect(h.Percentile(100)).To(Equal(uint64(10)))
+	})
+
+	It("should keep the percentiles of large latencies within the bucket precision", func() {
+		for usec := uint64(1); usec <= 100000; usec++ {
+			h.Record(usec)
+		}
+
+		Expect(float64(h.Percentile(50))).To(BeNumerically("~", 50000, 50000*0.07))
+		Expect(float64(h.Percentile(99))).To(BeNumerically("~", 99000, 99000*0.07))
+		Expect(float64(h.Percentile(99.9))).To(BeNumerically("~", 99900, 99900*0.07))
+	})
+
+	It("should spot the outliers at p99", func() {
+		for i := 0; i < 980; i++ {
+			h.Record(10)
+		}
+		for i := 0; i < 20; i++ {
+			h.Record(5000)
+		}
+
+		Expect(h.Percentile(50)).To(Equal(uint64(10)))
+		Expect(float64(h.Percentile(99))).To(BeNumerically("~", 5000, 5000*0.07))
+	})
+
+	It("should list cumulative counts over powers of two", func() {
+		h.Record(1)
+		h.Record(3)
+		h.Record(4)
+		h.Record(100)
+
+		Expect(h.PowerOfTwoBuckets()).To(Equal([][2]int64{
+			{1, 1},
+			{4, 3},
+			{128, 4},
+		}))
+	})
+
+	It("should count the latencies up to a bound", func() {
+		h.Record(1)
+		h.Record(3)
+		h.Record(4)
+		h.Record(100)
+
+		Expect(h.CountUpTo(0)).To(BeZero())
+		Expect(h.CountUpTo(2)).To(Equal(int64(1)))
+		Expect(h.CountUpTo(4)).To(Equal(int64(3)))
+		Expect(h.CountUpTo(1 << 20)).To(Equal(int64(4)))
+	})
+
+	It("should not count the buckets reaching past the bound", func() {
+		// counted in the bucket of 32 and 33.
+		h.Record(33)
+		h.Record(32)
+
+		Expect(h.CountUpTo(32)).To(BeZero())
+		Expect(h.CountUpTo(33)).To(Equal(int64(2)))
+	})
+
+	It("should forget the latencies when reset", func() {
+		h.Record(10)
+		h.Reset()
+
+		Expect(h.Count()).To(BeZero())
+	})
+})
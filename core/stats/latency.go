@@ -0,0 +1,167 @@
+package stats
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// number of latency spikes kept per event.
+const latencyHistoryLen = 160
+
+// events monitored by the latency monitor. Nothing is ever forked to take a snapshot of the
+// dataset, so unlike Redis there's no fork event.
+const (
+	// a command that took longer than the threshold.
+	LatencyEventCommand = "command"
+	// a run of the active expiry of a database.
+	LatencyEventExpireCycle = "expire-cycle"
+	// a run of the eviction of a database, to make room for new keys.
+	LatencyEventEvictionCycle = "eviction-cycle"
+)
+
+// a latency spike of an event, in milliseconds, at the given unix time in seconds.
+type LatencySample struct {
+	Time    int64
+	Latency int64
+}
+
+// LatencyEvent is the history of the latency spikes of an event.
+type LatencyEvent struct {
+	Name string
+	// the latest spikes, the oldest first.
+	History []LatencySample
+	// the highest latency ever seen for the event, in milliseconds.
+	Max int64
+}
+
+// returns the most recent spike of the event.
+func (e *LatencyEvent) Latest() LatencySample {
+	return e.History[len(e.History)-1]
+}
+
+// LatencyMonitor records the latency spikes of the events that took at least
+// config.LatencyMonitorThreshold, like Redis's latency monitor. It is disabled
+// when the threshold is 0.
+type LatencyMonitor struct {
+	mu     sync.Mutex
+	events map[string]*LatencyEvent
+
+	// the minimum latency of the recorded spikes.
+	threshold *time.Duration
+}
+
+// returns a latency monitor that follows the config, eg. the one of the server.
+func NewLatencyMonitor() *LatencyMonitor {
+	return &LatencyMonitor{
+		events:    make(map[string]*LatencyEvent),
+		threshold: &config.LatencyMonitorThreshold,
+	}
+}
+
+// returns a latency monitor with the given threshold instead of the one of the config, eg. the
+// one of an embedded engine.
+func NewLatencyMonitorWith(threshold time.Duration) *LatencyMonitor {
+	return &LatencyMonitor{
+		events:    make(map[string]*LatencyEvent),
+		threshold: &threshold,
+	}
+}
+
+var latencyMonitorInstance *LatencyMonitor
+
+// returns the latency monitor of the server.
+func GetLatencyMonitor() *LatencyMonitor {
+	if latencyMonitorInstance == nil {
+		latencyMonitorInstance = NewLatencyMonitor()
+	}
+
+	return latencyMonitorInstance
+}
+
+// records that the event took the given time, if it is above the threshold. spikes within
+// the same second are merged, keeping the highest latency.
+func (m *LatencyMonitor) Sample(event string, duration time.Duration) {
+	threshold := *m.threshold
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, exists := m.events[event]
+	if !exists {
+		e = &LatencyEvent{Name: event}
+		m.events[event] = e
+	}
+
+	sample := LatencySample{Time: time.Now().Unix(), Latency: duration.Milliseconds()}
+	e.Max = max(e.Max, sample.Latency)
+
+	if n := len(e.History); n > 0 && e.History[n-1].Time == sample.Time {
+		e.History[n-1].Latency = max(e.History[n-1].Latency, sample.Latency)
+		return
+	}
+
+	e.History = append(e.History, sample)
+	if len(e.History) > latencyHistoryLen {
+		e.History = e.History[1:]
+	}
+}
+
+// returns a copy of the events with latency spikes, sorted by name.
+func (m *LatencyMonitor) Events() []LatencyEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make([]LatencyEvent, 0, len(m.events))
+	for _, e := range m.events {
+		events = append(events, LatencyEvent{
+			Name:    e.Name,
+			History: append([]LatencySample(nil), e.History...),
+			Max:     e.Max,
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+	return events
+}
+
+// returns a copy of the latency spikes of the event, the oldest first.
+func (m *LatencyMonitor) History(event string) []LatencySample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, exists := m.events[event]
+	if !exists {
+		return []LatencySample{}
+	}
+	return append([]LatencySample(nil), e.History...)
+}
+
+// forgets the latency spikes of the given events, or of all of them if none are given.
+// returns the number of events forgotten.
+func (m *LatencyMonitor) Reset(events ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(events) == 0 {
+		n := len(m.events)
+		clear(m.events)
+		return n
+	}
+
+	n := 0
+	for _, event := range events {
+		if _, exists := m.events[event]; exists {
+			delete(m.events, event)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+const (
+	// arguments past this many are summarised in the last argument of an entry.
+	slowlogEntryMaxArgc = 32
+
+	// arguments longer than this many bytes are truncated.
+	slowlogEntryMaxString = 128
+)
+
+// SlowlogEntry is a command that ran for longer than config.SlowlogLogSlowerThan.
+type SlowlogEntry struct {
+	// unique, monotonically increasing id of the entry.
+	Id int64
+	// unix time, in seconds, the command was run at.
+	Time int64
+	// how long the command ran for, in microseconds.
+	Duration int64
+	// the command and its arguments, truncated.
+	Args []string
+
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLog keeps the latest commands that ran for too long in a ring of config.SlowlogMaxLen
+// entries, overwriting the oldest entry once the ring is full.
+type SlowLog struct {
+	mu sync.Mutex
+
+	// the commands that ran for at least this many microseconds are recorded, none if negative.
+	logSlowerThan *int64
+
+	ring []SlowlogEntry
+	// index of the oldest entry in the ring, and the number of entries.
+	head int
+	len  int
+
+	nextId int64
+}
+
+// returns a slow log that follows the config, eg. the one of the server.
+func NewSlowLog() *SlowLog {
+	return &SlowLog{
+		logSlowerThan: &config.SlowlogLogSlowerThan,
+		ring:          make([]SlowlogEntry, max(config.SlowlogMaxLen, 0)),
+	}
+}
+
+// returns a slow log of maxLen entries, that records the commands that ran for at least
+// logSlowerThan microseconds instead of following the config, eg. the one of an embedded engine.
+func NewSlowLogWith(maxLen int, logSlowerThan int64) *SlowLog {
+	return &SlowLog{
+		logSlowerThan: &logSlowerThan,
+		ring:          make([]SlowlogEntry, max(maxLen, 0)),
+	}
+}
+
+var slowLogInstance *SlowLog
+
+// returns the slow log of the server.
+func GetSlowLog() *SlowLog {
+	if slowLogInstance == nil {
+		slowLogInstance = NewSlowLog()
+	}
+
+	return slowLogInstance
+}
+
+// records the command if it ran for longer than the threshold.
+func (l *SlowLog) Record(cmd string, args []string, duration time.Duration, clientAddr string, clientName string) {
+	threshold := *l.logSlowerThan
+	usec := duration.Microseconds()
+	if threshold < 0 || usec < threshold {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.ring) == 0 {
+		return
+	}
+
+	entry := SlowlogEntry{
+		Id:         l.nextId,
+		Time:       time.Now().Unix(),
+		Duration:   usec,
+		Args:       truncateSlowlogArgs(append([]string{cmd}, args...)),
+		ClientAddr: clientAddr,
+		ClientName: clientName,
+	}
+	l.nextId++
+
+	if l.len < len(l.ring) {
+		l.ring[(l.head+l.len)%len(l.ring)] = entry
+		l.len++
+		return
+	}
+
+	// the ring is full, the oldest entry makes room for the new one.
+	l.ring[l.head] = entry
+	l.head = (l.head + 1) % len(l.ring)
+}
+
+// truncates the arguments like Redis does, so that huge commands don't blow up the slow log.
+func truncateSlowlogArgs(args []string) []string {
+	argc := min(len(args), slowlogEntryMaxArgc)
+
+	truncated := make([]string, argc)
+	for i := 0; i < argc; i++ {
+		if i == argc-1 && argc != len(args) {
+			truncated[i] = fmt.Sprintf("... (%d more arguments)", len(args)-argc+1)
+			break
+		}
+
+		arg := args[i]
+		if len(arg) > slowlogEntryMaxString {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:slowlogEntryMaxString], len(arg)-slowlogEntryMaxString)
+		}
+		truncated[i] = arg
+	}
+	return truncated
+}
+
+// returns up to count of the latest entries, the newest first. returns all of them if count is negative.
+func (l *SlowLog) Get(count int) []SlowlogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if count < 0 || count > l.len {
+		count = l.len
+	}
+
+	entries := make([]SlowlogEntry, count)
+	for i := range entries {
+		entries[i] = l.ring[(l.head+l.len-1-i)%len(l.ring)]
+	}
+	return entries
+}
+
+// returns the number of entries in the slow log.
+func (l *SlowLog) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.len
+}
+
+// removes all the entries from the slow log.
+func (l *SlowLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	clear(l.ring)
+	l.head = 0
+	l.len = 0
+}
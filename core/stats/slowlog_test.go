@@ -0,0 +1,110 @@
+package stats_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+)
+
+var _ = Describe("SlowLog", func() {
+	var (
+		slowLog         *stats.SlowLog
+		slow            = 20 * time.Millisecond
+		savedSlowerThan int64
+		savedMaxLen     int
+	)
+
+	BeforeEach(func() {
+		savedSlowerThan, savedMaxLen = config.SlowlogLogSlowerThan, config.SlowlogMaxLen
+		config.SlowlogLogSlowerThan = 10000
+		config.SlowlogMaxLen = 3
+		slowLog = stats.NewSlowLog()
+	})
+
+	AfterEach(func() {
+		config.SlowlogLogSlowerThan, config.SlowlogMaxLen = savedSlowerThan, savedMaxLen
+	})
+
+	It("should only record the commands slower than the threshold", func() {
+		slowLog.Record("GET", []string{"fast"}, time.Millisecond, "", "")
+		slowLog.Record("GET", []string{"slow"}, slow, "127.0.0.1:5000", "worker")
+
+		Expect(slowLog.Len()).To(Equal(1))
+
+		entry := slowLog.Get(-1)[0]
+		Expect(entry.Id).To(Equal(int64(0)))
+		Expect(entry.Duration).To(Equal(int64(20000)))
+		Expect(entry.Args).To(Equal([]string{"GET", "slow"}))
+		Expect(entry.ClientAddr).To(Equal("127.0.0.1:5000"))
+		Expect(entry.ClientName).To(Equal("worker"))
+	})
+
+	It("should keep the latest entries, the newest first", func() {
+		for i := 0; i < 5; i++ {
+			slowLog.Record("GET", []string{fmt.Sprintf("key-%d", i)}, slow, "", "")
+		}
+
+		Expect(slowLog.Len()).To(Equal(3))
+
+		entries := slowLog.Get(-1)
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Id).To(Equal(int64(4)))
+		Expect(entries[2].Id).To(Equal(int64(2)))
+
+		Expect(slowLog.Get(1)).To(HaveLen(1))
+		Expect(slowLog.Get(10)).To(HaveLen(3))
+	})
+
+	It("should truncate long commands", func() {
+		args := make([]string, 40)
+		for i := range args {
+			args[i] = "arg"
+		}
+		args[0] = strings.Repeat("x", 200)
+
+		slowLog.Record("DEL", args, slow, "", "")
+
+		entry := slowLog.Get(1)[0]
+		Expect(entry.Args).To(HaveLen(32))
+		Expect(entry.Args[1]).To(Equal(strings.Repeat("x", 128) + "... (72 more bytes)"))
+		Expect(entry.Args[31]).To(Equal("... (10 more arguments)"))
+	})
+
+	It("should record every command with a threshold of 0, and none with a negative one", func() {
+		config.SlowlogLogSlowerThan = 0
+		slowLog.Record("PING", nil, 0, "", "")
+		Expect(slowLog.Len()).To(Equal(1))
+
+		config.SlowlogLogSlowerThan = -1
+		slowLog.Record("PING", nil, time.Second, "", "")
+		Expect(slowLog.Len()).To(Equal(1))
+	})
+
+	It("should keep its own threshold and length when given them instead of the config", func() {
+		own := stats.NewSlowLogWith(1, 0)
+		own.Record("PING", nil, 0, "", "")
+		own.Record("ECHO", []string{"hi"}, 0, "", "")
+		Expect(own.Get(-1)).To(HaveLen(1))
+		Expect(own.Get(-1)[0].Args).To(Equal([]string{"ECHO", "hi"}))
+
+		config.SlowlogLogSlowerThan = -1
+		own.Record("PING", nil, 0, "", "")
+		Expect(own.Get(-1)[0].Args).To(Equal([]string{"PING"}))
+	})
+
+	It("should forget the entries when reset", func() {
+		slowLog.Record("GET", []string{"key"}, slow, "", "")
+		slowLog.Reset()
+
+		Expect(slowLog.Len()).To(BeZero())
+		Expect(slowLog.Get(-1)).To(BeEmpty())
+
+		slowLog.Record("GET", []string{"key"}, slow, "", "")
+		Expect(slowLog.Get(-1)[0].Id).To(Equal(int64(1)))
+	})
+})
@@ -0,0 +1,199 @@
+package stats
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// number of samples the instantaneous ops/sec is averaged over, and how often they are taken.
+	instantaneousSamples        = 16
+	instantaneousSampleInterval = 100 * time.Millisecond
+
+	// weight of the latest expire cycle in the running estimate of the stale keys.
+	expiredStaleWeight = 0.05
+)
+
+// Stats holds the counters of the server, as reported by INFO. They are updated where the
+// events happen (the store, the command handler, the event loop) and may be read from any
+// goroutine, eg. by a metrics exporter.
+type Stats struct {
+	StartTime time.Time
+
+	// random identifier of this run of the server.
+	RunId string
+
+	ConnectedClients         atomic.Int64
+	TotalConnectionsReceived atomic.Int64
+	RejectedConnections      atomic.Int64
+
+	TotalCommandsProcessed atomic.Int64
+	TotalErrorReplies      atomic.Int64
+
+	// per command statistics, and the error replies by their prefixes.
+	commands commandTable
+
+	ExpiredKeys    atomic.Int64
+	EvictedKeys    atomic.Int64
+	KeyspaceHits   atomic.Int64
+	KeyspaceMisses atomic.Int64
+
+	// expired keys, split into the ones found by the expire cycles (active) and the ones found
+	// on access (passive).
+	ActiveExpiredKeys  atomic.Int64
+	PassiveExpiredKeys atomic.Int64
+
+	// highest memory usage seen so far, in bytes.
+	usedMemoryPeak atomic.Uint64
+
+	// running estimate of the percentage of keys with an expiry that are already expired, as
+	// found by the expire cycles. stored as float64 bits.
+	expiredStalePerc atomic.Uint64
+
+	mu sync.Mutex
+
+	// evicted keys by the names of the eviction strategies that evicted them.
+	evictions map[string]int64
+
+	// the number of commands processed at the last few samples, for instantaneous_ops_per_sec.
+	opsSamples     [instantaneousSamples]float64
+	opsSampleIdx   int
+	lastSampleTime time.Time
+	lastSampleOps  int64
+}
+
+// returns new stats, with the server starting now.
+func NewStats() *Stats {
+	now := time.Now()
+
+	runId := make([]byte, 20)
+	rand.Read(runId)
+
+	return &Stats{
+		StartTime:      now,
+		RunId:          hex.EncodeToString(runId),
+		lastSampleTime: now,
+	}
+}
+
+var statsInstance *Stats
+
+// returns the stats of the server.
+func GetStats() *Stats {
+	if statsInstance == nil {
+		statsInstance = NewStats()
+	}
+
+	return statsInstance
+}
+
+// returns how long the server has been up.
+func (s *Stats) Uptime() time.Duration {
+	return time.Since(s.StartTime)
+}
+
+// records the results of an expire cycle, which found expired of the sampled keys to be expired.
+func (s *Stats) RecordExpireCycle(sampled int, expired int) {
+	if sampled == 0 {
+		return
+	}
+
+	current := float64(expired) / float64(sampled) * 100
+	previous := s.ExpiredStalePerc()
+	s.expiredStalePerc.Store(math.Float64bits(current*expiredStaleWeight + previous*(1-expiredStaleWeight)))
+}
+
+// returns the estimated percentage of the keys with an expiry that are already expired.
+func (s *Stats) ExpiredStalePerc() float64 {
+	return math.Float64frombits(s.expiredStalePerc.Load())
+}
+
+// records a key that expired, either by an expire cycle (active) or on access (passive).
+func (s *Stats) RecordExpiredKey(active bool) {
+	s.ExpiredKeys.Add(1)
+	if active {
+		s.ActiveExpiredKeys.Add(1)
+	} else {
+		s.PassiveExpiredKeys.Add(1)
+	}
+}
+
+// records a key that was evicted by the named eviction strategy.
+func (s *Stats) RecordEvictedKey(strategy string) {
+	s.EvictedKeys.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.evictions == nil {
+		s.evictions = make(map[string]int64)
+	}
+	s.evictions[strategy]++
+}
+
+// returns the number of keys evicted by every eviction strategy that evicted any.
+func (s *Stats) EvictedKeysByStrategy() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evictions := make(map[string]int64, len(s.evictions))
+	for strategy, count := range s.evictions {
+		evictions[strategy] = count
+	}
+	return evictions
+}
+
+// returns the number of bytes allocated by the server, and tracks the peak memory usage.
+func (s *Stats) TrackUsedMemory() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	for {
+		peak := s.usedMemoryPeak.Load()
+		if m.HeapAlloc <= peak || s.usedMemoryPeak.CompareAndSwap(peak, m.HeapAlloc) {
+			return m.HeapAlloc
+		}
+	}
+}
+
+// returns the highest memory usage seen so far, in bytes.
+func (s *Stats) UsedMemoryPeak() uint64 {
+	return s.usedMemoryPeak.Load()
+}
+
+// samples the number of commands processed since the last sample, at most once every
+// instantaneousSampleInterval. Called by the server's event loop.
+func (s *Stats) TrackInstantaneousMetrics() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(s.lastSampleTime)
+	if elapsed < instantaneousSampleInterval {
+		return
+	}
+
+	ops := s.TotalCommandsProcessed.Load()
+	s.opsSamples[s.opsSampleIdx] = float64(ops-s.lastSampleOps) / elapsed.Seconds()
+	s.opsSampleIdx = (s.opsSampleIdx + 1) % instantaneousSamples
+
+	s.lastSampleTime = now
+	s.lastSampleOps = ops
+}
+
+// returns the number of commands processed per second, averaged over the last few samples.
+func (s *Stats) InstantaneousOpsPerSec() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := 0.0
+	for _, sample := range s.opsSamples {
+		sum += sample
+	}
+	return int64(math.Round(sum / instantaneousSamples))
+}
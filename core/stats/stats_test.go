@@ -0,0 +1,194 @@
+package stats_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+)
+
+func TestStats(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Stats Suite")
+}
+
+var _ = Describe("Stats", func() {
+	var s *stats.Stats
+
+	BeforeEach(func() {
+		s = stats.NewStats()
+	})
+
+	It("should generate a 40 character run id", func() {
+		Expect(s.RunId).To(MatchRegexp("^[0-9a-f]{40}$"))
+		Expect(stats.NewStats().RunId).ToNot(Equal(s.RunId))
+	})
+
+	It("should move the estimate of stale keys towards the latest expire cycles", func() {
+		s.RecordExpireCycle(0, 0)
+		Expect(s.ExpiredStalePerc()).To(BeZero())
+
+		s.RecordExpireCycle(20, 20)
+		Expect(s.ExpiredStalePerc()).To(BeNumerically("~", 5, 0.001))
+
+		for i := 0; i < 200; i++ {
+			s.RecordExpireCycle(20, 10)
+		}
+		Expect(s.ExpiredStalePerc()).To(BeNumerically("~", 50, 0.1))
+	})
+
+	It("should average the commands processed per second over the samples", func() {
+		Expect(s.InstantaneousOpsPerSec()).To(BeZero())
+
+		// samples taken too close to each other are skipped.
+		s.TotalCommandsProcessed.Add(1000)
+		s.TrackInstantaneousMetrics()
+		Expect(s.InstantaneousOpsPerSec()).To(BeZero())
+
+		time.Sleep(200 * time.Millisecond)
+		s.TrackInstantaneousMetrics()
+
+		// a single sample of ~5000 ops/sec, averaged over 16 samples.
+		Expect(s.InstantaneousOpsPerSec()).To(BeNumerically("~", 5000/16, 50))
+	})
+
+	It("should split the expired keys into active and passive ones", func() {
+		s.RecordExpiredKey(true)
+		s.RecordExpiredKey(false)
+		s.RecordExpiredKey(false)
+
+		Expect(s.ExpiredKeys.Load()).To(Equal(int64(3)))
+		Expect(s.ActiveExpiredKeys.Load()).To(Equal(int64(1)))
+		Expect(s.PassiveExpiredKeys.Load()).To(Equal(int64(2)))
+	})
+
+	It("should count the evicted keys by eviction strategy", func() {
+		Expect(s.EvictedKeysByStrategy()).To(BeEmpty())
+
+		s.RecordEvictedKey("allkeys-lru")
+		s.RecordEvictedKey("allkeys-lru")
+
+		Expect(s.EvictedKeys.Load()).To(Equal(int64(2)))
+		Expect(s.EvictedKeysByStrategy()).To(Equal(map[string]int64{"allkeys-lru": 2}))
+	})
+
+	It("should track the peak memory usage", func() {
+		used := s.TrackUsedMemory()
+
+		Expect(used).To(BeNumerically(">", 0))
+		Expect(s.UsedMemoryPeak()).To(BeNumerically(">=", used))
+	})
+})
+
+var _ = Describe("Command stats", func() {
+	var s *stats.Stats
+
+	BeforeEach(func() {
+		s = stats.NewStats()
+	})
+
+	It("should record the calls of the commands", func() {
+		s.RecordCommand("GET", 10*time.Microsecond, false)
+		s.RecordCommand("get", 30*time.Microsecond, true)
+		s.RecordRejectedCommand("SET")
+
+		commands := s.Commands()
+		Expect(commands).To(HaveLen(2))
+
+		get := commands[0]
+		Expect(get.Name).To(Equal("get"))
+		Expect(get.Calls).To(Equal(int64(2)))
+		Expect(get.Usec).To(Equal(int64(40)))
+		Expect(get.UsecPerCall()).To(Equal(20.0))
+		Expect(get.FailedCalls).To(Equal(int64(1)))
+		Expect(get.Latency.Count()).To(Equal(int64(2)))
+
+		set := commands[1]
+		Expect(set.Name).To(Equal("set"))
+		Expect(set.Calls).To(BeZero())
+		Expect(set.RejectedCalls).To(Equal(int64(1)))
+	})
+
+	It("should only return the commands asked for", func() {
+		s.RecordCommand("GET", time.Microsecond, false)
+		s.RecordCommand("SET", time.Microsecond, false)
+
+		commands := s.Commands("SET", "DEL")
+		Expect(commands).To(HaveLen(1))
+		Expect(commands[0].Name).To(Equal("set"))
+	})
+
+	It("should count the error replies by their prefixes", func() {
+		s.RecordErrorReply(errors.New("ERR unknown command 'foo'"))
+		s.RecordErrorReply(errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"))
+		s.RecordErrorReply(errors.New("script: something went wrong"))
+
+		prefixes, counts := s.ErrorReplies()
+		Expect(prefixes).To(Equal([]string{"ERR", "WRONGTYPE"}))
+		Expect(counts).To(Equal(map[string]int64{"ERR": 2, "WRONGTYPE": 1}))
+		Expect(s.TotalErrorReplies.Load()).To(Equal(int64(3)))
+	})
+})
+
+var _ = Describe("LatencyMonitor", func() {
+	var (
+		monitor   *stats.LatencyMonitor
+		threshold time.Duration
+	)
+
+	BeforeEach(func() {
+		monitor = stats.NewLatencyMonitor()
+		threshold = config.LatencyMonitorThreshold
+		config.LatencyMonitorThreshold = 10 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		config.LatencyMonitorThreshold = threshold
+	})
+
+	It("should only record the events above the threshold", func() {
+		monitor.Sample(stats.LatencyEventCommand, 5*time.Millisecond)
+		Expect(monitor.Events()).To(BeEmpty())
+
+		monitor.Sample(stats.LatencyEventCommand, 20*time.Millisecond)
+		monitor.Sample(stats.LatencyEventExpireCycle, 15*time.Millisecond)
+
+		events := monitor.Events()
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Name).To(Equal(stats.LatencyEventCommand))
+		Expect(events[0].Latest().Latency).To(Equal(int64(20)))
+		Expect(events[0].Max).To(Equal(int64(20)))
+	})
+
+	It("should not record anything when disabled", func() {
+		config.LatencyMonitorThreshold = 0
+		monitor.Sample(stats.LatencyEventCommand, time.Second)
+
+		Expect(monitor.Events()).To(BeEmpty())
+	})
+
+	It("should merge the spikes within the same second", func() {
+		monitor.Sample(stats.LatencyEventCommand, 20*time.Millisecond)
+		monitor.Sample(stats.LatencyEventCommand, 50*time.Millisecond)
+		monitor.Sample(stats.LatencyEventCommand, 30*time.Millisecond)
+
+		history := monitor.History(stats.LatencyEventCommand)
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Latency).To(Equal(int64(50)))
+		Expect(monitor.History("unknown")).To(BeEmpty())
+	})
+
+	It("should forget the events when reset", func() {
+		monitor.Sample(stats.LatencyEventCommand, 20*time.Millisecond)
+		monitor.Sample(stats.LatencyEventExpireCycle, 20*time.Millisecond)
+
+		Expect(monitor.Reset(stats.LatencyEventCommand, "unknown")).To(Equal(1))
+		Expect(monitor.Events()).To(HaveLen(1))
+		Expect(monitor.Reset()).To(Equal(1))
+		Expect(monitor.Events()).To(BeEmpty())
+	})
+})
@@ -3,6 +3,7 @@ package store
 import (
 	"log"
 
+	"github.com/shashwatrathod/redis-internals/core/stats"
 	"github.com/shashwatrathod/redis-internals/utils"
 )
 
@@ -38,32 +39,28 @@ func (strategy *RandomSampleAutoDeletionStrategy) expireSample(dstore Store) flo
 	var keysToBeDeleted []string = make([]string, 0)
 
 	// find the keys to be deleted
-	dstore.ForEach(func(key string, val *Value) bool {
+	for _, key := range dstore.SampleKeysWithExpiry(strategy.sampleSize) {
 		if exp := dstore.GetExpiry(key); exp != nil {
 			nSearched++
 
-			expiry := utils.FromExpiryInUnixTime(*exp)
+			expiry := utils.FromExpiryInUnixMilli(*exp)
 			if expiry.IsExpired() {
 				keysToBeDeleted = append(keysToBeDeleted, key)
 			}
 		}
-
-		if nSearched == strategy.sampleSize {
-			return false
-		}
-
-		return true
-	})
+	}
 
 	// delete expired keys
 	var nExpired int = 0
 
 	for _, key := range keysToBeDeleted {
-		if dstore.Delete(key) {
+		if dstore.DeleteExpired(key) {
 			nExpired++
 		}
 	}
 
+	statsOf(dstore).RecordExpireCycle(nSearched, nExpired)
+
 	if nSearched > 0 {
 		return float32(nExpired) / float32(nSearched)
 	} else {
@@ -75,10 +72,17 @@ func (strategy *RandomSampleAutoDeletionStrategy) Execute(dstore Store) {
 	for {
 		fracExpired := strategy.expireSample(dstore)
 
-		log.Printf("auto-deleted %.2f %% of the sample.\n", fracExpired*100)
-
 		if fracExpired < strategy.rerunThreshold {
 			break
 		}
 	}
 }
+
+// returns the stats the store reports to, those of the server unless it's a DataStore reporting
+// to others.
+func statsOf(dstore Store) *stats.Stats {
+	if ds, ok := dstore.(*DataStore); ok {
+		return ds.getStats()
+	}
+	return stats.GetStats()
+}
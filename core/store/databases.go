@@ -0,0 +1,62 @@
+package store
+
+import "github.com/shashwatrathod/redis-internals/config"
+
+// The numbered logical databases of the server. Every database is an independent
+// DataStore, and clients pick the one their commands run against with SELECT.
+type Databases struct {
+	dbs []*DataStore
+}
+
+// returns n new, empty databases.
+func NewDatabases(n int) *Databases {
+	dbs := make([]*DataStore, n)
+	for i := range dbs {
+		dbs[i] = NewDataStore()
+	}
+	return &Databases{dbs: dbs}
+}
+
+var databasesInstance *Databases
+
+// returns the databases of the server, config.Databases of them.
+func GetDatabases() *Databases {
+	if databasesInstance == nil {
+		databasesInstance = NewDatabases(config.Databases)
+	}
+
+	return databasesInstance
+}
+
+// returns the database with the given index, nil if it is out of range.
+func (d *Databases) Get(index int) *DataStore {
+	if index < 0 || index >= len(d.dbs) {
+		return nil
+	}
+	return d.dbs[index]
+}
+
+// returns the number of databases.
+func (d *Databases) Count() int {
+	return len(d.dbs)
+}
+
+// swaps the contents of the two databases, so that the clients connected to one of
+// them immediately see the keys of the other. Both indexes must be in range.
+func (d *Databases) Swap(i int, j int) {
+	if i == j {
+		return
+	}
+	d.dbs[i].swapContents(d.dbs[j])
+}
+
+// deletes all the keys of all the databases, see DataStore.Reset and DataStore.ResetAsync.
+func (d *Databases) ResetAll(async bool) {
+	for _, db := range d.dbs {
+		if async {
+			db.ResetAsync()
+		} else {
+			db.Reset()
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package store_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+// records the names of the keyspace events it observes.
+type eventRecorder struct {
+	events []string
+}
+
+func (r *eventRecorder) OnKeyspaceEvent(event store.KeyspaceEvent) {
+	r.events = append(r.events, event.Name)
+}
+
+var _ = Describe("Databases", func() {
+	var (
+		databases *store.Databases
+		first     *store.DataStore
+		second    *store.DataStore
+	)
+
+	BeforeEach(func() {
+		databases = store.NewDatabases(2)
+		first = databases.Get(0)
+		second = databases.Get(1)
+	})
+
+	It("should keep the keys of every database apart", func() {
+		first.Put("key", "first", nil)
+
+		Expect(second.Get("key")).To(BeNil())
+		Expect(databases.Get(2)).To(BeNil())
+	})
+
+	Describe("Move", func() {
+		It("should move the key along with its expiry", func() {
+			first.Put("key", "value", utils.FromExpiryInSeconds(100))
+
+			Expect(first.Move("key", second)).To(BeTrue())
+
+			Expect(first.Get("key")).To(BeNil())
+			Expect(second.Get("key").Value).To(Equal("value"))
+			Expect(second.GetExpiry("key")).ToNot(BeNil())
+		})
+
+		It("should not move keys that exist in the destination", func() {
+			first.Put("key", "first", nil)
+			second.Put("key", "second", nil)
+
+			Expect(first.Move("key", second)).To(BeFalse())
+
+			Expect(first.Get("key").Value).To(Equal("first"))
+			Expect(second.Get("key").Value).To(Equal("second"))
+		})
+
+		It("should not move missing keys", func() {
+			Expect(first.Move("key", second)).To(BeFalse())
+		})
+
+		It("should emit move events in both databases", func() {
+			from, to := &eventRecorder{}, &eventRecorder{}
+			first.Put("key", "value", nil)
+			first.AddKeyspaceObserver(from)
+			second.AddKeyspaceObserver(to)
+
+			first.Move("key", second)
+
+			Expect(from.events).To(Equal([]string{store.MoveFromEventName}))
+			Expect(to.events).To(Equal([]string{store.MoveToEventName}))
+		})
+	})
+
+	Describe("Swap", func() {
+		It("should swap the keys of the databases", func() {
+			first.Put("key", "first", nil)
+			second.Put("other", "second", nil)
+
+			databases.Swap(0, 1)
+
+			Expect(first.Get("key")).To(BeNil())
+			Expect(first.Get("other").Value).To(Equal("second"))
+			Expect(second.Get("key").Value).To(Equal("first"))
+		})
+
+		It("should invalidate the watched keys of both databases", func() {
+			version := first.Watch("key")
+
+			databases.Swap(0, 1)
+
+			Expect(first.KeyVersion("key")).ToNot(Equal(version))
+		})
+	})
+
+	It("should delete the keys of all the databases", func() {
+		first.Put("key", "first", nil)
+		second.Put("key", "second", nil)
+
+		databases.ResetAll(true)
+
+		Expect(first.KeyCount()).To(Equal(0))
+		Expect(second.KeyCount()).To(Equal(0))
+	})
+})
@@ -0,0 +1,510 @@
+package store
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// number of buckets a table starts with.
+	dictInitialSize = 4
+
+	// tables are shrunk once less than 1/dictMinFillRatio of their buckets are used.
+	dictMinFillRatio = 8
+
+	// number of empty buckets a rehash step may skip for every bucket it has to migrate,
+	// so that a single step stays cheap even on a sparse table.
+	dictEmptyVisitsPerBucket = 10
+
+	// number of buckets migrated in between the checks of the time budget of RehashFor.
+	dictRehashBatch = 100
+
+	// number of keys sampled to pick a random key with a fair distribution.
+	dictFairRandomSample = 15
+)
+
+// An entry of a dict, chained with the other entries of its bucket.
+type dictEntry[V any] struct {
+	key   string
+	value V
+	next  *dictEntry[V]
+}
+
+type dictTable[V any] struct {
+	// the number of buckets is always a power of two, so that hashes map to buckets with a mask.
+	buckets []*dictEntry[V]
+	used    int
+}
+
+func newDictTable[V any](size int) *dictTable[V] {
+	return &dictTable[V]{buckets: make([]*dictEntry[V], size)}
+}
+
+func (t *dictTable[V]) mask() uint64 {
+	return uint64(len(t.buckets) - 1)
+}
+
+// Dict is a hash table with chained buckets, modelled after Redis's dict.
+//
+// When the table has to grow or shrink, a second table is allocated and the entries are
+// moved over to it incrementally: every operation migrates a bucket, and the server
+// migrates more of them in its cron through RehashFor. No single operation pays for
+// rehashing the whole table, which keeps the latency flat as the keyspace grows.
+//
+// Unlike a Go map, a Dict can be scanned with a stateless cursor (see Scan) and sampled
+// at random in constant time (see SampleKeys and RandomKey).
+type Dict[V any] struct {
+	// tables[1] is only set while rehashing, when the entries move from tables[0] to it.
+	tables [2]*dictTable[V]
+
+	// index of the next bucket of tables[0] to be migrated, -1 when not rehashing.
+	rehashIdx int
+
+	// set while the dict is being iterated over, when the entries must not move between tables.
+	pauseRehash int
+
+	seed maphash.Seed
+}
+
+// returns a new, empty dict.
+func NewDict[V any]() *Dict[V] {
+	return &Dict[V]{
+		tables:    [2]*dictTable[V]{newDictTable[V](0), nil},
+		rehashIdx: -1,
+		seed:      maphash.MakeSeed(),
+	}
+}
+
+func (d *Dict[V]) hash(key string) uint64 {
+	return maphash.String(d.seed, key)
+}
+
+// returns true if the entries are being moved over to a new table.
+func (d *Dict[V]) IsRehashing() bool {
+	return d.tables[1] != nil
+}
+
+// returns the number of entries in the dict.
+func (d *Dict[V]) Len() int {
+	if d.IsRehashing() {
+		return d.tables[0].used + d.tables[1].used
+	}
+	return d.tables[0].used
+}
+
+// returns the total number of buckets of the dict's tables.
+func (d *Dict[V]) Buckets() int {
+	if d.IsRehashing() {
+		return len(d.tables[0].buckets) + len(d.tables[1].buckets)
+	}
+	return len(d.tables[0].buckets)
+}
+
+// returns the value of the key, and false if the key is not in the dict.
+func (d *Dict[V]) Get(key string) (V, bool) {
+	d.rehashStep()
+
+	if e := d.find(key); e != nil {
+		return e.value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+// sets the value of the key. returns true if the key was added to the dict, or false if
+// the value of an existing key was replaced.
+func (d *Dict[V]) Set(key string, value V) bool {
+	d.rehashStep()
+
+	if e := d.find(key); e != nil {
+		e.value = value
+		return false
+	}
+
+	d.expandIfNeeded()
+
+	// while rehashing, new entries go straight into the new table.
+	table := d.tables[0]
+	if d.IsRehashing() {
+		table = d.tables[1]
+	}
+
+	idx := d.hash(key) & table.mask()
+	table.buckets[idx] = &dictEntry[V]{key: key, value: value, next: table.buckets[idx]}
+	table.used++
+	return true
+}
+
+// removes the key from the dict. returns its value, and false if the key was not in the dict.
+func (d *Dict[V]) Delete(key string) (V, bool) {
+	d.rehashStep()
+
+	var zero V
+	if d.Len() == 0 {
+		return zero, false
+	}
+
+	h := d.hash(key)
+	for _, table := range d.tables {
+		if table == nil || len(table.buckets) == 0 {
+			continue
+		}
+
+		idx := h & table.mask()
+		var prev *dictEntry[V] = nil
+		for e := table.buckets[idx]; e != nil; prev, e = e, e.next {
+			if e.key != key {
+				continue
+			}
+
+			if prev == nil {
+				table.buckets[idx] = e.next
+			} else {
+				prev.next = e.next
+			}
+			table.used--
+
+			d.shrinkIfNeeded()
+			return e.value, true
+		}
+	}
+	return zero, false
+}
+
+// removes all the entries from the dict.
+func (d *Dict[V]) Clear() {
+	for _, table := range d.tables {
+		if table != nil {
+			clear(table.buckets)
+		}
+	}
+
+	d.tables = [2]*dictTable[V]{newDictTable[V](0), nil}
+	d.rehashIdx = -1
+}
+
+// calls fn for every entry of the dict, until fn returns false. Rehashing is paused
+// during the iteration, so fn may delete the entry it is called with.
+func (d *Dict[V]) ForEach(fn func(key string, value V) bool) {
+	d.pauseRehash++
+	defer func() {
+		d.pauseRehash--
+	}()
+
+	for _, table := range d.tables {
+		if table == nil {
+			continue
+		}
+		for _, bucket := range table.buckets {
+			for e := bucket; e != nil; {
+				next := e.next
+				if !fn(e.key, e.value) {
+					return
+				}
+				e = next
+			}
+		}
+	}
+}
+
+// Scan calls fn for the entries of the next bucket(s) at the cursor, and returns the cursor to
+// continue from, which is 0 once the whole dict has been visited. Start scanning at cursor 0.
+//
+// This is Redis's reverse binary iteration: the cursor is incremented from its most significant
+// bit down, so that the buckets visited before a table grows or shrinks map onto buckets that are
+// also visited before in the resized table. Every entry present for the whole scan is visited at
+// least once, and only the entries of buckets that got merged by a shrink may be visited twice.
+func (d *Dict[V]) Scan(cursor uint64, fn func(key string, value V)) uint64 {
+	if d.Len() == 0 {
+		return 0
+	}
+
+	d.pauseRehash++
+	defer func() {
+		d.pauseRehash--
+	}()
+
+	v := cursor
+
+	if !d.IsRehashing() {
+		t0 := d.tables[0]
+		m0 := t0.mask()
+
+		emitBucket(t0.buckets[v&m0], fn)
+
+		// set the unmasked bits, so that incrementing the reversed cursor carries into the masked bits.
+		v |= ^m0
+		v = bits.Reverse64(bits.Reverse64(v) + 1)
+		return v
+	}
+
+	t0, t1 := d.tables[0], d.tables[1]
+	if len(t0.buckets) > len(t1.buckets) {
+		t0, t1 = t1, t0
+	}
+	m0, m1 := t0.mask(), t1.mask()
+
+	emitBucket(t0.buckets[v&m0], fn)
+
+	// visit all the buckets of the larger table that are expansions of the bucket of the smaller one.
+	for {
+		emitBucket(t1.buckets[v&m1], fn)
+
+		v |= ^m1
+		v = bits.Reverse64(bits.Reverse64(v) + 1)
+
+		if v&(m0^m1) == 0 {
+			break
+		}
+	}
+	return v
+}
+
+func emitBucket[V any](bucket *dictEntry[V], fn func(key string, value V)) {
+	for e := bucket; e != nil; {
+		next := e.next
+		fn(e.key, e.value)
+		e = next
+	}
+}
+
+// SampleKeys returns up to count keys, picked at random from the dict, in constant time. The keys
+// come from consecutive buckets starting at a random one, so they are not independent of each other,
+// which is good enough for sampling the candidates to be evicted or expired.
+func (d *Dict[V]) SampleKeys(count int) []string {
+	if count > d.Len() {
+		count = d.Len()
+	}
+	if count <= 0 {
+		return nil
+	}
+
+	for i := 0; i < count; i++ {
+		d.rehashStep()
+	}
+
+	maxMask := d.tables[0].mask()
+	if d.IsRehashing() && d.tables[1].mask() > maxMask {
+		maxMask = d.tables[1].mask()
+	}
+
+	keys := make([]string, 0, count)
+	i := rand.Uint64() & maxMask
+	emptyLen := 0
+
+	for steps := count * 10; len(keys) < count && steps > 0; steps-- {
+		for t, table := range d.tables {
+			if table == nil {
+				continue
+			}
+
+			// the buckets of the old table below rehashIdx have already been migrated.
+			if t == 0 && d.IsRehashing() && i < uint64(d.rehashIdx) {
+				if i >= uint64(len(d.tables[1].buckets)) {
+					i = uint64(d.rehashIdx)
+				} else {
+					continue
+				}
+			}
+
+			if i >= uint64(len(table.buckets)) {
+				continue
+			}
+
+			e := table.buckets[i]
+			if e == nil {
+				// jump elsewhere after a run of empty buckets.
+				emptyLen++
+				if emptyLen >= 5 && emptyLen > count {
+					i = rand.Uint64() & maxMask
+					emptyLen = 0
+				}
+				continue
+			}
+
+			emptyLen = 0
+			for ; e != nil && len(keys) < count; e = e.next {
+				keys = append(keys, e.key)
+			}
+		}
+		i = (i + 1) & maxMask
+	}
+
+	return keys
+}
+
+// RandomKey returns a key picked at random from the dict, and false if the dict is empty.
+// Picking a random bucket and then a random entry of it would favour the entries of short
+// chains, so the key is picked from a sample of keys instead, which evens the odds out.
+func (d *Dict[V]) RandomKey() (string, bool) {
+	keys := d.SampleKeys(dictFairRandomSample)
+	if len(keys) == 0 {
+		return d.randomBucketKey()
+	}
+	return keys[rand.IntN(len(keys))], true
+}
+
+// returns a random entry of a random non-empty bucket.
+func (d *Dict[V]) randomBucketKey() (string, bool) {
+	if d.Len() == 0 {
+		return "", false
+	}
+
+	var bucket *dictEntry[V] = nil
+	for bucket == nil {
+		if d.IsRehashing() {
+			// the buckets of the old table below rehashIdx are known to be empty.
+			size0, size1 := len(d.tables[0].buckets), len(d.tables[1].buckets)
+			idx := d.rehashIdx + rand.IntN(size0+size1-d.rehashIdx)
+			if idx >= size0 {
+				bucket = d.tables[1].buckets[idx-size0]
+			} else {
+				bucket = d.tables[0].buckets[idx]
+			}
+		} else {
+			bucket = d.tables[0].buckets[rand.IntN(len(d.tables[0].buckets))]
+		}
+	}
+
+	chainLen := 0
+	for e := bucket; e != nil; e = e.next {
+		chainLen++
+	}
+
+	e := bucket
+	for n := rand.IntN(chainLen); n > 0; n-- {
+		e = e.next
+	}
+	return e.key, true
+}
+
+// RehashFor migrates buckets to the new table for up to the given duration, if the dict is being
+// rehashed. Returns true if the rehashing is still not complete.
+func (d *Dict[V]) RehashFor(budget time.Duration) bool {
+	if d.pauseRehash > 0 {
+		return d.IsRehashing()
+	}
+
+	start := time.Now()
+	for d.rehash(dictRehashBatch) {
+		if time.Since(start) >= budget {
+			return true
+		}
+	}
+	return false
+}
+
+// migrates a single bucket, if the dict is being rehashed and the rehashing is not paused.
+func (d *Dict[V]) rehashStep() {
+	if d.pauseRehash == 0 {
+		d.rehash(1)
+	}
+}
+
+// migrates up to n buckets from the old table to the new one. Returns true if there are more
+// buckets left to migrate.
+func (d *Dict[V]) rehash(n int) bool {
+	if !d.IsRehashing() {
+		return false
+	}
+
+	t0, t1 := d.tables[0], d.tables[1]
+	emptyVisits := n * dictEmptyVisitsPerBucket
+
+	for ; n > 0 && t0.used > 0; n-- {
+		for t0.buckets[d.rehashIdx] == nil {
+			d.rehashIdx++
+			emptyVisits--
+			if emptyVisits == 0 {
+				return true
+			}
+		}
+
+		for e := t0.buckets[d.rehashIdx]; e != nil; {
+			next := e.next
+			idx := d.hash(e.key) & t1.mask()
+			e.next = t1.buckets[idx]
+			t1.buckets[idx] = e
+			t0.used--
+			t1.used++
+			e = next
+		}
+		t0.buckets[d.rehashIdx] = nil
+		d.rehashIdx++
+	}
+
+	if t0.used == 0 {
+		d.tables[0] = t1
+		d.tables[1] = nil
+		d.rehashIdx = -1
+		return false
+	}
+	return true
+}
+
+// returns the entry of the key, nil if it isn't in the dict.
+func (d *Dict[V]) find(key string) *dictEntry[V] {
+	if d.Len() == 0 {
+		return nil
+	}
+
+	h := d.hash(key)
+	for _, table := range d.tables {
+		if table == nil || len(table.buckets) == 0 {
+			continue
+		}
+		for e := table.buckets[h&table.mask()]; e != nil; e = e.next {
+			if e.key == key {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// starts growing the table once it has as many entries as buckets.
+func (d *Dict[V]) expandIfNeeded() {
+	if d.IsRehashing() {
+		return
+	}
+
+	t0 := d.tables[0]
+	if len(t0.buckets) == 0 {
+		d.tables[0] = newDictTable[V](dictInitialSize)
+		return
+	}
+
+	if t0.used >= len(t0.buckets) {
+		d.resize(t0.used + 1)
+	}
+}
+
+// starts shrinking the table once it is mostly empty.
+func (d *Dict[V]) shrinkIfNeeded() {
+	if d.IsRehashing() {
+		return
+	}
+
+	t0 := d.tables[0]
+	if len(t0.buckets) > dictInitialSize && t0.used*dictMinFillRatio < len(t0.buckets) {
+		d.resize(t0.used)
+	}
+}
+
+// starts rehashing into a new table with enough buckets for size entries.
+func (d *Dict[V]) resize(size int) {
+	newSize := dictInitialSize
+	for newSize < size {
+		newSize *= 2
+	}
+
+	if newSize == len(d.tables[0].buckets) {
+		return
+	}
+
+	d.tables[1] = newDictTable[V](newSize)
+	d.rehashIdx = 0
+}
@@ -0,0 +1,167 @@
+package store_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// scans the whole dict, calling mutate in between the scan calls. returns the number of times each key was seen.
+func scanAll(d *store.Dict[int], mutate func()) map[string]int {
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	for {
+		cursor = d.Scan(cursor, func(key string, value int) {
+			seen[key]++
+		})
+		if cursor == 0 {
+			return seen
+		}
+		mutate()
+	}
+}
+
+var _ = Describe("Dict", func() {
+	var d *store.Dict[int]
+
+	BeforeEach(func() {
+		d = store.NewDict[int]()
+	})
+
+	It("should set, get and delete keys while rehashing", func() {
+		sawRehashing := false
+		for i := 0; i < 1000; i++ {
+			Expect(d.Set(fmt.Sprintf("key-%d", i), i)).To(BeTrue())
+			sawRehashing = sawRehashing || d.IsRehashing()
+		}
+		Expect(sawRehashing).To(BeTrue())
+		Expect(d.Len()).To(Equal(1000))
+
+		Expect(d.Set("key-1", 42)).To(BeFalse())
+		value, ok := d.Get("key-1")
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(42))
+
+		for i := 0; i < 1000; i += 2 {
+			_, ok := d.Delete(fmt.Sprintf("key-%d", i))
+			Expect(ok).To(BeTrue())
+		}
+		Expect(d.Len()).To(Equal(500))
+
+		for i := 0; i < 1000; i++ {
+			_, ok := d.Get(fmt.Sprintf("key-%d", i))
+			Expect(ok).To(Equal(i%2 == 1))
+		}
+	})
+
+	It("should shrink the table once most keys are deleted", func() {
+		for i := 0; i < 1024; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+		grown := d.Buckets()
+
+		for i := 0; i < 1020; i++ {
+			d.Delete(fmt.Sprintf("key-%d", i))
+		}
+		for d.RehashFor(time.Millisecond) {
+		}
+
+		Expect(d.Buckets()).To(BeNumerically("<", grown))
+		Expect(d.Len()).To(Equal(4))
+	})
+
+	It("should finish rehashing within the cron budget", func() {
+		for i := 0; i < 100; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+		for d.RehashFor(time.Millisecond) {
+		}
+		Expect(d.IsRehashing()).To(BeFalse())
+	})
+
+	It("should return every key when scanning a dict that grows in between calls", func() {
+		for i := 0; i < 100; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+
+		// the dict grows a few times over during the scan.
+		next := 100
+		seen := scanAll(d, func() {
+			for j := 0; j < 20 && next < 1000; j++ {
+				d.Set(fmt.Sprintf("key-%d", next), next)
+				next++
+			}
+		})
+
+		for i := 0; i < 100; i++ {
+			Expect(seen).To(HaveKey(fmt.Sprintf("key-%d", i)))
+		}
+	})
+
+	It("should return every key when scanning a dict that shrinks in between calls", func() {
+		for i := 0; i < 1000; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+
+		// only the keys from 900 onwards are kept for the whole scan.
+		next := 0
+		seen := scanAll(d, func() {
+			for j := 0; j < 50 && next < 900; j++ {
+				d.Delete(fmt.Sprintf("key-%d", next))
+				next++
+			}
+		})
+
+		for i := 900; i < 1000; i++ {
+			Expect(seen).To(HaveKey(fmt.Sprintf("key-%d", i)))
+		}
+	})
+
+	It("should return no keys when scanning an empty dict", func() {
+		Expect(scanAll(d, func() {})).To(BeEmpty())
+	})
+
+	It("should sample distinct existing keys", func() {
+		for i := 0; i < 100; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+
+		sample := d.SampleKeys(10)
+		Expect(sample).To(HaveLen(10))
+
+		distinct := make(map[string]bool)
+		for _, key := range sample {
+			_, ok := d.Get(key)
+			Expect(ok).To(BeTrue())
+			distinct[key] = true
+		}
+		Expect(distinct).To(HaveLen(10))
+
+		Expect(d.SampleKeys(500)).To(HaveLen(100))
+		Expect(store.NewDict[int]().SampleKeys(5)).To(BeEmpty())
+	})
+
+	It("should pick every key as the random key", func() {
+		_, ok := d.RandomKey()
+		Expect(ok).To(BeFalse())
+
+		for i := 0; i < 8; i++ {
+			d.Set(fmt.Sprintf("key-%d", i), i)
+		}
+
+		picked := make(map[string]int)
+		for i := 0; i < 4000; i++ {
+			key, ok := d.RandomKey()
+			Expect(ok).To(BeTrue())
+			picked[key]++
+		}
+
+		Expect(picked).To(HaveLen(8))
+		for _, count := range picked {
+			Expect(count).To(BeNumerically(">", 250))
+		}
+	})
+})
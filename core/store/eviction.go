@@ -9,6 +9,9 @@ import (
 type EvictionStrategy interface {
 	// executes the eviction strategy on the datastore. returns the number of keys evicted
 	Execute(dstore Store) (int, error)
+
+	// returns the name of the strategy, as in Redis's maxmemory-policy (eg. allkeys-lru).
+	Name() string
 }
 
 // AllKeysLRUEvictionStrategy implements Redis's allkeys-lru eviction strategy.
@@ -23,30 +26,22 @@ func NewAllKeysLRUEvictionStrategy(sampleSize int) *AllKeysLRUEvictionStrategy {
 	}
 }
 
+func (strategy *AllKeysLRUEvictionStrategy) Name() string {
+	return "allkeys-lru"
+}
+
 func (strategy *AllKeysLRUEvictionStrategy) findLeastRecentlyUsedKey(dstore Store) *string {
 	var leastRecentlyUsedKey *string = nil
 	var earliestAccessTime utils.LRUTime = utils.GetCurrentLruTime()
 
-	nKeysScanned := 0
-
-	dstore.ForEach(func(key string, val *Value) bool {
-		if nKeysScanned >= strategy.SampleSize {
-			return false
-		}
-
+	for _, key := range dstore.SampleKeys(strategy.SampleSize) {
 		keyMetadata := dstore.GetKeyMetadata(key)
 
-		if keyMetadata != nil {
-			nKeysScanned++
-
-			if uint32(keyMetadata.LastAccessedTimestamp) <= uint32(earliestAccessTime) {
-				earliestAccessTime = keyMetadata.LastAccessedTimestamp
-				leastRecentlyUsedKey = &key
-			}
+		if keyMetadata != nil && uint32(keyMetadata.LastAccessedTimestamp) <= uint32(earliestAccessTime) {
+			earliestAccessTime = keyMetadata.LastAccessedTimestamp
+			leastRecentlyUsedKey = &key
 		}
-
-		return true
-	})
+	}
 
 	return leastRecentlyUsedKey
 }
@@ -55,7 +50,7 @@ func (strategy *AllKeysLRUEvictionStrategy) Execute(dstore Store) (int, error) {
 	leastRecentlyUsedKey := strategy.findLeastRecentlyUsedKey(dstore)
 
 	if leastRecentlyUsedKey != nil {
-		dstore.Delete(*leastRecentlyUsedKey)
+		dstore.DeleteEvicted(*leastRecentlyUsedKey)
 	}
 
 	// TODO: implement
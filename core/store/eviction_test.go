@@ -32,12 +32,7 @@ var _ = Describe("AllKeysLRUEvictionStrategy", func() {
 
 	It("should evict the least recently used key", func() {
 		// Set up mock store with key metadata
-		mockStore.On("ForEach", mock.Anything).Run(func(args mock.Arguments) {
-			fn := args.Get(0).(func(string, *store.Value) bool)
-			fn("key1", &store.Value{})
-			fn("key2", &store.Value{})
-			fn("key3", &store.Value{})
-		}).Return()
+		mockStore.On("SampleKeys", sampleSize).Return([]string{"key1", "key2", "key3"})
 
 		mockStore.On("GetKeyMetadata", "key1").Return(&store.KeyMetadata{
 			LastAccessedTimestamp: utils.ToLRUTime(time.Now().Add(-10 * time.Minute)),
@@ -49,41 +44,33 @@ var _ = Describe("AllKeysLRUEvictionStrategy", func() {
 			LastAccessedTimestamp: utils.ToLRUTime(time.Now().Add(-1 * time.Minute)),
 		})
 
-		mockStore.On("Delete", "key1").Return(true)
+		mockStore.On("DeleteEvicted", "key1").Return(true)
 
 		// Execute eviction strategy
 		strategy.Execute(mockStore)
 
 		// Verify that the least recently used key was deleted
-		mockStore.AssertCalled(GinkgoT(), "Delete", "key1")
+		mockStore.AssertCalled(GinkgoT(), "DeleteEvicted", "key1")
 
 		// Verify that no other key was deleted
-		mockStore.AssertNotCalled(GinkgoT(), "Delete", "key2")
-		mockStore.AssertNotCalled(GinkgoT(), "Delete", "key3")
+		mockStore.AssertNotCalled(GinkgoT(), "DeleteEvicted", "key2")
+		mockStore.AssertNotCalled(GinkgoT(), "DeleteEvicted", "key3")
 	})
 
 	It("should not evict any key if the store is empty", func() {
 		// Set up mock store with no keys
-		mockStore.On("ForEach", mock.Anything).Return()
+		mockStore.On("SampleKeys", sampleSize).Return([]string{})
 
 		// Execute eviction strategy
 		strategy.Execute(mockStore)
 
 		// Verify that no key was deleted
-		mockStore.AssertNotCalled(GinkgoT(), "Delete", mock.Anything)
+		mockStore.AssertNotCalled(GinkgoT(), "DeleteEvicted", mock.Anything)
 	})
 	It("should only sample 'SampleSize' keys", func() {
 		nkeys := 10
 		// Set up mock store with more keys than the sample size
-		mockStore.On("ForEach", mock.Anything).Run(func(args mock.Arguments) {
-			fn := args.Get(0).(func(string, *store.Value) bool)
-			for i := 1; i <= nkeys; i++ {
-				cont := fn(fmt.Sprintf("key-%d", i), &store.Value{})
-				if !cont {
-					break
-				}
-			}
-		}).Return()
+		mockStore.On("SampleKeys", sampleSize).Return([]string{"key-2", "key-5", "key-9"})
 
 		for i := 1; i <= nkeys; i++ {
 			mockStore.On("GetKeyMetadata", fmt.Sprintf("key-%d", i)).Return(&store.KeyMetadata{
@@ -91,12 +78,13 @@ var _ = Describe("AllKeysLRUEvictionStrategy", func() {
 			}).Maybe()
 		}
 
-		mockStore.On("Delete", mock.Anything).Return(true)
+		mockStore.On("DeleteEvicted", mock.Anything).Return(true)
 
 		// Execute eviction strategy
 		strategy.Execute(mockStore)
 
-		// Verify that only 'SampleSize' keys were sampled
+		// Verify that only 'SampleSize' keys were sampled, and the oldest of them was evicted
 		mockStore.AssertNumberOfCalls(GinkgoT(), "GetKeyMetadata", sampleSize)
+		mockStore.AssertCalled(GinkgoT(), "DeleteEvicted", "key-9")
 	})
 })
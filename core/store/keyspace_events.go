@@ -0,0 +1,63 @@
+package store
+
+// Classes of keyspace events, as bit flags. They mirror the classes that can be
+// enabled through Redis's notify-keyspace-events setting.
+type KeyspaceEventClass int
+
+const (
+	// events such as del, expire and rename that are not specific to a datatype.
+	GenericEvent KeyspaceEventClass = 1 << iota
+	StringEvent
+	ListEvent
+	SetEvent
+	HashEvent
+	ZSetEvent
+	StreamEvent
+	// key got deleted because it expired.
+	ExpiredEvent
+	// key got deleted to make room for new keys.
+	EvictedEvent
+	// key was looked up but was not found in the store.
+	KeyMissEvent
+	// key got added to the store.
+	NewKeyEvent
+	// all the keys of the store got deleted or replaced at once, eg. by FLUSHDB or SWAPDB.
+	// the event carries no key, and is never published as a keyspace notification.
+	FlushEvent
+)
+
+// names of the keyspace events emitted by the store.
+const (
+	SetEventName     = "set"
+	DelEventName     = "del"
+	ExpireEventName  = "expire"
+	ExpiredEventName = "expired"
+	EvictedEventName = "evicted"
+	KeyMissEventName = "keymiss"
+	NewKeyEventName  = "new"
+	HSetEventName    = "hset"
+	HDelEventName    = "hdel"
+	// key got moved into another database with MOVE.
+	MoveFromEventName = "move_from"
+	MoveToEventName   = "move_to"
+	// key got renamed with RENAME or RENAMENX.
+	RenameFromEventName = "rename_from"
+	RenameToEventName   = "rename_to"
+	// key got created as a copy of another key with COPY.
+	CopyToEventName = "copy_to"
+	// all the keys of the store got deleted or replaced at once.
+	FlushEventName = "flush"
+)
+
+// Represents a change made to a key in the store, or a failed lookup of a key.
+type KeyspaceEvent struct {
+	Class KeyspaceEventClass
+	// name of the event, eg. "set", "del", "expired".
+	Name string
+	Key  string
+}
+
+// Gets notified about every keyspace event in the stores it observes.
+type KeyspaceObserver interface {
+	OnKeyspaceEvent(event KeyspaceEvent)
+}
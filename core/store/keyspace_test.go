@@ -0,0 +1,199 @@
+package store_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+var _ = Describe("Keyspace operations", func() {
+	var (
+		dataStore *store.DataStore
+		maxKeys   int
+	)
+
+	BeforeEach(func() {
+		dataStore = store.NewDataStore()
+		maxKeys = config.MaxKeys
+		config.MaxKeys = 1000
+	})
+
+	AfterEach(func() {
+		config.MaxKeys = maxKeys
+	})
+
+	Describe("Peek", func() {
+		It("should not report expired keys", func() {
+			dataStore.Put("key", "value", utils.FromExpiryInSeconds(-1))
+
+			Expect(dataStore.Peek("key")).To(BeNil())
+		})
+	})
+
+	Describe("Rename", func() {
+		It("should rename the key along with its expiry", func() {
+			dataStore.Put("key", "value", utils.FromExpiryInSeconds(100))
+
+			Expect(dataStore.Rename("key", "renamed", false)).To(BeTrue())
+
+			Expect(dataStore.Get("key")).To(BeNil())
+			Expect(dataStore.Get("renamed").Value).To(Equal("value"))
+			Expect(dataStore.GetExpiry("renamed")).ToNot(BeNil())
+		})
+
+		It("should only overwrite the new key if asked to", func() {
+			dataStore.Put("key", "value", nil)
+			dataStore.Put("renamed", "existing", nil)
+
+			Expect(dataStore.Rename("key", "renamed", false)).To(BeFalse())
+			Expect(dataStore.Rename("key", "renamed", true)).To(BeTrue())
+
+			Expect(dataStore.Get("renamed").Value).To(Equal("value"))
+		})
+
+		It("should emit rename events", func() {
+			recorder := &eventRecorder{}
+			dataStore.Put("key", "value", nil)
+			dataStore.AddKeyspaceObserver(recorder)
+
+			dataStore.Rename("key", "renamed", false)
+
+			Expect(recorder.events).To(Equal([]string{store.RenameFromEventName, store.RenameToEventName}))
+		})
+	})
+
+	Describe("Copy", func() {
+		It("should copy the key into the destination", func() {
+			other := store.NewDataStore()
+			dataStore.Put("key", "value", utils.FromExpiryInSeconds(100))
+
+			Expect(dataStore.Copy("key", other, "copied", false)).To(BeTrue())
+
+			Expect(dataStore.Get("key").Value).To(Equal("value"))
+			Expect(other.Get("copied").Value).To(Equal("value"))
+			Expect(other.GetExpiry("copied")).ToNot(BeNil())
+		})
+
+		It("should only overwrite the new key if asked to", func() {
+			dataStore.Put("key", "value", nil)
+			dataStore.Put("copied", "existing", nil)
+
+			Expect(dataStore.Copy("key", dataStore, "copied", false)).To(BeFalse())
+			Expect(dataStore.Copy("key", dataStore, "copied", true)).To(BeTrue())
+
+			Expect(dataStore.Get("copied").Value).To(Equal("value"))
+		})
+	})
+
+	Describe("Hashes", func() {
+		It("should set and delete the fields, and the key along with the last of them", func() {
+			recorder := &eventRecorder{}
+			dataStore.AddKeyspaceObserver(recorder)
+
+			Expect(dataStore.HSet("key", []string{"a", "1", "b", "2"})).To(Equal(2))
+			Expect(dataStore.HSet("key", []string{"a", "3"})).To(Equal(0))
+			Expect(dataStore.Get("key").TypeName()).To(Equal("hash"))
+
+			Expect(dataStore.HDel("key", []string{"a", "missing"})).To(Equal(1))
+			Expect(dataStore.HDel("key", []string{"b"})).To(Equal(1))
+			Expect(dataStore.Peek("key")).To(BeNil())
+			Expect(recorder.events).To(Equal([]string{"new", "hset", "hset", "hdel", "hdel", "del"}))
+		})
+
+		It("should not touch the keys holding another type of value", func() {
+			dataStore.Put("key", "value", nil)
+
+			_, err := dataStore.HSet("key", []string{"a", "1"})
+			Expect(err).To(MatchError(ContainSubstring("WRONGTYPE")))
+			_, err = dataStore.HDel("key", []string{"a"})
+			Expect(err).To(MatchError(ContainSubstring("WRONGTYPE")))
+			Expect(dataStore.Get("key").Value).To(Equal("value"))
+		})
+
+		It("should copy the fields, so that the copy changes independently", func() {
+			dataStore.HSet("key", []string{"a", "1"})
+
+			Expect(dataStore.Copy("key", dataStore, "copied", false)).To(BeTrue())
+			dataStore.HSet("copied", []string{"a", "2"})
+
+			hash, err := dataStore.Get("key").Hash()
+			Expect(err).NotTo(HaveOccurred())
+			value, _ := hash.Get("a")
+			Expect(value).To(Equal("1"))
+		})
+	})
+
+	Describe("RandomKey", func() {
+		It("should return one of the keys", func() {
+			dataStore.Put("a", "1", nil)
+			dataStore.Put("b", "2", nil)
+
+			key, found := dataStore.RandomKey()
+
+			Expect(found).To(BeTrue())
+			Expect(key).To(BeElementOf("a", "b"))
+		})
+
+		It("should not return expired keys", func() {
+			dataStore.Put("key", "value", utils.FromExpiryInSeconds(-1))
+
+			_, found := dataStore.RandomKey()
+
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Scan", func() {
+		scanAll := func(count int, between func()) map[string]int {
+			visited := make(map[string]int)
+			cursor := uint64(0)
+			for {
+				cursor = dataStore.Scan(cursor, count, func(key string, value *store.Value) {
+					visited[key]++
+				})
+				if cursor == 0 {
+					return visited
+				}
+				between()
+			}
+		}
+
+		It("should visit every key exactly once in a stable keyspace", func() {
+			for i := 0; i < 100; i++ {
+				dataStore.Put(fmt.Sprintf("key%d", i), "value", nil)
+			}
+
+			visited := scanAll(7, func() {})
+
+			Expect(visited).To(HaveLen(100))
+			for _, n := range visited {
+				Expect(n).To(Equal(1))
+			}
+		})
+
+		It("should visit every key present for the whole scan while the keyspace changes", func() {
+			for i := 0; i < 100; i++ {
+				dataStore.Put(fmt.Sprintf("key%d", i), "value", nil)
+			}
+
+			added := 0
+			visited := scanAll(5, func() {
+				dataStore.Put(fmt.Sprintf("new%d", added), "value", nil)
+				dataStore.Delete(fmt.Sprintf("key%d", 50+added))
+				added++
+			})
+
+			for i := 0; i < 50; i++ {
+				Expect(visited).To(HaveKey(fmt.Sprintf("key%d", i)))
+			}
+		})
+
+		It("should complete right away on an empty store", func() {
+			Expect(dataStore.Scan(0, 10, func(string, *store.Value) {})).To(Equal(uint64(0)))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// values made of more elements than this are freed in the background when lazy freeing is on.
+	// tearing down smaller values inline is cheaper than handing them over to the freer.
+	LAZYFREE_THRESHOLD = 64
+
+	// number of free jobs that can be queued before the values get freed inline again.
+	lazyFreeQueueSize = 1024
+)
+
+// A value made of many elements (eg. a hash), which takes time to tear down.
+type freeable interface {
+	Len() int
+	Clear()
+}
+
+// LazyFreer frees values and whole databases on a background goroutine, so that deleting
+// a large value doesn't block the event loop while it is torn down.
+type LazyFreer struct {
+	jobs chan func()
+
+	// number of objects queued to be freed, and the number freed so far.
+	pending atomic.Int64
+	freed   atomic.Int64
+}
+
+// returns a new freer, with its background goroutine running.
+func NewLazyFreer() *LazyFreer {
+	f := &LazyFreer{
+		jobs: make(chan func(), lazyFreeQueueSize),
+	}
+	go f.run()
+	return f
+}
+
+var (
+	lazyFreerInstance *LazyFreer
+	lazyFreerOnce     sync.Once
+)
+
+// returns the freer shared by all the databases. It may be first called from any goroutine, eg.
+// the event loop or the cron of an embedded engine.
+func GetLazyFreer() *LazyFreer {
+	lazyFreerOnce.Do(func() {
+		lazyFreerInstance = NewLazyFreer()
+	})
+
+	return lazyFreerInstance
+}
+
+func (f *LazyFreer) run() {
+	for job := range f.jobs {
+		job()
+	}
+}
+
+// FreeValue tears the value down. The value is freed in the background if lazy is set
+// and it is large enough for that to pay off, and right away otherwise.
+func (f *LazyFreer) FreeValue(value *Value, lazy bool) {
+	if value == nil {
+		return
+	}
+
+	v, ok := value.Value.(freeable)
+	if !ok {
+		// a plain value, eg. a string, has nothing to tear down, it is left to the garbage collector.
+		return
+	}
+
+	if lazy && v.Len() > LAZYFREE_THRESHOLD {
+		f.submit(1, v.Clear)
+		return
+	}
+	v.Clear()
+}
+
+// frees the tables of a database that has been flushed, in the background.
+func (f *LazyFreer) freeTables(data *Dict[*Value], keyMetadata *Dict[*KeyMetadata], expiries *Dict[*int64]) {
+	f.submit(int64(data.Len()), func() {
+		data.ForEach(func(key string, value *Value) bool {
+			f.FreeValue(value, false)
+			return true
+		})
+		data.Clear()
+		keyMetadata.Clear()
+		expiries.Clear()
+	})
+}
+
+// queues the freeing of n objects. the objects are freed inline if the queue is full.
+func (f *LazyFreer) submit(n int64, free func()) {
+	f.pending.Add(n)
+	job := func() {
+		free()
+		f.pending.Add(-n)
+		f.freed.Add(n)
+	}
+
+	select {
+	case f.jobs <- job:
+	default:
+		job()
+	}
+}
+
+// returns the number of objects waiting to be freed.
+func (f *LazyFreer) PendingObjects() int64 {
+	return f.pending.Load()
+}
+
+// returns the number of objects freed in the background so far.
+func (f *LazyFreer) FreedObjects() int64 {
+	return f.freed.Load()
+}
@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// sets a hash with n fields at the key.
+func putHash(dataStore *store.DataStore, key string, n int) *store.Dict[string] {
+	fieldValues := []string{}
+	for i := 0; i < n; i++ {
+		fieldValues = append(fieldValues, fmt.Sprintf("field-%d", i), "value")
+	}
+	_, err := dataStore.HSet(key, fieldValues)
+	Expect(err).NotTo(HaveOccurred())
+
+	hash, err := dataStore.Peek(key).Hash()
+	Expect(err).NotTo(HaveOccurred())
+	return hash
+}
+
+var _ = Describe("LazyFreer", func() {
+	var dataStore *store.DataStore
+
+	BeforeEach(func() {
+		dataStore = store.NewDataStore()
+		for i := 0; i < 10; i++ {
+			dataStore.Put(fmt.Sprintf("key-%d", i), "value", nil)
+		}
+	})
+
+	It("should free the tables of a database flushed asynchronously in the background", func() {
+		freer := store.GetLazyFreer()
+		// the freer is shared with the other specs, which may have left some objects to free.
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+
+		dataStore.ResetAsync()
+
+		Expect(dataStore.Get("key-0")).To(BeNil())
+		Eventually(freer.FreedObjects).Should(Equal(freed + 10))
+		Eventually(freer.PendingObjects).Should(BeZero())
+	})
+
+	It("should free the tables of a database flushed synchronously right away", func() {
+		freer := store.GetLazyFreer()
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+
+		dataStore.Reset()
+
+		Expect(dataStore.Get("key-0")).To(BeNil())
+		Consistently(freer.FreedObjects).Should(Equal(freed))
+	})
+
+	It("should free the large values of the unlinked keys in the background", func() {
+		freer := store.GetLazyFreer()
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+		hash := putHash(dataStore, "hash", store.LAZYFREE_THRESHOLD+1)
+
+		Expect(dataStore.Unlink("hash")).To(BeTrue())
+
+		Eventually(freer.FreedObjects).Should(Equal(freed + 1))
+		Expect(hash.Len()).To(BeZero())
+	})
+
+	It("should free the small values right away", func() {
+		freer := store.GetLazyFreer()
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+		hash := putHash(dataStore, "hash", store.LAZYFREE_THRESHOLD)
+
+		Expect(dataStore.Unlink("hash")).To(BeTrue())
+
+		Expect(hash.Len()).To(BeZero())
+		Expect(freer.FreedObjects()).To(Equal(freed))
+	})
+
+	It("should only free the values deleted by DEL in the background with lazyfree-lazy-user-del", func() {
+		userDel := config.LazyfreeLazyUserDel
+		DeferCleanup(func() {
+			config.LazyfreeLazyUserDel = userDel
+		})
+		freer := store.GetLazyFreer()
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+
+		config.LazyfreeLazyUserDel = false
+		Expect(dataStore.Delete(fmt.Sprintf("key-%d", 0))).To(BeTrue())
+		putHash(dataStore, "hash", store.LAZYFREE_THRESHOLD+1)
+		Expect(dataStore.Delete("hash")).To(BeTrue())
+		Expect(freer.FreedObjects()).To(Equal(freed))
+
+		config.LazyfreeLazyUserDel = true
+		hash := putHash(dataStore, "hash", store.LAZYFREE_THRESHOLD+1)
+		Expect(dataStore.Delete("hash")).To(BeTrue())
+		Eventually(freer.FreedObjects).Should(Equal(freed + 1))
+		Expect(hash.Len()).To(BeZero())
+	})
+
+	It("should free the values overwritten by SET in the background with lazyfree-lazy-server-del", func() {
+		serverDel := config.LazyfreeLazyServerDel
+		config.LazyfreeLazyServerDel = true
+		DeferCleanup(func() {
+			config.LazyfreeLazyServerDel = serverDel
+		})
+		freer := store.GetLazyFreer()
+		Eventually(freer.PendingObjects).Should(BeZero())
+		freed := freer.FreedObjects()
+		hash := putHash(dataStore, "hash", store.LAZYFREE_THRESHOLD+1)
+
+		dataStore.Put("hash", "value", nil)
+
+		Expect(dataStore.Get("hash").Value).To(Equal("value"))
+		Eventually(freer.FreedObjects).Should(Equal(freed + 1))
+		Expect(hash.Len()).To(BeZero())
+	})
+})
@@ -2,48 +2,124 @@ package store
 
 import (
 	"log"
+	"time"
 
 	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/stats"
 	"github.com/shashwatrathod/redis-internals/utils"
 )
 
 type DataStore struct {
-	data                 map[string]*Value
+	data                 *Dict[*Value]
 	autoDeletionStrategy AutoDeletionStrategy
 	evictionStrategy     EvictionStrategy
-	keyMetadata          map[string]*KeyMetadata
-	expiries             map[string]*int64
+	keyMetadata          *Dict[*KeyMetadata]
+	expiries             *Dict[*int64]
+	observers            []KeyspaceObserver
+	watchedKeys          map[string]*watchedKey
+
+	// set while the keys are being expired by the auto deletion strategy, to tell the active
+	// expirations apart from the passive ones that happen on access.
+	activeExpiry bool
+
+	// set while the last access times of the keys are not to be updated, see SetNoTouch.
+	noTouch bool
+
+	// the number of keys the store holds before evicting some, see SetMaxKeys.
+	maxKeys int
+
+	// the stats and the latency monitor the store reports to, see SetStats.
+	stats          *stats.Stats
+	latencyMonitor *stats.LatencyMonitor
+}
+
+// sets the number of keys the store holds before evicting some, eg. for the databases of an
+// embedded engine. config.MaxKeys applies if 0.
+func (s *DataStore) SetMaxKeys(maxKeys int) {
+	s.maxKeys = maxKeys
+}
+
+// returns the number of keys the store holds before evicting some.
+func (s *DataStore) keyLimit() int {
+	if s.maxKeys > 0 {
+		return s.maxKeys
+	}
+	return config.MaxKeys
+}
+
+// sets the stats and the latency monitor the store reports its hits, misses, expired and
+// evicted keys to, eg. those of an embedded engine. The ones of the server apply if nil.
+func (s *DataStore) SetStats(counters *stats.Stats, latencyMonitor *stats.LatencyMonitor) {
+	s.stats = counters
+	s.latencyMonitor = latencyMonitor
+}
+
+// returns the stats the store reports to.
+func (s *DataStore) getStats() *stats.Stats {
+	if s.stats != nil {
+		return s.stats
+	}
+	return stats.GetStats()
+}
+
+// returns the latency monitor the store reports to.
+func (s *DataStore) getLatencyMonitor() *stats.LatencyMonitor {
+	if s.latencyMonitor != nil {
+		return s.latencyMonitor
+	}
+	return stats.GetLatencyMonitor()
+}
+
+// version tracking information of a key that is being watched.
+type watchedKey struct {
+	version  uint64
+	watchers int
 }
 
 func (s *DataStore) Put(key string, value string, expiry *utils.ExpiryTime) {
-	if s.KeyCount() >= config.MaxKeys {
+	if s.KeyCount() >= s.keyLimit() {
 		s.Evict()
 	}
 
 	// todo: better error handling to account for inefficient eviction.
-	if s.KeyCount() >= config.MaxKeys {
+	if s.KeyCount() >= s.keyLimit() {
 		return
 	}
 
 	var keyMetadata *KeyMetadata = newKeyMetadata()
 
-	if s.data[key] != nil && s.keyMetadata[key] != nil {
-		keyMetadata = s.GetKeyMetadata(key)
+	_, exists := s.data.Get(key)
+	isNewKey := !exists
+
+	if existing := s.GetKeyMetadata(key); !isNewKey && existing != nil {
+		keyMetadata = existing
 		// Update the LastAccessedTs to Now if the key already exists.
-		keyMetadata.LastAccessedTimestamp = utils.GetCurrentLruTime()
+		if !s.noTouch {
+			keyMetadata.LastAccessedTimestamp = utils.GetCurrentLruTime()
+		}
 	}
 
-	s.data[key] = &Value{
+	old, _ := s.data.Get(key)
+	s.data.Set(key, &Value{
 		Value:     value,
 		ValueType: String, // TODO: Add more types
+	})
+	s.keyMetadata.Set(key, keyMetadata)
+
+	if !isNewKey {
+		GetLazyFreer().FreeValue(old, config.LazyfreeLazyServerDel)
+	}
+
+	if isNewKey {
+		s.notify(NewKeyEvent, NewKeyEventName, key)
 	}
-	s.keyMetadata[key] = keyMetadata
+	s.notify(StringEvent, SetEventName, key)
 
 	s.SetExpiry(key, expiry)
 }
 
 func (s *DataStore) GetExpiry(key string) *int64 {
-	exp, exists := s.expiries[key]
+	exp, exists := s.expiries.Get(key)
 
 	if !exists || exp == nil {
 		return nil
@@ -53,81 +129,413 @@ func (s *DataStore) GetExpiry(key string) *int64 {
 }
 
 func (s *DataStore) Get(key string) *Value {
-	_, exists := s.data[key]
+	_, exists := s.data.Get(key)
 
 	// Passively delete a key if it is found to be expired.
 	if exists && s.isExpired(key) {
-		s.Delete(key)
+		s.DeleteExpired(key)
 	}
 
-	if metadata := s.GetKeyMetadata(key); metadata != nil {
+	if metadata := s.GetKeyMetadata(key); metadata != nil && !s.noTouch {
 		metadata.LastAccessedTimestamp = utils.GetCurrentLruTime()
 	}
 
-	return s.data[key]
+	value, exists := s.data.Get(key)
+	if !exists {
+		s.getStats().KeyspaceMisses.Add(1)
+		s.notify(KeyMissEvent, KeyMissEventName, key)
+	} else {
+		s.getStats().KeyspaceHits.Add(1)
+	}
+
+	return value
+}
+
+func (s *DataStore) Peek(key string) *Value {
+	if !s.exists(key) {
+		return nil
+	}
+	value, _ := s.data.Get(key)
+	return value
+}
+
+func (s *DataStore) SetNoTouch(noTouch bool) {
+	s.noTouch = noTouch
+}
+
+func (s *DataStore) HSet(key string, fieldValues []string) (int, error) {
+	value := s.Peek(key)
+	isNewKey := value == nil
+	if isNewKey {
+		if s.KeyCount() >= s.keyLimit() {
+			s.Evict()
+		}
+		// like Put, nothing is set if no key could be evicted.
+		if s.KeyCount() >= s.keyLimit() {
+			return 0, nil
+		}
+
+		value = &Value{
+			Value:     NewDict[string](),
+			ValueType: Hash,
+		}
+		s.data.Set(key, value)
+		s.keyMetadata.Set(key, newKeyMetadata())
+	} else if metadata := s.GetKeyMetadata(key); metadata != nil && !s.noTouch {
+		metadata.LastAccessedTimestamp = utils.GetCurrentLruTime()
+	}
+
+	hash, err := value.Hash()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for i := 0; i+1 < len(fieldValues); i += 2 {
+		if hash.Set(fieldValues[i], fieldValues[i+1]) {
+			added++
+		}
+	}
+
+	if isNewKey {
+		s.notify(NewKeyEvent, NewKeyEventName, key)
+	}
+	s.notify(HashEvent, HSetEventName, key)
+	return added, nil
+}
+
+func (s *DataStore) HDel(key string, fields []string) (int, error) {
+	value := s.Peek(key)
+	if value == nil {
+		return 0, nil
+	}
+
+	hash, err := value.Hash()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, field := range fields {
+		if _, exists := hash.Delete(field); exists {
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	s.notify(HashEvent, HDelEventName, key)
+	// like Redis, a hash never stays empty.
+	if hash.Len() == 0 {
+		s.remove(key, false)
+		s.notify(GenericEvent, DelEventName, key)
+	}
+	return deleted, nil
 }
 
 // returns whether the given key has expired. returns false if the key doesn't exist,
 // or if there is no expiry set on the key.
 func (s *DataStore) isExpired(key string) bool {
-	exp, exists := s.expiries[key]
+	exp, exists := s.expiries.Get(key)
 
 	if !exists || exp == nil {
 		return false
 	}
 
-	return utils.FromExpiryInUnixTime(*exp).IsExpired()
+	return utils.FromExpiryInUnixMilli(*exp).IsExpired()
 }
 
 func (s *DataStore) SetExpiry(key string, expiry *utils.ExpiryTime) {
-
-	if exists := s.Get(key); exists == nil {
+	if _, exists := s.data.Get(key); !exists || s.isExpired(key) {
 		return
 	}
 
 	if expiry == nil {
-		delete(s.expiries, key)
+		s.expiries.Delete(key)
 		return
 	}
 
-	timestamp := expiry.ToUnixTimestamp()
-	s.expiries[key] = &timestamp
+	timestamp := expiry.ToUnixMilli()
+	s.expiries.Set(key, &timestamp)
+	s.notify(GenericEvent, ExpireEventName, key)
+
+	// the key is already past its expiry, eg. when it is set with a negative TTL.
+	if expiry.IsExpired() {
+		s.DeleteExpired(key)
+	}
 }
 
 func (s *DataStore) Delete(key string) bool {
-	if _, exists := s.data[key]; exists {
-		delete(s.data, key)
-		delete(s.keyMetadata, key)
-		delete(s.expiries, key)
-		return true
+	if !s.remove(key, config.LazyfreeLazyUserDel) {
+		return false
+	}
+
+	s.notify(GenericEvent, DelEventName, key)
+	return true
+}
+
+func (s *DataStore) Unlink(key string) bool {
+	if !s.remove(key, true) {
+		return false
 	}
-	return false
+
+	s.notify(GenericEvent, DelEventName, key)
+	return true
+}
+
+func (s *DataStore) DeleteExpired(key string) bool {
+	if !s.remove(key, config.LazyfreeLazyExpire) {
+		return false
+	}
+
+	s.getStats().RecordExpiredKey(s.activeExpiry)
+	s.notify(ExpiredEvent, ExpiredEventName, key)
+	return true
+}
+
+func (s *DataStore) DeleteEvicted(key string) bool {
+	if !s.remove(key, config.LazyfreeLazyEviction) {
+		return false
+	}
+
+	s.getStats().RecordEvictedKey(s.evictionStrategy.Name())
+	s.notify(EvictedEvent, EvictedEventName, key)
+	return true
+}
+
+// removes the key and everything associated with it from the store, and frees its value,
+// in the background if lazy is set. returns true if the key was present in the store, else false.
+func (s *DataStore) remove(key string, lazy bool) bool {
+	value, exists := s.unlink(key)
+	if exists {
+		GetLazyFreer().FreeValue(value, lazy)
+	}
+	return exists
+}
+
+// removes the key and everything associated with it from the store, leaving its value untouched,
+// eg. after it has been moved to another key. returns the value, and false if the key wasn't present.
+func (s *DataStore) unlink(key string) (*Value, bool) {
+	value, exists := s.data.Delete(key)
+	if exists {
+		s.keyMetadata.Delete(key)
+		s.expiries.Delete(key)
+	}
+	return value, exists
 }
 
 func (s *DataStore) Reset() {
-	s.data = make(map[string]*Value)
-	s.keyMetadata = make(map[string]*KeyMetadata)
-	s.expiries = make(map[string]*int64)
+	s.notifyFlush()
+
+	s.data.Clear()
+	s.keyMetadata.Clear()
+	s.expiries.Clear()
 }
 
-func (s *DataStore) AutoDeleteExpiredKeys() {
-	s.autoDeletionStrategy.Execute(s)
+func (s *DataStore) ResetAsync() {
+	s.notifyFlush()
+
+	data, keyMetadata, expiries := s.data, s.keyMetadata, s.expiries
+	s.data = NewDict[*Value]()
+	s.keyMetadata = NewDict[*KeyMetadata]()
+	s.expiries = NewDict[*int64]()
+
+	// nothing else refers to the old tables anymore, so they can be freed off the event loop.
+	GetLazyFreer().freeTables(data, keyMetadata, expiries)
 }
 
-func (s *DataStore) ForEach(fn func(key string, value *Value) bool) {
-	for k, v := range s.data {
-		if !fn(k, v) {
+// swaps the keys of the two stores. the observers and watchers stay with their stores.
+func (s *DataStore) swapContents(other *DataStore) {
+	s.notifyFlush()
+	other.notifyFlush()
+
+	s.data, other.data = other.data, s.data
+	s.keyMetadata, other.keyMetadata = other.keyMetadata, s.keyMetadata
+	s.expiries, other.expiries = other.expiries, s.expiries
+}
+
+// bumps the versions of all the watched keys and notifies the observers, when every key in the
+// store is being modified.
+func (s *DataStore) notifyFlush() {
+	for _, wk := range s.watchedKeys {
+		wk.version++
+	}
+
+	for _, observer := range s.observers {
+		observer.OnKeyspaceEvent(KeyspaceEvent{
+			Class: FlushEvent,
+			Name:  FlushEventName,
+		})
+	}
+}
+
+func (s *DataStore) Move(key string, dest Store) bool {
+	target, ok := dest.(*DataStore)
+	if !ok || target == s || !s.exists(key) || target.exists(key) {
+		return false
+	}
+
+	if target.KeyCount() >= target.keyLimit() {
+		target.Evict()
+	}
+	if target.KeyCount() >= target.keyLimit() {
+		return false
+	}
+
+	s.transfer(key, target, key)
+	s.unlink(key)
+
+	s.notify(GenericEvent, MoveFromEventName, key)
+	target.notify(GenericEvent, MoveToEventName, key)
+	return true
+}
+
+func (s *DataStore) Rename(key string, newKey string, replace bool) bool {
+	if !s.exists(key) {
+		return false
+	}
+	if key == newKey {
+		return replace
+	}
+	if s.exists(newKey) && !replace {
+		return false
+	}
+
+	s.remove(newKey, config.LazyfreeLazyServerDel)
+	s.transfer(key, s, newKey)
+	s.unlink(key)
+
+	s.notify(GenericEvent, RenameFromEventName, key)
+	s.notify(GenericEvent, RenameToEventName, newKey)
+	return true
+}
+
+func (s *DataStore) Copy(key string, dest Store, newKey string, replace bool) bool {
+	target, ok := dest.(*DataStore)
+	if !ok || (target == s && key == newKey) || !s.exists(key) {
+		return false
+	}
+
+	if target.exists(newKey) {
+		if !replace {
+			return false
+		}
+		target.remove(newKey, config.LazyfreeLazyServerDel)
+	}
+
+	if target.KeyCount() >= target.keyLimit() {
+		target.Evict()
+	}
+	if target.KeyCount() >= target.keyLimit() {
+		return false
+	}
+
+	// the copy gets its own value, metadata and expiry, so that they can change independently.
+	value, _ := s.data.Get(key)
+	target.data.Set(newKey, value.clone())
+	target.keyMetadata.Set(newKey, newKeyMetadata())
+	if exp, hasExpiry := s.expiries.Get(key); hasExpiry {
+		expiry := *exp
+		target.expiries.Set(newKey, &expiry)
+	}
+
+	target.notify(GenericEvent, CopyToEventName, newKey)
+	return true
+}
+
+// sets the value, the metadata and the expiry of the key as newKey in the target store,
+// which may be this store. the key is left in place, it is up to the caller to remove it.
+func (s *DataStore) transfer(key string, target *DataStore, newKey string) {
+	value, _ := s.data.Get(key)
+	target.data.Set(newKey, value)
+	if metadata, hasMetadata := s.keyMetadata.Get(key); hasMetadata {
+		target.keyMetadata.Set(newKey, metadata)
+	}
+	if exp, hasExpiry := s.expiries.Get(key); hasExpiry {
+		target.expiries.Set(newKey, exp)
+	}
+}
+
+func (s *DataStore) RandomKey() (string, bool) {
+	for s.data.Len() > 0 {
+		key, _ := s.data.RandomKey()
+		if s.exists(key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (s *DataStore) SampleKeys(count int) []string {
+	return s.data.SampleKeys(count)
+}
+
+func (s *DataStore) SampleKeysWithExpiry(count int) []string {
+	return s.expiries.SampleKeys(count)
+}
+
+// The cursor is the dict's reverse binary cursor, see Dict.Scan. Like Redis, the batch is
+// made of whole buckets, and a few more buckets are visited if the first ones are empty.
+func (s *DataStore) Scan(cursor uint64, count int, fn func(key string, value *Value)) uint64 {
+	visited := 0
+	for maxBuckets := count * 10; maxBuckets > 0; maxBuckets-- {
+		cursor = s.data.Scan(cursor, func(key string, value *Value) {
+			visited++
+			fn(key, value)
+		})
+
+		if cursor == 0 || visited >= count {
 			break
 		}
 	}
+	return cursor
+}
+
+func (s *DataStore) Rehash(budget time.Duration) {
+	for _, dict := range []interface{ RehashFor(time.Duration) bool }{s.data, s.keyMetadata, s.expiries} {
+		dict.RehashFor(budget)
+	}
+}
+
+// returns whether the key exists in the store, passively deleting it if it has expired.
+// unlike Get, it neither touches the key nor counts as a miss.
+func (s *DataStore) exists(key string) bool {
+	if _, exists := s.data.Get(key); !exists {
+		return false
+	}
+
+	if s.isExpired(key) {
+		s.DeleteExpired(key)
+		return false
+	}
+	return true
+}
+
+func (s *DataStore) AutoDeleteExpiredKeys() {
+	s.activeExpiry = true
+	defer func() {
+		s.activeExpiry = false
+	}()
+
+	start := time.Now()
+	s.autoDeletionStrategy.Execute(s)
+	s.getLatencyMonitor().Sample(stats.LatencyEventExpireCycle, time.Since(start))
+}
+
+func (s *DataStore) ForEach(fn func(key string, value *Value) bool) {
+	s.data.ForEach(fn)
 }
 
 func (s *DataStore) GetKeyMetadata(key string) *KeyMetadata {
-	return s.keyMetadata[key]
+	metadata, _ := s.keyMetadata.Get(key)
+	return metadata
 }
 
 func (s *DataStore) Evict() int {
+	start := time.Now()
 	nKeysEvicted, err := s.evictionStrategy.Execute(s)
+	s.getLatencyMonitor().Sample(stats.LatencyEventEvictionCycle, time.Since(start))
 
 	if err != nil {
 		log.Printf("Encountered an error while trying to evict keys : %s", err.Error())
@@ -137,5 +545,57 @@ func (s *DataStore) Evict() int {
 }
 
 func (s *DataStore) KeyCount() int {
-	return len(s.data)
+	return s.data.Len()
+}
+
+func (s *DataStore) ExpiryCount() int {
+	return s.expiries.Len()
+}
+
+func (s *DataStore) AddKeyspaceObserver(observer KeyspaceObserver) {
+	s.observers = append(s.observers, observer)
+}
+
+func (s *DataStore) Watch(key string) uint64 {
+	wk, exists := s.watchedKeys[key]
+	if !exists {
+		wk = &watchedKey{}
+		s.watchedKeys[key] = wk
+	}
+	wk.watchers++
+	return wk.version
+}
+
+func (s *DataStore) Unwatch(key string) {
+	wk, exists := s.watchedKeys[key]
+	if !exists {
+		return
+	}
+	wk.watchers--
+	if wk.watchers <= 0 {
+		delete(s.watchedKeys, key)
+	}
+}
+
+func (s *DataStore) KeyVersion(key string) uint64 {
+	if wk, exists := s.watchedKeys[key]; exists {
+		return wk.version
+	}
+	return 0
+}
+
+// notifies all the observers about the change made to the key,
+// and invalidates the watchers of the key if it was modified.
+func (s *DataStore) notify(class KeyspaceEventClass, name string, key string) {
+	if wk, exists := s.watchedKeys[key]; exists && class != KeyMissEvent {
+		wk.version++
+	}
+
+	for _, observer := range s.observers {
+		observer.OnKeyspaceEvent(KeyspaceEvent{
+			Class: class,
+			Name:  name,
+			Key:   key,
+		})
+	}
 }
@@ -3,10 +3,12 @@ package store_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/stats"
 	"github.com/shashwatrathod/redis-internals/core/store"
 	"github.com/shashwatrathod/redis-internals/utils"
 )
@@ -22,7 +24,7 @@ var _ = Describe("SimpleDataStore", func() {
 	)
 
 	BeforeEach(func() {
-		dataStore = store.GetStore()
+		dataStore = store.NewDataStore()
 	})
 
 	AfterEach(func() {
@@ -78,6 +80,39 @@ var _ = Describe("SimpleDataStore", func() {
 			dataStore.Put("key", value.Value.(string), pastTime)
 			Expect(dataStore.Get("key")).To(BeNil())
 		})
+
+		It("should count the keyspace hits, misses and expired keys", func() {
+			serverStats := stats.GetStats()
+			hits, misses, expired := serverStats.KeyspaceHits.Load(), serverStats.KeyspaceMisses.Load(), serverStats.ExpiredKeys.Load()
+
+			dataStore.Put("key", "value", nil)
+			dataStore.Put("expired", "value", utils.FromExpiryInSeconds(10))
+			dataStore.SetExpiry("expired", utils.FromExpiryInMilliseconds(-1000))
+
+			dataStore.Get("key")
+			dataStore.Get("nonexistent")
+			dataStore.Get("expired")
+
+			Expect(serverStats.KeyspaceHits.Load() - hits).To(Equal(int64(1)))
+			Expect(serverStats.KeyspaceMisses.Load() - misses).To(Equal(int64(2)))
+			Expect(serverStats.ExpiredKeys.Load() - expired).To(Equal(int64(1)))
+		})
+
+		It("should tell the keys expired on access apart from the ones expired by the expire cycles", func() {
+			serverStats := stats.GetStats()
+			active, passive := serverStats.ActiveExpiredKeys.Load(), serverStats.PassiveExpiredKeys.Load()
+
+			dataStore.Put("passive", "value", utils.FromExpiryInMilliseconds(-1000))
+			dataStore.Get("passive")
+			// a key set with an expiry in the past is expired right away, so let this one expire.
+			dataStore.Put("active", "value", utils.FromExpiryInMilliseconds(10))
+			Eventually(func() int64 {
+				dataStore.AutoDeleteExpiredKeys()
+				return serverStats.ActiveExpiredKeys.Load() - active
+			}, time.Second, 5*time.Millisecond).Should(Equal(int64(1)))
+
+			Expect(serverStats.PassiveExpiredKeys.Load() - passive).To(Equal(int64(1)))
+		})
 	})
 
 	Describe("Delete", func() {
@@ -3,6 +3,7 @@ package store
 import (
 	"time"
 
+	"github.com/shashwatrathod/redis-internals/commons"
 	"github.com/shashwatrathod/redis-internals/config"
 	"github.com/shashwatrathod/redis-internals/core/resp"
 	"github.com/shashwatrathod/redis-internals/utils"
@@ -17,6 +18,12 @@ const (
 	Array                      = resp.RespArray
 )
 
+// datatypes that have no RESP counterpart.
+const (
+	// a hash of fields to string values, held in a *Dict[string].
+	Hash SupportedDatatypes = SupportedDatatypes(resp.RespInteger) + 1 + iota
+)
+
 const (
 	AUTO_EXPIRE_SEARCH_LIMIT                      = 20
 	AUTO_EXPIRE_ALLOWABLE_EXPIRE_FRACTION float32 = 0.25
@@ -29,11 +36,39 @@ type Store interface {
 	// returns the value of the given key if it exists in the store, else returns nil.
 	Get(key string) *Value
 
+	// like Get, but neither updates the last access time of the key, nor counts as a keyspace miss.
+	Peek(key string) *Value
+
+	// while set, accessing the keys doesn't update their last access times, eg. for the commands
+	// of the clients with CLIENT NO-TOUCH on.
+	SetNoTouch(noTouch bool)
+
+	// sets the fields of the hash at the given key to the values, given as field value pairs. The hash
+	// is created if the key doesn't exist. returns the number of fields that were added, and an error
+	// if the key holds another type of value.
+	HSet(key string, fieldValues []string) (int, error)
+
+	// deletes the fields from the hash at the given key, and the key along with the last of them.
+	// returns the number of fields that were deleted, and an error if the key holds another type of value.
+	HDel(key string, fields []string) (int, error)
+
 	// deletes the given key from the store.
 	// returns true if the key was present in the store, else false.
 	Delete(key string) bool
 
-	// returns the expiry timestamp of the given key.
+	// deletes the given key from the store like Delete, but always frees its value in the background.
+	// returns true if the key was present in the store, else false.
+	Unlink(key string) bool
+
+	// deletes the given key from the store because it has expired.
+	// returns true if the key was present in the store, else false.
+	DeleteExpired(key string) bool
+
+	// deletes the given key from the store to make room for other keys.
+	// returns true if the key was present in the store, else false.
+	DeleteEvicted(key string) bool
+
+	// returns the expiry timestamp of the given key, as a unix time in milliseconds.
 	// returns null if they key doesn't exist or if there is no expiry set on the key.
 	GetExpiry(key string) *int64
 
@@ -49,6 +84,9 @@ type Store interface {
 	// resets the data in the store. DELETES all the keys. WARNING : Irreversable operation!
 	Reset()
 
+	// like Reset, but the deleted keys are freed in the background instead of blocking the caller.
+	ResetAsync()
+
 	// executes the function for each key value pair in the datastore.
 	// the fn should return false if the iteration is to be terminated early, else true.
 	ForEach(func(key string, value *Value) bool)
@@ -63,6 +101,52 @@ type Store interface {
 
 	// returns the number of keys present in the datastore at the moment.
 	KeyCount() int
+
+	// returns the number of keys with an expiry in the datastore.
+	ExpiryCount() int
+
+	// starts tracking the version of the given key, which gets bumped everytime the key
+	// is modified, expired or evicted. returns the current version of the key.
+	// every call to Watch must be paired with a call to Unwatch.
+	Watch(key string) uint64
+
+	// stops tracking the version of the given key once it has no watchers left.
+	Unwatch(key string)
+
+	// returns the current version of a watched key.
+	KeyVersion(key string) uint64
+
+	// moves the key, along with its expiry, into the destination store.
+	// returns false, without moving it, if the key doesn't exist or already exists in the destination.
+	Move(key string, dest Store) bool
+
+	// renames the key, along with its expiry, to newKey. an existing newKey is only overwritten if replace is set.
+	// returns false, without renaming it, if the key doesn't exist or newKey exists and is not to be replaced.
+	Rename(key string, newKey string, replace bool) bool
+
+	// copies the value and the expiry of the key to newKey in the destination store, which may be this store.
+	// an existing newKey is only overwritten if replace is set. returns false, without copying it, if the key
+	// doesn't exist or newKey exists and is not to be replaced.
+	Copy(key string, dest Store, newKey string, replace bool) bool
+
+	// returns a random key from the store, and false if the store is empty.
+	RandomKey() (string, bool)
+
+	// returns up to count keys sampled at random from the store, eg. as the candidates for eviction.
+	SampleKeys(count int) []string
+
+	// returns up to count keys sampled at random from the keys with an expiry.
+	SampleKeysWithExpiry(count int) []string
+
+	// visits a batch of roughly count keys, starting at the cursor, and returns the cursor to
+	// continue from, which is 0 once all the keys have been visited. Start scanning at cursor 0.
+	// every key that is present for the whole scan is visited at least once, even if the store
+	// is modified in between the calls.
+	Scan(cursor uint64, count int, fn func(key string, value *Value)) uint64
+
+	// incrementally rehashes the store's tables for up to the given duration, if they are being resized.
+	// the tables are also rehashed a bit on every access, this lets the idle stores catch up.
+	Rehash(budget time.Duration)
 }
 
 // Represents a Value that can be stored in the datastore.
@@ -71,6 +155,41 @@ type Value struct {
 	ValueType SupportedDatatypes
 }
 
+// returns the name of the value's type, as replied by TYPE.
+func (v *Value) TypeName() string {
+	switch v.ValueType {
+	case String:
+		return "string"
+	case Hash:
+		return "hash"
+	default:
+		return "none"
+	}
+}
+
+// returns the fields of the hash held by the value, and an error if it holds another type of value.
+func (v *Value) Hash() (*Dict[string], error) {
+	hash, ok := v.Value.(*Dict[string])
+	if v.ValueType != Hash || !ok {
+		return nil, commons.WrongTypeErr()
+	}
+	return hash, nil
+}
+
+// returns a copy of the value, which can be modified without modifying the value.
+func (v *Value) clone() *Value {
+	copied := *v
+	if hash, err := v.Hash(); err == nil {
+		fields := NewDict[string]()
+		hash.ForEach(func(field string, value string) bool {
+			fields.Set(field, value)
+			return true
+		})
+		copied.Value = fields
+	}
+	return &copied
+}
+
 // contains information like last-accessed ts and created ts for a key in the store.
 type KeyMetadata struct {
 	// when the key was last accessed. gets updated everytime the key gets updated or fetched (via Get)
@@ -87,17 +206,14 @@ func newKeyMetadata() *KeyMetadata {
 	}
 }
 
-var storeInstance *DataStore
-
-func GetStore() *DataStore {
-	if storeInstance == nil {
-		storeInstance = &DataStore{
-			data:                 make(map[string]*Value),
-			keyMetadata:          make(map[string]*KeyMetadata),
-			autoDeletionStrategy: NewRandomSampleAutoDeletionStrategy(AUTO_EXPIRE_SEARCH_LIMIT, AUTO_EXPIRE_ALLOWABLE_EXPIRE_FRACTION), // TODO Make this configurable through additional config params or constructors.
-			evictionStrategy:     NewAllKeysLRUEvictionStrategy(config.LRUEvictionSampleSize),
-		}
+// returns a new, empty DataStore.
+func NewDataStore() *DataStore {
+	return &DataStore{
+		data:                 NewDict[*Value](),
+		keyMetadata:          NewDict[*KeyMetadata](),
+		expiries:             NewDict[*int64](),
+		watchedKeys:          make(map[string]*watchedKey),
+		autoDeletionStrategy: NewRandomSampleAutoDeletionStrategy(AUTO_EXPIRE_SEARCH_LIMIT, AUTO_EXPIRE_ALLOWABLE_EXPIRE_FRACTION), // TODO Make this configurable through additional config params or constructors.
+		evictionStrategy:     NewAllKeysLRUEvictionStrategy(config.LRUEvictionSampleSize),
 	}
-
-	return storeInstance
 }
@@ -0,0 +1,312 @@
+package tracking
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+// channel the invalidation messages are published to for the RESP2 clients, which can't
+// receive push messages. They have to subscribe to it on a separate connection, and redirect
+// the messages of the tracking connection to it.
+const InvalidationChannel = "__redis__:invalidate"
+
+// Tracker implements the server assisted client side caching enabled with CLIENT TRACKING. It
+// remembers the keys the tracking clients read, and tells them when those keys get modified,
+// expired or evicted, so that they can drop them from their caches.
+//
+// The keys are tracked by name, regardless of the database they were read from, like Redis does.
+type Tracker struct {
+	// the keys read by the clients tracking in the default mode, along with the ids of the
+	// clients that read them. a key is forgotten once its clients are told about it.
+	keys map[string]map[int64]struct{}
+
+	// the prefixes tracked by the clients in the BCAST mode, along with the clients tracking
+	// them. the empty prefix matches all the keys.
+	prefixes map[string]map[*client.Client]struct{}
+
+	// number of clients with tracking on.
+	clients int
+
+	// the client whose command is running. the invalidation messages sent to it are held until
+	// the command replied, so that they don't get in between the command and its reply.
+	current *client.Client
+	pending map[*client.Client][][]byte
+
+	// whether the clients were told to drop all their keys during the command, so that they are
+	// told only once when FLUSHALL flushes every database.
+	flushed bool
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		keys:     make(map[string]map[int64]struct{}),
+		prefixes: make(map[string]map[*client.Client]struct{}),
+		pending:  make(map[*client.Client][][]byte),
+	}
+}
+
+var trackerInstance *Tracker
+
+// returns the tracker of the server, which observes the keyspace of all the databases.
+func GetTracker() *Tracker {
+	if trackerInstance == nil {
+		trackerInstance = NewTracker()
+	}
+
+	return trackerInstance
+}
+
+// turns tracking on for the client with the given options, or updates the options if it is on
+// already. the prefixes of the BCAST mode add up to the ones the client tracks already, and
+// must not overlap with them nor with each other.
+func (t *Tracker) Enable(c *client.Client, options client.Tracking) error {
+	var existing []string
+	if c.Tracking != nil {
+		existing = c.Tracking.Prefixes
+	}
+
+	prefixes := options.Prefixes
+	if options.Bcast && len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	if options.Bcast {
+		if err := checkPrefixCollisions(existing, prefixes); err != nil {
+			return err
+		}
+	}
+
+	if c.Tracking == nil {
+		t.clients++
+	}
+
+	options.Prefixes = existing
+	for _, prefix := range prefixes {
+		if _, exists := t.prefixes[prefix]; !exists {
+			t.prefixes[prefix] = make(map[*client.Client]struct{})
+		}
+		t.prefixes[prefix][c] = struct{}{}
+		options.Prefixes = append(options.Prefixes, prefix)
+	}
+
+	c.Tracking = &options
+	return nil
+}
+
+// returns an error if any of the prefixes is a prefix of another one, or of an existing one.
+func checkPrefixCollisions(existing []string, prefixes []string) error {
+	for i, prefix := range prefixes {
+		for _, other := range existing {
+			if strings.HasPrefix(prefix, other) || strings.HasPrefix(other, prefix) {
+				return fmt.Errorf("ERR Prefix '%s' overlaps with an existing prefix '%s'. Prefixes for a single client must not overlap.", prefix, other)
+			}
+		}
+
+		for _, other := range prefixes[i+1:] {
+			if strings.HasPrefix(prefix, other) || strings.HasPrefix(other, prefix) {
+				return fmt.Errorf("ERR Prefix '%s' overlaps with another provided prefix '%s'. Prefixes for a single client must not overlap.", prefix, other)
+			}
+		}
+	}
+	return nil
+}
+
+// turns tracking off for the client. the keys it read are forgotten lazily, as they get modified.
+func (t *Tracker) Disable(c *client.Client) {
+	if c.Tracking == nil {
+		return
+	}
+
+	for _, prefix := range c.Tracking.Prefixes {
+		delete(t.prefixes[prefix], c)
+		if len(t.prefixes[prefix]) == 0 {
+			delete(t.prefixes, prefix)
+		}
+	}
+
+	c.Tracking = nil
+	t.clients--
+}
+
+// remembers that the client read the keys, if it tracks the keys it reads.
+func (t *Tracker) RememberKeys(c *client.Client, keys []string) {
+	tracking := c.Tracking
+	if tracking == nil || tracking.Bcast || (tracking.OptIn && !tracking.Caching) || (tracking.OptOut && tracking.Caching) {
+		return
+	}
+
+	for _, key := range keys {
+		if _, exists := t.keys[key]; !exists {
+			t.keys[key] = make(map[int64]struct{})
+		}
+		t.keys[key][c.Id] = struct{}{}
+	}
+
+	// make room in the table by telling the clients to drop some of their keys, as if they were modified.
+	for config.TrackingTableMaxKeys > 0 && len(t.keys) > config.TrackingTableMaxKeys {
+		for key := range t.keys {
+			t.invalidateKey(key, false)
+			break
+		}
+	}
+}
+
+// tells the clients that read the key, and the clients in the BCAST mode tracking its prefix,
+// that the key got modified.
+func (t *Tracker) Invalidate(key string) {
+	t.invalidateKey(key, true)
+}
+
+func (t *Tracker) invalidateKey(key string, bcast bool) {
+	if bcast {
+		for prefix, clients := range t.prefixes {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			for c := range clients {
+				t.send(c, []string{key})
+			}
+		}
+	}
+
+	ids, exists := t.keys[key]
+	if !exists {
+		return
+	}
+	delete(t.keys, key)
+
+	for id := range ids {
+		// the client may have disconnected, turned tracking off or switched to the BCAST mode since it read the key.
+		c := client.ById(id)
+		if c == nil || c.Tracking == nil || c.Tracking.Bcast {
+			continue
+		}
+		t.send(c, []string{key})
+	}
+}
+
+// tells all the tracking clients to drop their caches entirely, eg. when a database got flushed.
+func (t *Tracker) InvalidateAll() {
+	if t.flushed {
+		return
+	}
+	t.flushed = t.current != nil
+
+	if t.clients > 0 {
+		for _, c := range client.Connected() {
+			if c.Tracking != nil {
+				t.send(c, nil)
+			}
+		}
+	}
+
+	t.keys = make(map[string]map[int64]struct{})
+}
+
+// invalidates the keys modified in the databases the tracker observes.
+func (t *Tracker) OnKeyspaceEvent(event store.KeyspaceEvent) {
+	switch event.Class {
+	case store.KeyMissEvent, store.NewKeyEvent:
+		// a lookup doesn't modify the key, and a new key always comes along with another event.
+	case store.FlushEvent:
+		t.InvalidateAll()
+	default:
+		t.Invalidate(event.Key)
+	}
+}
+
+// sends the invalidation message for the keys, or for all the keys if keys is nil, to the
+// tracking client or to the client it redirects its messages to.
+func (t *Tracker) send(c *client.Client, keys []string) {
+	if c.Tracking.NoLoop && c == t.current {
+		// the client modified the key itself.
+		return
+	}
+
+	target := c
+	if c.Tracking.Redirect != 0 {
+		target = client.ById(c.Tracking.Redirect)
+		if target == nil {
+			// only the RESP3 clients can be told about it, as the RESP2 ones can't receive pushes.
+			if c.Protocol == resp.Resp3 && !c.Tracking.BrokenRedirect {
+				t.write(c, resp.EncodePush([]interface{}{"tracking-redir-broken", c.Tracking.Redirect}))
+			}
+			c.Tracking.BrokenRedirect = true
+			return
+		}
+	}
+
+	if target.Protocol == resp.Resp3 {
+		var payload interface{} = resp.Raw(resp.Null)
+		if keys != nil {
+			payload = keys
+		}
+		t.write(target, resp.EncodePush([]interface{}{"invalidate", payload}))
+		return
+	}
+
+	// the RESP2 clients only get the messages while they are subscribed to the invalidation channel.
+	if _, subscribed := target.Channels[InvalidationChannel]; subscribed {
+		var payload interface{} = resp.Raw(resp.NullBulkString)
+		if keys != nil {
+			payload = keys
+		}
+		t.write(target, resp.EncodeArray([]interface{}{"message", InvalidationChannel, payload}))
+	}
+}
+
+// writes the message to the client, unless the client is running a command, in which case the
+// message is held until the command replied.
+func (t *Tracker) write(c *client.Client, message []byte) {
+	if c == t.current {
+		t.pending[c] = append(t.pending[c], message)
+		return
+	}
+	c.Write(message)
+}
+
+// marks the client as the one whose command is running. returns the client whose command was
+// running before, if any, eg. a script that serves other clients while it's busy, which has to
+// be passed to EndCommand.
+func (t *Tracker) BeginCommand(c *client.Client) *client.Client {
+	previous := t.current
+	t.current = c
+	t.flushed = false
+	return previous
+}
+
+// sends the invalidation messages held while the command of the current client was running,
+// and restores the client whose command was running before.
+func (t *Tracker) EndCommand(previous *client.Client) {
+	if c := t.current; c != nil {
+		for _, message := range t.pending[c] {
+			c.Write(message)
+		}
+		delete(t.pending, c)
+	}
+	t.current = previous
+	t.flushed = false
+}
+
+// returns the number of clients with tracking on.
+func (t *Tracker) Clients() int {
+	return t.clients
+}
+
+// returns the number of keys in the tracking table, and the number of clients tracking them in total.
+func (t *Tracker) TotalKeys() (int, int) {
+	items := 0
+	for _, ids := range t.keys {
+		items += len(ids)
+	}
+	return len(t.keys), items
+}
+
+// returns the number of prefixes tracked by the clients in the BCAST mode.
+func (t *Tracker) TotalPrefixes() int {
+	return len(t.prefixes)
+}
@@ -0,0 +1,197 @@
+package tracking_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/core/tracking"
+)
+
+func TestTracking(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracking Suite")
+}
+
+// returns a connected RESP3 client, along with the connection its replies are written to.
+func newTestClient() (*client.Client, *bytes.Buffer) {
+	conn := &bytes.Buffer{}
+	c := client.NewClient(-1, conn)
+	c.Protocol = resp.Resp3
+	client.Register(c)
+	return c, conn
+}
+
+// flushes the client's replies and returns everything written since the last call.
+func flushed(c *client.Client, conn *bytes.Buffer) string {
+	_, err := c.Flush()
+	Expect(err).ToNot(HaveOccurred())
+
+	written := conn.String()
+	conn.Reset()
+	return written
+}
+
+// returns the number of keys in the tracking table of the tracker.
+func trackedKeys(tracker *tracking.Tracker) int {
+	keys, _ := tracker.TotalKeys()
+	return keys
+}
+
+var _ = Describe("Tracker", func() {
+	var (
+		tracker   *tracking.Tracker
+		dataStore *store.DataStore
+		c         *client.Client
+		conn      *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		tracker = tracking.NewTracker()
+		dataStore = store.NewDataStore()
+		dataStore.AddKeyspaceObserver(tracker)
+		c, conn = newTestClient()
+	})
+
+	AfterEach(func() {
+		c.Release()
+	})
+
+	It("should invalidate the keys the client read once they get modified", func() {
+		Expect(tracker.Enable(c, client.Tracking{})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+		Expect(trackedKeys(tracker)).To(Equal(1))
+
+		dataStore.Put("other", "value", nil)
+		Expect(flushed(c, conn)).To(BeEmpty())
+
+		dataStore.Put("key", "value", nil)
+		Expect(flushed(c, conn)).To(Equal(">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nkey\r\n"))
+
+		// the key is forgotten until the client reads it again.
+		dataStore.Put("key", "value", nil)
+		Expect(flushed(c, conn)).To(BeEmpty())
+	})
+
+	It("should only remember the keys read with CACHING yes in the OPTIN mode", func() {
+		Expect(tracker.Enable(c, client.Tracking{OptIn: true})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+		Expect(trackedKeys(tracker)).To(BeZero())
+
+		c.Tracking.Caching = true
+		tracker.RememberKeys(c, []string{"key"})
+		Expect(trackedKeys(tracker)).To(Equal(1))
+	})
+
+	It("should invalidate every key matching the prefixes in the BCAST mode", func() {
+		Expect(tracker.Enable(c, client.Tracking{Bcast: true, Prefixes: []string{"user:"}})).To(Succeed())
+		Expect(tracker.TotalPrefixes()).To(Equal(1))
+
+		dataStore.Put("user:1", "value", nil)
+		dataStore.Put("order:1", "value", nil)
+		Expect(flushed(c, conn)).To(Equal(">2\r\n$10\r\ninvalidate\r\n*1\r\n$6\r\nuser:1\r\n"))
+
+		Expect(tracker.Enable(c, client.Tracking{Bcast: true, Prefixes: []string{"user:admin:"}})).
+			To(MatchError(ContainSubstring("overlaps with an existing prefix 'user:'")))
+	})
+
+	It("should redirect the invalidations to the channel subscriber in RESP2", func() {
+		subscriber, subscriberConn := newTestClient()
+		defer subscriber.Release()
+		subscriber.Protocol = resp.Resp2
+		subscriber.Channels[tracking.InvalidationChannel] = struct{}{}
+
+		Expect(tracker.Enable(c, client.Tracking{Redirect: subscriber.Id})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+		dataStore.Put("key", "value", nil)
+
+		Expect(flushed(c, conn)).To(BeEmpty())
+		Expect(flushed(subscriber, subscriberConn)).To(Equal("*3\r\n$7\r\nmessage\r\n$20\r\n__redis__:invalidate\r\n*1\r\n$3\r\nkey\r\n"))
+	})
+
+	It("should tell the client once its redirect is broken", func() {
+		target, _ := newTestClient()
+		Expect(tracker.Enable(c, client.Tracking{Redirect: target.Id})).To(Succeed())
+		target.Release()
+
+		tracker.RememberKeys(c, []string{"key"})
+		dataStore.Put("key", "value", nil)
+		Expect(flushed(c, conn)).To(Equal(">2\r\n$21\r\ntracking-redir-broken\r\n:" + fmt.Sprint(target.Id) + "\r\n"))
+		Expect(c.Tracking.BrokenRedirect).To(BeTrue())
+	})
+
+	It("should not invalidate the keys modified by the client itself in the NOLOOP mode", func() {
+		Expect(tracker.Enable(c, client.Tracking{NoLoop: true})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+
+		previous := tracker.BeginCommand(c)
+		dataStore.Put("key", "value", nil)
+		tracker.EndCommand(previous)
+
+		Expect(flushed(c, conn)).To(BeEmpty())
+	})
+
+	It("should hold the invalidations until the command of the client replied", func() {
+		Expect(tracker.Enable(c, client.Tracking{})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+
+		previous := tracker.BeginCommand(c)
+		dataStore.Put("key", "value", nil)
+		c.Write([]byte("+OK\r\n"))
+		tracker.EndCommand(previous)
+
+		Expect(flushed(c, conn)).To(Equal("+OK\r\n>2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nkey\r\n"))
+	})
+
+	It("should invalidate all the keys when the database gets flushed", func() {
+		Expect(tracker.Enable(c, client.Tracking{})).To(Succeed())
+		tracker.RememberKeys(c, []string{"key"})
+
+		dataStore.Reset()
+		Expect(flushed(c, conn)).To(Equal(">2\r\n$10\r\ninvalidate\r\n_\r\n"))
+		Expect(trackedKeys(tracker)).To(BeZero())
+	})
+
+	It("should invalidate all the keys only once when every database gets flushed", func() {
+		other := store.NewDataStore()
+		other.AddKeyspaceObserver(tracker)
+		Expect(tracker.Enable(c, client.Tracking{})).To(Succeed())
+
+		previous := tracker.BeginCommand(c)
+		dataStore.Reset()
+		other.Reset()
+		tracker.EndCommand(previous)
+
+		Expect(flushed(c, conn)).To(Equal(">2\r\n$10\r\ninvalidate\r\n_\r\n"))
+	})
+
+	It("should evict keys from the tracking table once it is full", func() {
+		defer func(maxKeys int) { config.TrackingTableMaxKeys = maxKeys }(config.TrackingTableMaxKeys)
+		config.TrackingTableMaxKeys = 2
+
+		Expect(tracker.Enable(c, client.Tracking{})).To(Succeed())
+		tracker.RememberKeys(c, []string{"a", "b", "c"})
+
+		Expect(trackedKeys(tracker)).To(Equal(2))
+		Expect(flushed(c, conn)).To(HavePrefix(">2\r\n$10\r\ninvalidate\r\n"))
+	})
+
+	It("should stop tracking the client once tracking is off", func() {
+		Expect(tracker.Enable(c, client.Tracking{Bcast: true})).To(Succeed())
+		Expect(tracker.Clients()).To(Equal(1))
+
+		tracker.Disable(c)
+		Expect(c.Tracking).To(BeNil())
+		Expect(tracker.Clients()).To(BeZero())
+		Expect(tracker.TotalPrefixes()).To(BeZero())
+
+		dataStore.Put("key", "value", nil)
+		Expect(flushed(c, conn)).To(BeEmpty())
+	})
+})
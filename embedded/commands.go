@@ -0,0 +1,159 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// returned by TTL for the keys that don't exist, and for the ones without an expiry.
+const (
+	NoKey    time.Duration = -2
+	NoExpiry time.Duration = -1
+)
+
+// SetOptions of Set.
+type SetOptions struct {
+	// how long the key lives for, down to the millisecond. It doesn't expire if 0.
+	TTL time.Duration
+}
+
+// Get returns the value of the key, and false if it doesn't exist.
+func (e *Engine) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := e.Do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	value, err := stringReply(reply)
+	return value, err == nil, err
+}
+
+// Set sets the value of the key, overwriting the one it has, if any.
+func (e *Engine) Set(ctx context.Context, key string, value string, opts SetOptions) error {
+	if opts.TTL < 0 {
+		return fmt.Errorf("embedded: invalid TTL %s", opts.TTL)
+	}
+	args := []string{"SET", key, value}
+	if opts.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(opts.TTL.Milliseconds(), 1), 10))
+	}
+	_, err := e.Do(ctx, args...)
+	return err
+}
+
+// Del deletes the keys, and returns the number of them that existed.
+func (e *Engine) Del(ctx context.Context, keys ...string) (int64, error) {
+	return e.intCommand(ctx, "DEL", keys...)
+}
+
+// Exists returns the number of the keys that exist, counting the keys given more than once as many times.
+func (e *Engine) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return e.intCommand(ctx, "EXISTS", keys...)
+}
+
+// Expire sets how long the key lives for, down to the millisecond, whether it expires already or
+// not, and returns false if the key doesn't exist. The key is deleted right away if the TTL isn't
+// positive.
+func (e *Engine) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ms := ttl.Milliseconds()
+	if ttl > 0 {
+		ms = max(ms, 1)
+	}
+	set, err := e.intCommand(ctx, "PEXPIRE", key, strconv.FormatInt(ms, 10))
+	return set == 1, err
+}
+
+// TTL returns how long the key has left to live, down to the second. Returns NoKey if the key doesn't
+// exist, and NoExpiry if it doesn't expire.
+func (e *Engine) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := e.intCommand(ctx, "TTL", key)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return time.Duration(ttl), nil
+	}
+	return time.Duration(ttl) * time.Second, nil
+}
+
+// HSet sets the fields of the hash to the values, creating the hash if the key doesn't exist, and
+// returns the number of fields that were added.
+func (e *Engine) HSet(ctx context.Context, key string, fields map[string]string) (int64, error) {
+	args := []string{key}
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	return e.intCommand(ctx, "HSET", args...)
+}
+
+// HGet returns the value of the field of the hash, and false if either doesn't exist.
+func (e *Engine) HGet(ctx context.Context, key string, field string) (string, bool, error) {
+	reply, err := e.Do(ctx, "HGET", key, field)
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	value, err := stringReply(reply)
+	return value, err == nil, err
+}
+
+// HDel deletes the fields from the hash, and the hash along with the last of them. Returns the
+// number of fields that existed.
+func (e *Engine) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return e.intCommand(ctx, "HDEL", append([]string{key}, fields...)...)
+}
+
+// HGetAll returns the fields of the hash along with their values, which are empty if the key
+// doesn't exist.
+func (e *Engine) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	reply, err := e.Do(ctx, "HGETALL", key)
+	if err != nil {
+		return nil, err
+	}
+	// the engine speaks RESP2, so the fields and the values alternate in an array.
+	items, ok := reply.([]interface{})
+	if !ok || len(items)%2 != 0 {
+		return nil, fmt.Errorf("embedded: unexpected reply %v to HGETALL", reply)
+	}
+
+	fields := make(map[string]string, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		field, err := stringReply(items[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := stringReply(items[i+1])
+		if err != nil {
+			return nil, err
+		}
+		fields[field] = value
+	}
+	return fields, nil
+}
+
+// HLen returns the number of fields of the hash, 0 if the key doesn't exist.
+func (e *Engine) HLen(ctx context.Context, key string) (int64, error) {
+	return e.intCommand(ctx, "HLEN", key)
+}
+
+// runs the command, which replies with an integer.
+func (e *Engine) intCommand(ctx context.Context, cmd string, args ...string) (int64, error) {
+	reply, err := e.Do(ctx, append([]string{cmd}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("embedded: unexpected reply %v to %s", reply, cmd)
+	}
+	return n, nil
+}
+
+// returns the string the command replied with.
+func stringReply(reply interface{}) (string, error) {
+	s, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("embedded: unexpected reply %v", reply)
+	}
+	return s, nil
+}
@@ -0,0 +1,255 @@
+// Package embedded runs the engine inside the process embedding it, eg. as an in-process cache,
+// without a server. Each engine has databases of its own, along with its own stats, slow log,
+// latency monitor, ACL users, scripts and channels, and runs the commands against them through
+// the same dispatch as the commands the server receives.
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/commandhandler"
+	"github.com/shashwatrathod/redis-internals/core/eval"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/core/store"
+)
+
+const (
+	// how often the expired keys are purged and the tables of the databases rehashed, like the
+	// server does.
+	cron_frequency     time.Duration = 1 * time.Second
+	cron_rehash_budget time.Duration = 1 * time.Millisecond
+)
+
+// returned by the commands run through an engine that was closed.
+var ErrClosed = errors.New("embedded: the engine is closed")
+
+// Options of an engine.
+type Options struct {
+	// the number of databases, config.Databases if 0.
+	Databases int
+
+	// the index of the database the commands run against. SELECT only switches the database of
+	// the command it's part of.
+	DB int
+
+	// the number of keys each of the databases holds before evicting some, config.MaxKeys if 0.
+	MaxKeys int
+
+	// the commands that run for at least this long are recorded in the slow log of the engine,
+	// config.SlowlogLogSlowerThan microseconds if 0. None are recorded if negative.
+	SlowlogLogSlowerThan time.Duration
+
+	// the number of entries the slow log of the engine keeps, config.SlowlogMaxLen if 0.
+	SlowlogMaxLen int
+
+	// the minimum latency of the events recorded by the latency monitor of the engine,
+	// config.LatencyMonitorThreshold if 0. None are recorded if negative.
+	LatencyMonitorThreshold time.Duration
+}
+
+// Engine is an instance of the engine, whose state is apart from the one of the other engines and
+// of the server of the process. It's safe to use from multiple goroutines.
+type Engine struct {
+	instance *eval.Instance
+	db       int
+
+	// the client the commands run on behalf of, which belongs to the engine.
+	client *client.Client
+
+	// taken by the commands and the cron of the engine, which run one at a time. It's taken by
+	// sending to it, so that the callers waiting for their turn can give up once their context
+	// is done.
+	turn chan struct{}
+
+	// closed once the engine is, and once the cron stopped.
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// Open returns a new engine with empty databases.
+func Open(opts Options) (*Engine, error) {
+	count := opts.Databases
+	if count == 0 {
+		count = config.Databases
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("embedded: invalid number of databases %d", opts.Databases)
+	}
+	if opts.DB < 0 || opts.DB >= count {
+		return nil, fmt.Errorf("embedded: DB %d is out of range, there are %d databases", opts.DB, count)
+	}
+	if opts.MaxKeys < 0 {
+		return nil, fmt.Errorf("embedded: invalid max keys %d", opts.MaxKeys)
+	}
+	if opts.SlowlogMaxLen < 0 {
+		return nil, fmt.Errorf("embedded: invalid slow log length %d", opts.SlowlogMaxLen)
+	}
+
+	databases := store.NewDatabases(count)
+	for i := 0; i < count; i++ {
+		databases.Get(i).SetMaxKeys(opts.MaxKeys)
+	}
+	instance := eval.NewInstance(databases, newSlowLog(opts), newLatencyMonitor(opts))
+
+	e := &Engine{
+		instance: instance,
+		db:       opts.DB,
+		client:   instance.NewFakeClient(),
+		turn:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	e.client.Authenticated = true
+
+	go e.cron()
+	return e, nil
+}
+
+// returns the slow log of an engine opened with the options.
+func newSlowLog(opts Options) *stats.SlowLog {
+	maxLen := opts.SlowlogMaxLen
+	if maxLen == 0 {
+		maxLen = config.SlowlogMaxLen
+	}
+
+	logSlowerThan := opts.SlowlogLogSlowerThan.Microseconds()
+	switch {
+	case opts.SlowlogLogSlowerThan == 0:
+		logSlowerThan = config.SlowlogLogSlowerThan
+	case opts.SlowlogLogSlowerThan < 0:
+		logSlowerThan = -1
+	}
+	return stats.NewSlowLogWith(maxLen, logSlowerThan)
+}
+
+// returns the latency monitor of an engine opened with the options.
+func newLatencyMonitor(opts Options) *stats.LatencyMonitor {
+	threshold := opts.LatencyMonitorThreshold
+	switch {
+	case threshold == 0:
+		threshold = config.LatencyMonitorThreshold
+	case threshold < 0:
+		threshold = 0
+	}
+	return stats.NewLatencyMonitorWith(threshold)
+}
+
+// Close stops the engine. The commands run through it afterwards return ErrClosed.
+func (e *Engine) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+	<-e.stopped
+	return nil
+}
+
+// purges the expired keys and rehashes the tables of the databases every cron_frequency, until
+// the engine is closed.
+func (e *Engine) cron() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(cron_frequency)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		}
+
+		select {
+		case <-e.done:
+			return
+		case e.turn <- struct{}{}:
+		}
+		databases := e.instance.Databases
+		for i := 0; i < databases.Count(); i++ {
+			databases.Get(i).AutoDeleteExpiredKeys()
+			databases.Get(i).Rehash(cron_rehash_budget)
+		}
+		<-e.turn
+	}
+}
+
+// Do runs the command, eg. Do(ctx, "SET", "key", "value"), and returns its reply: a string for the
+// simple and the bulk strings, an int64 for the integers, a []interface{} for the arrays and nil
+// for the nulls. The error replies are returned as errors, and so are the errors within the
+// arrays. The commands that only make sense on a connection to the server, eg. MULTI, SUBSCRIBE
+// or CLIENT, aren't allowed. The scripts and the functions run until they are done, as nothing
+// else runs through the engine in the meantime to kill them.
+func (e *Engine) Do(ctx context.Context, args ...string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("ERR no command given")
+	}
+	cmd := &eval.RedisCmd{Cmd: strings.ToUpper(args[0]), Args: args[1:]}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-e.done:
+		return nil, ErrClosed
+	case e.turn <- struct{}{}:
+	}
+	defer func() { <-e.turn }()
+
+	// the engine may be closed while waiting for the turn.
+	select {
+	case <-e.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	if eval.ConnectionCommands[cmd.Cmd] {
+		return nil, errors.New("ERR This Redis command is not allowed in an embedded engine")
+	}
+
+	// every command starts out on the database of the engine.
+	e.client.Db = e.db
+	result := commandhandler.Execute(cmd, e.instance.Databases.Get(e.db), e.client)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	value, _, err := resp.DecodeTyped(result.Response, 0)
+	if err != nil {
+		return nil, err
+	}
+	if value.Type == resp.RespSimpleErrorIdentifier {
+		return nil, errors.New(value.Str)
+	}
+	return replyOf(value), nil
+}
+
+// converts the decoded reply into the Go value Do returns.
+func replyOf(value resp.TypedValue) interface{} {
+	if value.IsNull {
+		return nil
+	}
+
+	switch value.Type {
+	case resp.RespIntegerIdentifier:
+		return value.Int
+	case resp.RespSimpleStringIdentifier, resp.RespBulkStringIdentifier:
+		return value.Str
+	case resp.RespSimpleErrorIdentifier:
+		return errors.New(value.Str)
+	default:
+		items := make([]interface{}, 0, len(value.Items))
+		for _, item := range value.Items {
+			items = append(items, replyOf(item))
+		}
+		return items
+	}
+}
@@ -0,0 +1,297 @@
+package embedded_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+	"github.com/shashwatrathod/redis-internals/embedded"
+)
+
+func TestEmbedded(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Embedded Suite")
+}
+
+// opens an engine, and closes it once the spec is done.
+func open(opts embedded.Options) *embedded.Engine {
+	e, err := embedded.Open(opts)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(e.Close)
+	return e
+}
+
+var _ = Describe("Engine", func() {
+	var ctx context.Context
+	var e *embedded.Engine
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		e = open(embedded.Options{MaxKeys: 1000})
+	})
+
+	It("runs the commands and returns their replies", func() {
+		Expect(e.Do(ctx, "SET", "k", "v")).To(Equal("OK"))
+		Expect(e.Do(ctx, "get", "k")).To(Equal("v"))
+		Expect(e.Do(ctx, "GET", "missing")).To(BeNil())
+		Expect(e.Do(ctx, "EXISTS", "k", "missing")).To(Equal(int64(1)))
+		Expect(e.Do(ctx, "KEYS", "*")).To(Equal([]interface{}{"k"}))
+	})
+
+	It("returns the error replies as errors", func() {
+		_, err := e.Do(ctx, "NOPE")
+		Expect(err).To(MatchError(ContainSubstring("unknown command")))
+
+		_, err = e.Do(ctx, "GET")
+		Expect(err).To(MatchError(ContainSubstring("wrong number of arguments")))
+	})
+
+	It("doesn't run the commands that need a connection", func() {
+		for _, args := range [][]string{{"MULTI"}, {"SUBSCRIBE", "news"}, {"CLIENT", "ID"}, {"AUTH", "secret"}} {
+			_, err := e.Do(ctx, args...)
+			Expect(err).To(MatchError("ERR This Redis command is not allowed in an embedded engine"))
+		}
+	})
+
+	It("runs the scripts and the functions against its own keys", func() {
+		Expect(e.Do(ctx, "EVAL", "return redis.call('SET', KEYS[1], ARGV[1])", "1", "k", "v")).To(Equal("OK"))
+		Expect(e.Do(ctx, "GET", "k")).To(Equal("v"))
+
+		sha, err := e.Do(ctx, "SCRIPT", "LOAD", "return redis.call('GET', KEYS[1])")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Do(ctx, "EVALSHA", sha.(string), "1", "k")).To(Equal("v"))
+
+		Expect(e.Do(ctx, "FUNCTION", "LOAD", "#!lua name=lib\nredis.register_function('get', function(keys) return redis.call('GET', keys[1]) end)")).To(Equal("lib"))
+		Expect(e.Do(ctx, "FCALL", "get", "1", "k")).To(Equal("v"))
+	})
+
+	It("keeps its scripts, users and channels apart from the ones of the other engines and the server", func() {
+		other := open(embedded.Options{})
+		subscriber := client.NewFakeClient()
+		pubsub.GetPubSub().Subscribe(subscriber, "news")
+		DeferCleanup(pubsub.GetPubSub().UnsubscribeAll, subscriber)
+
+		sha, err := e.Do(ctx, "SCRIPT", "LOAD", "return 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Do(ctx, "ACL", "SETUSER", "alice", "on")).To(Equal("OK"))
+
+		Expect(other.Do(ctx, "SCRIPT", "EXISTS", sha.(string))).To(Equal([]interface{}{int64(0)}))
+		Expect(other.Do(ctx, "ACL", "USERS")).To(Equal([]interface{}{"default"}))
+		Expect(scripting.GetScripts().Exists(sha.(string))).To(BeFalse())
+		Expect(acl.GetUsers().GetUser("alice")).To(BeNil())
+
+		Expect(e.Do(ctx, "PUBLISH", "news", "hello")).To(Equal(int64(0)))
+		Expect(e.Do(ctx, "PUBSUB", "NUMSUB", "news")).To(Equal([]interface{}{"news", int64(0)}))
+
+		Expect(other.Do(ctx, "SCRIPT", "FLUSH")).To(Equal("OK"))
+		Expect(e.Do(ctx, "SCRIPT", "EXISTS", sha.(string))).To(Equal([]interface{}{int64(1)}))
+	})
+
+	It("keeps its slow log and latency monitor apart from the ones of the other engines and the server", func() {
+		opts := embedded.Options{SlowlogLogSlowerThan: time.Nanosecond, LatencyMonitorThreshold: time.Nanosecond}
+		first, second := open(opts), open(opts)
+		serverEntries := stats.GetSlowLog().Len()
+
+		Expect(first.Do(ctx, "PING")).To(Equal("PONG"))
+		Expect(second.Do(ctx, "PING")).To(Equal("PONG"))
+		Expect(first.Do(ctx, "SLOWLOG", "RESET")).To(Equal("OK"))
+		Expect(first.Do(ctx, "LATENCY", "RESET")).To(Equal(int64(1)))
+
+		Expect(second.Do(ctx, "SLOWLOG", "LEN")).To(Equal(int64(1)))
+		Expect(second.Do(ctx, "LATENCY", "LATEST")).To(HaveLen(1))
+		Expect(stats.GetSlowLog().Len()).To(Equal(serverEntries))
+		Expect(e.Do(ctx, "SLOWLOG", "LEN")).To(Equal(int64(0)))
+	})
+
+	It("runs its commands while the other engines run theirs", func() {
+		other := open(embedded.Options{})
+		started, done := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			close(started)
+			Expect(e.Do(ctx, "EVAL", "for i = 1, 10000000 do end return 1", "0")).To(Equal(int64(1)))
+		}()
+
+		<-started
+		time.Sleep(50 * time.Millisecond)
+		Expect(other.Do(ctx, "PING")).To(Equal("PONG"))
+		Expect(done).NotTo(BeClosed())
+		Eventually(done).WithTimeout(time.Minute).Should(BeClosed())
+	})
+
+	It("keeps its keys apart from the ones of the other engines", func() {
+		other := open(embedded.Options{})
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+
+		Expect(other.Exists(ctx, "k")).To(Equal(int64(0)))
+		Expect(other.Do(ctx, "FLUSHALL")).To(Equal("OK"))
+		Expect(e.Exists(ctx, "k")).To(Equal(int64(1)))
+	})
+
+	It("lists its own keys in INFO keyspace", func() {
+		other := open(embedded.Options{Databases: 2, DB: 1})
+		Expect(other.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+
+		Expect(other.Do(ctx, "INFO", "keyspace")).To(Equal("# Keyspace\r\ndb1:keys=1,expires=0,avg_ttl=0\r\n"))
+		Expect(e.Do(ctx, "INFO", "keyspace")).To(Equal("# Keyspace\r\n"))
+	})
+
+	It("runs the commands against the database of the options", func() {
+		other := open(embedded.Options{Databases: 2, DB: 1})
+		Expect(other.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+
+		// SELECT only lasts for the command it's part of.
+		Expect(other.Do(ctx, "SELECT", "0")).To(Equal("OK"))
+		Expect(other.Exists(ctx, "k")).To(Equal(int64(1)))
+
+		Expect(other.Do(ctx, "SWAPDB", "0", "1")).To(Equal("OK"))
+		Expect(other.Exists(ctx, "k")).To(Equal(int64(0)))
+		Expect(other.Do(ctx, "MOVE", "k", "0")).To(Equal(int64(0)))
+
+		_, err := other.Do(ctx, "SELECT", "2")
+		Expect(err).To(MatchError("ERR DB index is out of range"))
+	})
+
+	It("rejects the databases that are out of range", func() {
+		_, err := embedded.Open(embedded.Options{Databases: 2, DB: 2})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("gets, sets and deletes the keys", func() {
+		_, found, err := e.Get(ctx, "k")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+		value, found, err := e.Get(ctx, "k")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("v"))
+
+		Expect(e.Del(ctx, "k", "missing")).To(Equal(int64(1)))
+		Expect(e.Exists(ctx, "k")).To(Equal(int64(0)))
+	})
+
+	It("expires the keys set with a TTL", func() {
+		Expect(e.TTL(ctx, "k")).To(Equal(embedded.NoKey))
+
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+		Expect(e.TTL(ctx, "k")).To(Equal(embedded.NoExpiry))
+
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{TTL: time.Minute})).To(Succeed())
+		Expect(e.TTL(ctx, "k")).To(BeNumerically("~", time.Minute, time.Second))
+
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{TTL: 10 * time.Millisecond})).To(Succeed())
+		Eventually(func() (bool, error) {
+			_, found, err := e.Get(ctx, "k")
+			return found, err
+		}).Should(BeFalse())
+	})
+
+	It("sets the expiry of the keys, with or without one already", func() {
+		Expect(e.Expire(ctx, "missing", time.Minute)).To(BeFalse())
+
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+		Expect(e.Expire(ctx, "k", time.Minute)).To(BeTrue())
+		Expect(e.TTL(ctx, "k")).To(BeNumerically("~", time.Minute, time.Second))
+
+		Expect(e.Expire(ctx, "k", time.Hour)).To(BeTrue())
+		Expect(e.TTL(ctx, "k")).To(BeNumerically("~", time.Hour, time.Second))
+	})
+
+	It("expires the keys down to the millisecond", func() {
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+		Expect(e.Expire(ctx, "k", 500*time.Millisecond)).To(BeTrue())
+
+		// a TTL under a second doesn't delete the key right away.
+		Expect(e.Exists(ctx, "k")).To(Equal(int64(1)))
+		Eventually(func() (int64, error) {
+			return e.Exists(ctx, "k")
+		}).Should(BeZero())
+	})
+
+	It("deletes the keys expired with a TTL that isn't positive", func() {
+		Expect(e.Set(ctx, "k", "v", embedded.SetOptions{})).To(Succeed())
+		Expect(e.Expire(ctx, "k", 0)).To(BeTrue())
+
+		Expect(e.Exists(ctx, "k")).To(BeZero())
+	})
+
+	It("sets, gets and deletes the fields of the hashes", func() {
+		Expect(e.HSet(ctx, "h", map[string]string{"a": "1", "b": "2"})).To(Equal(int64(2)))
+		Expect(e.HSet(ctx, "h", map[string]string{"a": "3"})).To(Equal(int64(0)))
+
+		value, found, err := e.HGet(ctx, "h", "a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("3"))
+		_, found, err = e.HGet(ctx, "h", "missing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+
+		Expect(e.HGetAll(ctx, "h")).To(Equal(map[string]string{"a": "3", "b": "2"}))
+		Expect(e.HLen(ctx, "h")).To(Equal(int64(2)))
+
+		Expect(e.HDel(ctx, "h", "a", "b")).To(Equal(int64(2)))
+		Expect(e.Exists(ctx, "h")).To(BeZero())
+		Expect(e.HGetAll(ctx, "h")).To(BeEmpty())
+	})
+
+	It("doesn't read the hashes as strings", func() {
+		Expect(e.HSet(ctx, "h", map[string]string{"a": "1"})).To(Equal(int64(1)))
+
+		_, _, err := e.Get(ctx, "h")
+		Expect(err).To(MatchError(ContainSubstring("WRONGTYPE")))
+	})
+
+	It("can be used from multiple goroutines", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := strconv.Itoa(i*100 + j)
+					Expect(e.Set(ctx, key, key, embedded.SetOptions{})).To(Succeed())
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		Expect(e.Do(ctx, "DBSIZE")).To(Equal(int64(800)))
+	})
+
+	It("evicts some of the keys past max keys", func() {
+		other := open(embedded.Options{MaxKeys: 10})
+		for i := 0; i < 20; i++ {
+			Expect(other.Set(ctx, strconv.Itoa(i), "v", embedded.SetOptions{})).To(Succeed())
+		}
+		Expect(other.Do(ctx, "DBSIZE")).To(BeNumerically("<=", 10))
+	})
+
+	It("gives up once the context is done", func() {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := e.Do(cancelled, "PING")
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("doesn't run the commands once it's closed", func() {
+		Expect(e.Close()).To(Succeed())
+
+		_, err := e.Do(ctx, "PING")
+		Expect(err).To(MatchError(embedded.ErrClosed))
+	})
+})
@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/shashwatrathod/redis-internals/config"
 	"github.com/shashwatrathod/redis-internals/server"
@@ -11,8 +13,44 @@ import (
 // setupFlags initializes the command-line flags for the application.
 func setupFlags() {
 	flag.StringVar(&config.Host, "host", "0.0.0.0", "host for the redis server.")
-	flag.IntVar(&config.Port, "port", 7379, "port for the redis server.")
+	flag.IntVar(&config.Port, "port", 7379, "port for the plaintext connections. 0 disables it.")
+	flag.StringVar(&config.Bind, "bind", "", "space separated IPv4 and IPv6 addresses to listen on. overrides -host.")
+	flag.StringVar(&config.UnixSocket, "unixsocket", "", "path of the unix socket to listen on.")
+	flag.Func("unixsocketperm", "octal permissions of the unix socket file (eg. 700).", func(value string) error {
+		perm, err := strconv.ParseUint(value, 8, 32)
+		config.UnixSocketPerm = uint32(perm)
+		return err
+	})
+	flag.StringVar(&config.Pidfile, "pidfile", "", "path of the file to write the pid of the server to.")
+	flag.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 10*time.Second, "how long a shutdown waits for the replies of the clients to be written.")
+	flag.StringVar(&config.NetBackend, "net-backend", "", "network backend serving the connections: epoll (Linux only) or net. picks epoll on Linux by default.")
+	flag.IntVar(&config.IoThreads, "io-threads", 1, "number of threads reading the commands and writing the replies, the one running the commands included.")
+	flag.IntVar(&config.Databases, "databases", 16, "number of logical databases.")
 	flag.BoolVar(&config.LogRequest, "log_request", false, "whether to log raw request body.")
+	flag.StringVar(&config.NotifyKeyspaceEvents, "notify-keyspace-events", "", "classes of keyspace events to publish (eg. KEA).")
+	flag.BoolVar(&config.LazyfreeLazyEviction, "lazyfree-lazy-eviction", false, "whether to free the values of evicted keys in the background.")
+	flag.BoolVar(&config.LazyfreeLazyExpire, "lazyfree-lazy-expire", false, "whether to free the values of expired keys in the background.")
+	flag.BoolVar(&config.LazyfreeLazyServerDel, "lazyfree-lazy-server-del", false, "whether to free the values overwritten or deleted by commands in the background.")
+	flag.BoolVar(&config.LazyfreeLazyUserDel, "lazyfree-lazy-user-del", false, "whether DEL frees the values in the background, like UNLINK.")
+	flag.DurationVar(&config.LatencyMonitorThreshold, "latency-monitor-threshold", 0, "minimum duration of the events recorded by the latency monitor (eg. 100ms). 0 disables it.")
+	flag.Int64Var(&config.SlowlogLogSlowerThan, "slowlog-log-slower-than", 10000, "commands that run for longer than this many microseconds are recorded in the slow log. negative disables it.")
+	flag.IntVar(&config.SlowlogMaxLen, "slowlog-max-len", 128, "maximum number of entries kept in the slow log.")
+	flag.IntVar(&config.MetricsPort, "metrics-port", 0, "port of the HTTP listener serving the Prometheus metrics on /metrics. 0 disables it.")
+	flag.IntVar(&config.MaxClients, "maxclients", 10000, "maximum number of clients connected at once.")
+	flag.IntVar(&config.Timeout, "timeout", 0, "close the clients idle for this many seconds. 0 disables it.")
+	flag.IntVar(&config.TcpKeepalive, "tcp-keepalive", 300, "interval in seconds of the TCP keepalive probes. 0 disables them.")
+	flag.IntVar(&config.TrackingTableMaxKeys, "tracking-table-max-keys", 1000000, "maximum number of keys remembered for the clients tracking the keys they read. 0 is unlimited.")
+	flag.StringVar(&config.RequirePass, "requirepass", "", "password of the default user. empty lets the clients in without authenticating.")
+	flag.StringVar(&config.AclFile, "aclfile", "", "path of the file the ACL users are loaded from and saved to.")
+	flag.IntVar(&config.AclLogMaxLen, "acllog-max-len", 128, "maximum number of entries kept in the ACL log.")
+	flag.IntVar(&config.TlsPort, "tls-port", 0, "port for the TLS connections. 0 disables it.")
+	flag.StringVar(&config.TlsCertFile, "tls-cert-file", "", "path of the PEM encoded certificate of the server.")
+	flag.StringVar(&config.TlsKeyFile, "tls-key-file", "", "path of the PEM encoded private key of the server.")
+	flag.StringVar(&config.TlsCaCertFile, "tls-ca-cert-file", "", "path of the PEM encoded CA certificates the client certificates are verified with.")
+	flag.StringVar(&config.TlsAuthClients, "tls-auth-clients", "yes", "whether the clients must present a certificate: yes, optional or no.")
+	flag.StringVar(&config.TlsProtocols, "tls-protocols", "", "space separated TLS versions to accept (eg. \"TLSv1.2 TLSv1.3\").")
+	flag.StringVar(&config.TlsCiphers, "tls-ciphers", "", "colon separated cipher suites to accept for TLSv1.2 and below.")
+	flag.DurationVar(&config.BusyReplyThreshold, "busy-reply-threshold", 5*time.Second, "how long a script can run before the server replies BUSY to other clients.")
 	flag.Parse()
 }
 
@@ -20,5 +58,7 @@ func setupFlags() {
 func main() {
 	setupFlags()
 	log.Println("getting started")
-	server.RunAsyncTcpServer()
+	if err := server.RunAsyncTcpServer(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -3,9 +3,12 @@
 package mocks
 
 import (
+	time "time"
+
 	store "github.com/shashwatrathod/redis-internals/core/store"
-	utils "github.com/shashwatrathod/redis-internals/utils"
 	mock "github.com/stretchr/testify/mock"
+
+	utils "github.com/shashwatrathod/redis-internals/utils"
 )
 
 // Store is an autogenerated mock type for the Store type
@@ -53,6 +56,55 @@ func (_c *Store_AutoDeleteExpiredKeys_Call) RunAndReturn(run func()) *Store_Auto
 	return _c
 }
 
+// Copy provides a mock function with given fields: key, dest, newKey, replace
+func (_m *Store) Copy(key string, dest store.Store, newKey string, replace bool) bool {
+	ret := _m.Called(key, dest, newKey, replace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Copy")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, store.Store, string, bool) bool); ok {
+		r0 = rf(key, dest, newKey, replace)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_Copy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Copy'
+type Store_Copy_Call struct {
+	*mock.Call
+}
+
+// Copy is a helper method to define mock.On call
+//   - key string
+//   - dest store.Store
+//   - newKey string
+//   - replace bool
+func (_e *Store_Expecter) Copy(key interface{}, dest interface{}, newKey interface{}, replace interface{}) *Store_Copy_Call {
+	return &Store_Copy_Call{Call: _e.mock.On("Copy", key, dest, newKey, replace)}
+}
+
+func (_c *Store_Copy_Call) Run(run func(key string, dest store.Store, newKey string, replace bool)) *Store_Copy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(store.Store), args[2].(string), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *Store_Copy_Call) Return(_a0 bool) *Store_Copy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Copy_Call) RunAndReturn(run func(string, store.Store, string, bool) bool) *Store_Copy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Delete provides a mock function with given fields: key
 func (_m *Store) Delete(key string) bool {
 	ret := _m.Called(key)
@@ -99,6 +151,98 @@ func (_c *Store_Delete_Call) RunAndReturn(run func(string) bool) *Store_Delete_C
 	return _c
 }
 
+// DeleteEvicted provides a mock function with given fields: key
+func (_m *Store) DeleteEvicted(key string) bool {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteEvicted")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_DeleteEvicted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteEvicted'
+type Store_DeleteEvicted_Call struct {
+	*mock.Call
+}
+
+// DeleteEvicted is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) DeleteEvicted(key interface{}) *Store_DeleteEvicted_Call {
+	return &Store_DeleteEvicted_Call{Call: _e.mock.On("DeleteEvicted", key)}
+}
+
+func (_c *Store_DeleteEvicted_Call) Run(run func(key string)) *Store_DeleteEvicted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_DeleteEvicted_Call) Return(_a0 bool) *Store_DeleteEvicted_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_DeleteEvicted_Call) RunAndReturn(run func(string) bool) *Store_DeleteEvicted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteExpired provides a mock function with given fields: key
+func (_m *Store) DeleteExpired(key string) bool {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpired")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_DeleteExpired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpired'
+type Store_DeleteExpired_Call struct {
+	*mock.Call
+}
+
+// DeleteExpired is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) DeleteExpired(key interface{}) *Store_DeleteExpired_Call {
+	return &Store_DeleteExpired_Call{Call: _e.mock.On("DeleteExpired", key)}
+}
+
+func (_c *Store_DeleteExpired_Call) Run(run func(key string)) *Store_DeleteExpired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_DeleteExpired_Call) Return(_a0 bool) *Store_DeleteExpired_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_DeleteExpired_Call) RunAndReturn(run func(string) bool) *Store_DeleteExpired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Evict provides a mock function with no fields
 func (_m *Store) Evict() int {
 	ret := _m.Called()
@@ -144,6 +288,51 @@ func (_c *Store_Evict_Call) RunAndReturn(run func() int) *Store_Evict_Call {
 	return _c
 }
 
+// ExpiryCount provides a mock function with no fields
+func (_m *Store) ExpiryCount() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpiryCount")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Store_ExpiryCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExpiryCount'
+type Store_ExpiryCount_Call struct {
+	*mock.Call
+}
+
+// ExpiryCount is a helper method to define mock.On call
+func (_e *Store_Expecter) ExpiryCount() *Store_ExpiryCount_Call {
+	return &Store_ExpiryCount_Call{Call: _e.mock.On("ExpiryCount")}
+}
+
+func (_c *Store_ExpiryCount_Call) Run(run func()) *Store_ExpiryCount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Store_ExpiryCount_Call) Return(_a0 int) *Store_ExpiryCount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_ExpiryCount_Call) RunAndReturn(run func() int) *Store_ExpiryCount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ForEach provides a mock function with given fields: _a0
 func (_m *Store) ForEach(_a0 func(string, *store.Value) bool) {
 	_m.Called(_a0)
@@ -321,152 +510,877 @@ func (_c *Store_GetKeyMetadata_Call) RunAndReturn(run func(string) *store.KeyMet
 	return _c
 }
 
-// KeyCount provides a mock function with no fields
-func (_m *Store) KeyCount() int {
-	ret := _m.Called()
+// HDel provides a mock function with given fields: key, fields
+func (_m *Store) HDel(key string, fields []string) (int, error) {
+	ret := _m.Called(key, fields)
 
 	if len(ret) == 0 {
-		panic("no return value specified for KeyCount")
+		panic("no return value specified for HDel")
 	}
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func() int); ok {
-		r0 = rf()
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []string) (int, error)); ok {
+		return rf(key, fields)
+	}
+	if rf, ok := ret.Get(0).(func(string, []string) int); ok {
+		r0 = rf(key, fields)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(key, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Store_KeyCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeyCount'
-type Store_KeyCount_Call struct {
+// Store_HDel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HDel'
+type Store_HDel_Call struct {
 	*mock.Call
 }
 
-// KeyCount is a helper method to define mock.On call
-func (_e *Store_Expecter) KeyCount() *Store_KeyCount_Call {
-	return &Store_KeyCount_Call{Call: _e.mock.On("KeyCount")}
+// HDel is a helper method to define mock.On call
+//   - key string
+//   - fields []string
+func (_e *Store_Expecter) HDel(key interface{}, fields interface{}) *Store_HDel_Call {
+	return &Store_HDel_Call{Call: _e.mock.On("HDel", key, fields)}
 }
 
-func (_c *Store_KeyCount_Call) Run(run func()) *Store_KeyCount_Call {
+func (_c *Store_HDel_Call) Run(run func(key string, fields []string)) *Store_HDel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(string), args[1].([]string))
 	})
 	return _c
 }
 
-func (_c *Store_KeyCount_Call) Return(_a0 int) *Store_KeyCount_Call {
-	_c.Call.Return(_a0)
+func (_c *Store_HDel_Call) Return(_a0 int, _a1 error) *Store_HDel_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Store_KeyCount_Call) RunAndReturn(run func() int) *Store_KeyCount_Call {
+func (_c *Store_HDel_Call) RunAndReturn(run func(string, []string) (int, error)) *Store_HDel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Put provides a mock function with given fields: key, value, expiry
-func (_m *Store) Put(key string, value string, expiry *utils.ExpiryTime) {
-	_m.Called(key, value, expiry)
+// HSet provides a mock function with given fields: key, fieldValues
+func (_m *Store) HSet(key string, fieldValues []string) (int, error) {
+	ret := _m.Called(key, fieldValues)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HSet")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []string) (int, error)); ok {
+		return rf(key, fieldValues)
+	}
+	if rf, ok := ret.Get(0).(func(string, []string) int); ok {
+		r0 = rf(key, fieldValues)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []string) error); ok {
+		r1 = rf(key, fieldValues)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// Store_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
-type Store_Put_Call struct {
+// Store_HSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HSet'
+type Store_HSet_Call struct {
 	*mock.Call
 }
 
-// Put is a helper method to define mock.On call
+// HSet is a helper method to define mock.On call
 //   - key string
-//   - value string
-//   - expiry *utils.ExpiryTime
-func (_e *Store_Expecter) Put(key interface{}, value interface{}, expiry interface{}) *Store_Put_Call {
-	return &Store_Put_Call{Call: _e.mock.On("Put", key, value, expiry)}
+//   - fieldValues []string
+func (_e *Store_Expecter) HSet(key interface{}, fieldValues interface{}) *Store_HSet_Call {
+	return &Store_HSet_Call{Call: _e.mock.On("HSet", key, fieldValues)}
 }
 
-func (_c *Store_Put_Call) Run(run func(key string, value string, expiry *utils.ExpiryTime)) *Store_Put_Call {
+func (_c *Store_HSet_Call) Run(run func(key string, fieldValues []string)) *Store_HSet_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(*utils.ExpiryTime))
+		run(args[0].(string), args[1].([]string))
 	})
 	return _c
 }
 
-func (_c *Store_Put_Call) Return() *Store_Put_Call {
-	_c.Call.Return()
+func (_c *Store_HSet_Call) Return(_a0 int, _a1 error) *Store_HSet_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *Store_Put_Call) RunAndReturn(run func(string, string, *utils.ExpiryTime)) *Store_Put_Call {
-	_c.Run(run)
+func (_c *Store_HSet_Call) RunAndReturn(run func(string, []string) (int, error)) *Store_HSet_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// Reset provides a mock function with no fields
-func (_m *Store) Reset() {
-	_m.Called()
+// KeyCount provides a mock function with no fields
+func (_m *Store) KeyCount() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for KeyCount")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
 }
 
-// Store_Reset_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reset'
-type Store_Reset_Call struct {
+// Store_KeyCount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeyCount'
+type Store_KeyCount_Call struct {
 	*mock.Call
 }
 
-// Reset is a helper method to define mock.On call
-func (_e *Store_Expecter) Reset() *Store_Reset_Call {
-	return &Store_Reset_Call{Call: _e.mock.On("Reset")}
+// KeyCount is a helper method to define mock.On call
+func (_e *Store_Expecter) KeyCount() *Store_KeyCount_Call {
+	return &Store_KeyCount_Call{Call: _e.mock.On("KeyCount")}
 }
 
-func (_c *Store_Reset_Call) Run(run func()) *Store_Reset_Call {
+func (_c *Store_KeyCount_Call) Run(run func()) *Store_KeyCount_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *Store_Reset_Call) Return() *Store_Reset_Call {
-	_c.Call.Return()
+func (_c *Store_KeyCount_Call) Return(_a0 int) *Store_KeyCount_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Store_Reset_Call) RunAndReturn(run func()) *Store_Reset_Call {
-	_c.Run(run)
+func (_c *Store_KeyCount_Call) RunAndReturn(run func() int) *Store_KeyCount_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// SetExpiry provides a mock function with given fields: key, expiry
-func (_m *Store) SetExpiry(key string, expiry *utils.ExpiryTime) {
-	_m.Called(key, expiry)
+// KeyVersion provides a mock function with given fields: key
+func (_m *Store) KeyVersion(key string) uint64 {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KeyVersion")
+	}
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
 }
 
-// Store_SetExpiry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetExpiry'
-type Store_SetExpiry_Call struct {
+// Store_KeyVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KeyVersion'
+type Store_KeyVersion_Call struct {
 	*mock.Call
 }
 
-// SetExpiry is a helper method to define mock.On call
+// KeyVersion is a helper method to define mock.On call
 //   - key string
-//   - expiry *utils.ExpiryTime
-func (_e *Store_Expecter) SetExpiry(key interface{}, expiry interface{}) *Store_SetExpiry_Call {
-	return &Store_SetExpiry_Call{Call: _e.mock.On("SetExpiry", key, expiry)}
+func (_e *Store_Expecter) KeyVersion(key interface{}) *Store_KeyVersion_Call {
+	return &Store_KeyVersion_Call{Call: _e.mock.On("KeyVersion", key)}
 }
 
-func (_c *Store_SetExpiry_Call) Run(run func(key string, expiry *utils.ExpiryTime)) *Store_SetExpiry_Call {
+func (_c *Store_KeyVersion_Call) Run(run func(key string)) *Store_KeyVersion_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*utils.ExpiryTime))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *Store_SetExpiry_Call) Return() *Store_SetExpiry_Call {
-	_c.Call.Return()
+func (_c *Store_KeyVersion_Call) Return(_a0 uint64) *Store_KeyVersion_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *Store_SetExpiry_Call) RunAndReturn(run func(string, *utils.ExpiryTime)) *Store_SetExpiry_Call {
+func (_c *Store_KeyVersion_Call) RunAndReturn(run func(string) uint64) *Store_KeyVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Move provides a mock function with given fields: key, dest
+func (_m *Store) Move(key string, dest store.Store) bool {
+	ret := _m.Called(key, dest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Move")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, store.Store) bool); ok {
+		r0 = rf(key, dest)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_Move_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Move'
+type Store_Move_Call struct {
+	*mock.Call
+}
+
+// Move is a helper method to define mock.On call
+//   - key string
+//   - dest store.Store
+func (_e *Store_Expecter) Move(key interface{}, dest interface{}) *Store_Move_Call {
+	return &Store_Move_Call{Call: _e.mock.On("Move", key, dest)}
+}
+
+func (_c *Store_Move_Call) Run(run func(key string, dest store.Store)) *Store_Move_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(store.Store))
+	})
+	return _c
+}
+
+func (_c *Store_Move_Call) Return(_a0 bool) *Store_Move_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Move_Call) RunAndReturn(run func(string, store.Store) bool) *Store_Move_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Peek provides a mock function with given fields: key
+func (_m *Store) Peek(key string) *store.Value {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Peek")
+	}
+
+	var r0 *store.Value
+	if rf, ok := ret.Get(0).(func(string) *store.Value); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*store.Value)
+		}
+	}
+
+	return r0
+}
+
+// Store_Peek_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Peek'
+type Store_Peek_Call struct {
+	*mock.Call
+}
+
+// Peek is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) Peek(key interface{}) *Store_Peek_Call {
+	return &Store_Peek_Call{Call: _e.mock.On("Peek", key)}
+}
+
+func (_c *Store_Peek_Call) Run(run func(key string)) *Store_Peek_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Peek_Call) Return(_a0 *store.Value) *Store_Peek_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Peek_Call) RunAndReturn(run func(string) *store.Value) *Store_Peek_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Put provides a mock function with given fields: key, value, expiry
+func (_m *Store) Put(key string, value string, expiry *utils.ExpiryTime) {
+	_m.Called(key, value, expiry)
+}
+
+// Store_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
+type Store_Put_Call struct {
+	*mock.Call
+}
+
+// Put is a helper method to define mock.On call
+//   - key string
+//   - value string
+//   - expiry *utils.ExpiryTime
+func (_e *Store_Expecter) Put(key interface{}, value interface{}, expiry interface{}) *Store_Put_Call {
+	return &Store_Put_Call{Call: _e.mock.On("Put", key, value, expiry)}
+}
+
+func (_c *Store_Put_Call) Run(run func(key string, value string, expiry *utils.ExpiryTime)) *Store_Put_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(*utils.ExpiryTime))
+	})
+	return _c
+}
+
+func (_c *Store_Put_Call) Return() *Store_Put_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_Put_Call) RunAndReturn(run func(string, string, *utils.ExpiryTime)) *Store_Put_Call {
+	_c.Run(run)
+	return _c
+}
+
+// RandomKey provides a mock function with no fields
+func (_m *Store) RandomKey() (string, bool) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RandomKey")
+	}
+
+	var r0 string
+	var r1 bool
+	if rf, ok := ret.Get(0).(func() (string, bool)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() bool); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Store_RandomKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RandomKey'
+type Store_RandomKey_Call struct {
+	*mock.Call
+}
+
+// RandomKey is a helper method to define mock.On call
+func (_e *Store_Expecter) RandomKey() *Store_RandomKey_Call {
+	return &Store_RandomKey_Call{Call: _e.mock.On("RandomKey")}
+}
+
+func (_c *Store_RandomKey_Call) Run(run func()) *Store_RandomKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Store_RandomKey_Call) Return(_a0 string, _a1 bool) *Store_RandomKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_RandomKey_Call) RunAndReturn(run func() (string, bool)) *Store_RandomKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Rehash provides a mock function with given fields: budget
+func (_m *Store) Rehash(budget time.Duration) {
+	_m.Called(budget)
+}
+
+// Store_Rehash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rehash'
+type Store_Rehash_Call struct {
+	*mock.Call
+}
+
+// Rehash is a helper method to define mock.On call
+//   - budget time.Duration
+func (_e *Store_Expecter) Rehash(budget interface{}) *Store_Rehash_Call {
+	return &Store_Rehash_Call{Call: _e.mock.On("Rehash", budget)}
+}
+
+func (_c *Store_Rehash_Call) Run(run func(budget time.Duration)) *Store_Rehash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Store_Rehash_Call) Return() *Store_Rehash_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_Rehash_Call) RunAndReturn(run func(time.Duration)) *Store_Rehash_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Rename provides a mock function with given fields: key, newKey, replace
+func (_m *Store) Rename(key string, newKey string, replace bool) bool {
+	ret := _m.Called(key, newKey, replace)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rename")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string, bool) bool); ok {
+		r0 = rf(key, newKey, replace)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_Rename_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rename'
+type Store_Rename_Call struct {
+	*mock.Call
+}
+
+// Rename is a helper method to define mock.On call
+//   - key string
+//   - newKey string
+//   - replace bool
+func (_e *Store_Expecter) Rename(key interface{}, newKey interface{}, replace interface{}) *Store_Rename_Call {
+	return &Store_Rename_Call{Call: _e.mock.On("Rename", key, newKey, replace)}
+}
+
+func (_c *Store_Rename_Call) Run(run func(key string, newKey string, replace bool)) *Store_Rename_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *Store_Rename_Call) Return(_a0 bool) *Store_Rename_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Rename_Call) RunAndReturn(run func(string, string, bool) bool) *Store_Rename_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Reset provides a mock function with no fields
+func (_m *Store) Reset() {
+	_m.Called()
+}
+
+// Store_Reset_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Reset'
+type Store_Reset_Call struct {
+	*mock.Call
+}
+
+// Reset is a helper method to define mock.On call
+func (_e *Store_Expecter) Reset() *Store_Reset_Call {
+	return &Store_Reset_Call{Call: _e.mock.On("Reset")}
+}
+
+func (_c *Store_Reset_Call) Run(run func()) *Store_Reset_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Store_Reset_Call) Return() *Store_Reset_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_Reset_Call) RunAndReturn(run func()) *Store_Reset_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ResetAsync provides a mock function with no fields
+func (_m *Store) ResetAsync() {
+	_m.Called()
+}
+
+// Store_ResetAsync_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetAsync'
+type Store_ResetAsync_Call struct {
+	*mock.Call
+}
+
+// ResetAsync is a helper method to define mock.On call
+func (_e *Store_Expecter) ResetAsync() *Store_ResetAsync_Call {
+	return &Store_ResetAsync_Call{Call: _e.mock.On("ResetAsync")}
+}
+
+func (_c *Store_ResetAsync_Call) Run(run func()) *Store_ResetAsync_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Store_ResetAsync_Call) Return() *Store_ResetAsync_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_ResetAsync_Call) RunAndReturn(run func()) *Store_ResetAsync_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SampleKeys provides a mock function with given fields: count
+func (_m *Store) SampleKeys(count int) []string {
+	ret := _m.Called(count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SampleKeys")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Store_SampleKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SampleKeys'
+type Store_SampleKeys_Call struct {
+	*mock.Call
+}
+
+// SampleKeys is a helper method to define mock.On call
+//   - count int
+func (_e *Store_Expecter) SampleKeys(count interface{}) *Store_SampleKeys_Call {
+	return &Store_SampleKeys_Call{Call: _e.mock.On("SampleKeys", count)}
+}
+
+func (_c *Store_SampleKeys_Call) Run(run func(count int)) *Store_SampleKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *Store_SampleKeys_Call) Return(_a0 []string) *Store_SampleKeys_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_SampleKeys_Call) RunAndReturn(run func(int) []string) *Store_SampleKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SampleKeysWithExpiry provides a mock function with given fields: count
+func (_m *Store) SampleKeysWithExpiry(count int) []string {
+	ret := _m.Called(count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SampleKeysWithExpiry")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// Store_SampleKeysWithExpiry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SampleKeysWithExpiry'
+type Store_SampleKeysWithExpiry_Call struct {
+	*mock.Call
+}
+
+// SampleKeysWithExpiry is a helper method to define mock.On call
+//   - count int
+func (_e *Store_Expecter) SampleKeysWithExpiry(count interface{}) *Store_SampleKeysWithExpiry_Call {
+	return &Store_SampleKeysWithExpiry_Call{Call: _e.mock.On("SampleKeysWithExpiry", count)}
+}
+
+func (_c *Store_SampleKeysWithExpiry_Call) Run(run func(count int)) *Store_SampleKeysWithExpiry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *Store_SampleKeysWithExpiry_Call) Return(_a0 []string) *Store_SampleKeysWithExpiry_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_SampleKeysWithExpiry_Call) RunAndReturn(run func(int) []string) *Store_SampleKeysWithExpiry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Scan provides a mock function with given fields: cursor, count, fn
+func (_m *Store) Scan(cursor uint64, count int, fn func(string, *store.Value)) uint64 {
+	ret := _m.Called(cursor, count, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Scan")
+	}
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(uint64, int, func(string, *store.Value)) uint64); ok {
+		r0 = rf(cursor, count, fn)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// Store_Scan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Scan'
+type Store_Scan_Call struct {
+	*mock.Call
+}
+
+// Scan is a helper method to define mock.On call
+//   - cursor uint64
+//   - count int
+//   - fn func(string , *store.Value)
+func (_e *Store_Expecter) Scan(cursor interface{}, count interface{}, fn interface{}) *Store_Scan_Call {
+	return &Store_Scan_Call{Call: _e.mock.On("Scan", cursor, count, fn)}
+}
+
+func (_c *Store_Scan_Call) Run(run func(cursor uint64, count int, fn func(string, *store.Value))) *Store_Scan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uint64), args[1].(int), args[2].(func(string, *store.Value)))
+	})
+	return _c
+}
+
+func (_c *Store_Scan_Call) Return(_a0 uint64) *Store_Scan_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Scan_Call) RunAndReturn(run func(uint64, int, func(string, *store.Value)) uint64) *Store_Scan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetExpiry provides a mock function with given fields: key, expiry
+func (_m *Store) SetExpiry(key string, expiry *utils.ExpiryTime) {
+	_m.Called(key, expiry)
+}
+
+// Store_SetExpiry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetExpiry'
+type Store_SetExpiry_Call struct {
+	*mock.Call
+}
+
+// SetExpiry is a helper method to define mock.On call
+//   - key string
+//   - expiry *utils.ExpiryTime
+func (_e *Store_Expecter) SetExpiry(key interface{}, expiry interface{}) *Store_SetExpiry_Call {
+	return &Store_SetExpiry_Call{Call: _e.mock.On("SetExpiry", key, expiry)}
+}
+
+func (_c *Store_SetExpiry_Call) Run(run func(key string, expiry *utils.ExpiryTime)) *Store_SetExpiry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(*utils.ExpiryTime))
+	})
+	return _c
+}
+
+func (_c *Store_SetExpiry_Call) Return() *Store_SetExpiry_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_SetExpiry_Call) RunAndReturn(run func(string, *utils.ExpiryTime)) *Store_SetExpiry_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetNoTouch provides a mock function with given fields: noTouch
+func (_m *Store) SetNoTouch(noTouch bool) {
+	_m.Called(noTouch)
+}
+
+// Store_SetNoTouch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetNoTouch'
+type Store_SetNoTouch_Call struct {
+	*mock.Call
+}
+
+// SetNoTouch is a helper method to define mock.On call
+//   - noTouch bool
+func (_e *Store_Expecter) SetNoTouch(noTouch interface{}) *Store_SetNoTouch_Call {
+	return &Store_SetNoTouch_Call{Call: _e.mock.On("SetNoTouch", noTouch)}
+}
+
+func (_c *Store_SetNoTouch_Call) Run(run func(noTouch bool)) *Store_SetNoTouch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(bool))
+	})
+	return _c
+}
+
+func (_c *Store_SetNoTouch_Call) Return() *Store_SetNoTouch_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_SetNoTouch_Call) RunAndReturn(run func(bool)) *Store_SetNoTouch_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Unlink provides a mock function with given fields: key
+func (_m *Store) Unlink(key string) bool {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unlink")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Store_Unlink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unlink'
+type Store_Unlink_Call struct {
+	*mock.Call
+}
+
+// Unlink is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) Unlink(key interface{}) *Store_Unlink_Call {
+	return &Store_Unlink_Call{Call: _e.mock.On("Unlink", key)}
+}
+
+func (_c *Store_Unlink_Call) Run(run func(key string)) *Store_Unlink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Unlink_Call) Return(_a0 bool) *Store_Unlink_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Unlink_Call) RunAndReturn(run func(string) bool) *Store_Unlink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unwatch provides a mock function with given fields: key
+func (_m *Store) Unwatch(key string) {
+	_m.Called(key)
+}
+
+// Store_Unwatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unwatch'
+type Store_Unwatch_Call struct {
+	*mock.Call
+}
+
+// Unwatch is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) Unwatch(key interface{}) *Store_Unwatch_Call {
+	return &Store_Unwatch_Call{Call: _e.mock.On("Unwatch", key)}
+}
+
+func (_c *Store_Unwatch_Call) Run(run func(key string)) *Store_Unwatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Unwatch_Call) Return() *Store_Unwatch_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Store_Unwatch_Call) RunAndReturn(run func(string)) *Store_Unwatch_Call {
 	_c.Run(run)
 	return _c
 }
 
+// Watch provides a mock function with given fields: key
+func (_m *Store) Watch(key string) uint64 {
+	ret := _m.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Watch")
+	}
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(string) uint64); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
+// Store_Watch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Watch'
+type Store_Watch_Call struct {
+	*mock.Call
+}
+
+// Watch is a helper method to define mock.On call
+//   - key string
+func (_e *Store_Expecter) Watch(key interface{}) *Store_Watch_Call {
+	return &Store_Watch_Call{Call: _e.mock.On("Watch", key)}
+}
+
+func (_c *Store_Watch_Call) Run(run func(key string)) *Store_Watch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Store_Watch_Call) Return(_a0 uint64) *Store_Watch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Watch_Call) RunAndReturn(run func(string) uint64) *Store_Watch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStore(t interface {
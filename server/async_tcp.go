@@ -1,183 +1,484 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/acl"
+	"github.com/shashwatrathod/redis-internals/core/client"
 	"github.com/shashwatrathod/redis-internals/core/commandhandler"
 	"github.com/shashwatrathod/redis-internals/core/eval"
 	redisio "github.com/shashwatrathod/redis-internals/core/io"
+	"github.com/shashwatrathod/redis-internals/core/metrics"
+	"github.com/shashwatrathod/redis-internals/core/pubsub"
 	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/core/scripting"
+	"github.com/shashwatrathod/redis-internals/core/shutdown"
+	"github.com/shashwatrathod/redis-internals/core/stats"
 	"github.com/shashwatrathod/redis-internals/core/store"
+	"github.com/shashwatrathod/redis-internals/core/tracking"
 )
 
-// GREAT video on FDs https://www.youtube.com/watch?v=-gP58pozNuM
-
 const (
 	max_concurrent_clients               = 20000
 	cron_frequency         time.Duration = 1 * time.Second
+	cron_rehash_budget     time.Duration = 1 * time.Millisecond
+
+	// how long to wait for network events before running the frequent cron tasks, such as
+	// sampling the instantaneous metrics, so that they run even when the server is idle.
+	event_poll_interval_ms = 100
+
+	// how long to wait for network events in between checking on a script that is running past the busy threshold.
+	busy_script_poll_interval_ms = 10
+
+	// how long the scrapes that are in flight when the server shuts down are waited for.
+	metrics_shutdown_timeout time.Duration = 1 * time.Second
 )
 
 var lastCronExecutionTs time.Time = time.Now()
 
+// fds of the clients that are being watched for writability, because their
+// sockets couldn't take all of their replies.
+var awaitingWritable = make(map[int]bool)
+
+// clients whose commands are postponed by CLIENT PAUSE, in the order they were postponed.
+var postponed []*client.Client
+
+// clients that have something to read that their sockets won't tell about: the ones whose
+// connections read more from their sockets than the clients read from them, eg. the TLS records
+// that hold more than one command, and the ones that became readable while they couldn't be read from.
+var bufferedReads = make(map[*client.Client]struct{})
+
+// the databases outlive the server, which can be served more than once in the same process, eg.
+// by the tests, so they're only observed once.
+var observeKeyspaces sync.Once
+
+// RunAsyncTcpServer runs the server on the addresses and the ports of the config, with the network
+// backend of the config, until it shuts down.
 func RunAsyncTcpServer() error {
-	log.Println("Initializing the server on ", config.Host, ":", config.Port)
+	if config.Port == 0 && config.TlsPort == 0 && config.UnixSocket == "" {
+		return fmt.Errorf("either the port, the TLS port or the unix socket has to be set")
+	}
 
-	// First initialize a socket.
-	// O_NONBLOCK creates the socket in a non-blocking mode.
-	// SOCK_STREAM sets the type of socket to STREAM.
-	serverFd, err := syscall.Socket(syscall.AF_INET, syscall.O_NONBLOCK|syscall.SOCK_STREAM, 0)
+	var tlsConfig *tls.Config
+	var err error
+	if config.TlsPort != 0 {
+		if tlsConfig, err = loadTLSConfig(); err != nil {
+			return err
+		}
+	}
 
+	b, err := newBackend(tlsConfig)
 	if err != nil {
 		return err
 	}
-	defer syscall.Close(serverFd)
+	return serve(b, tlsConfig)
+}
+
+// Serve runs the server over the listeners with the net backend, until it shuts down, eg. with
+// SHUTDOWN. The addresses and the ports of the config are left alone, and the listeners are
+// closed once it returns.
+func Serve(listeners ...net.Listener) error {
+	return serve(newNetBackend(listeners), nil)
+}
+
+// runs the event loop over the connections of the backend, and closes the backend once the server
+// shuts down. the metrics are served over HTTPS with the certificate of the TLS config, if there's one.
+func serve(b backend, tlsConfig *tls.Config) error {
+	defer b.close()
+
+	if config.IoThreads < 1 || config.IoThreads > max_io_threads {
+		return fmt.Errorf("io-threads has to be between 1 and %d", max_io_threads)
+	}
+	adjustOpenFilesLimit()
+
+	// SIGTERM and SIGINT shut the server down gracefully, see SHUTDOWN.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(signals)
+
+	if config.Pidfile != "" {
+		if err := os.WriteFile(config.Pidfile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+			log.Println("Failed to write the pid file: ", err)
+		} else {
+			defer os.Remove(config.Pidfile)
+		}
+	}
 
-	// Enable nonblocking behavior on the socket server.
-	if err = syscall.SetNonblock(serverFd, true); err != nil {
+	log.Println("Sucessfully started the server.")
+	for _, addr := range b.addresses() {
+		log.Printf("Listening on %s...\n", addr)
+	}
+
+	if err := pubsub.SetKeyspaceEvents(config.NotifyKeyspaceEvents); err != nil {
 		return err
 	}
 
-	// Bind the socket to the given port.
-	ipv4 := net.ParseIP(config.Host).To4()
-	err = syscall.Bind(serverFd, &syscall.SockaddrInet4{
-		Port: config.Port,
-		Addr: [4]byte{ipv4[0], ipv4[1], ipv4[2], ipv4[3]},
+	if config.RequirePass != "" {
+		if err := acl.GetUsers().SetUser(acl.DefaultUser, "resetpass", ">"+config.RequirePass); err != nil {
+			return err
+		}
+	}
+	if config.AclFile != "" {
+		if err := acl.GetUsers().LoadFile(config.AclFile); err != nil {
+			return fmt.Errorf("error loading the ACL file: %w", err)
+		}
+	}
+
+	databases := store.GetDatabases()
+	observeKeyspaces.Do(func() {
+		for i := 0; i < databases.Count(); i++ {
+			databases.Get(i).AddKeyspaceObserver(pubsub.NewKeyspaceNotifier(pubsub.GetPubSub(), i))
+			databases.Get(i).AddKeyspaceObserver(tracking.GetTracker())
+		}
 	})
-	if err != nil {
-		return err
+
+	serverStats := stats.GetStats()
+
+	threads := newIoThreads(config.IoThreads)
+	defer threads.stop()
+	if config.IoThreads > 1 {
+		log.Printf("Reading the commands and writing the replies on %d threads.\n", config.IoThreads)
 	}
 
-	// Start listening on the socket server for new connections.
-	//  max_concurrent_clients specifies the max number of clients that can be in the queue.
-	if err = syscall.Listen(serverFd, max_concurrent_clients); err != nil {
-		return err
+	// the metrics are served from the snapshots the loop publishes, so that the scrapers never
+	// touch the keyspace the loop owns.
+	if config.MetricsPort != 0 {
+		metrics.Publish(metrics.TakeSnapshot(databases))
+		addr := net.JoinHostPort(bindAddresses()[0], strconv.Itoa(config.MetricsPort))
+		metricsServer, err := metrics.Listen(addr, metricsTLSConfig(tlsConfig))
+		if err != nil {
+			return fmt.Errorf("error serving the metrics: %w", err)
+		}
+		// stops listening along with the other listeners, so that the port is free once the server
+		// shut down.
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), metrics_shutdown_timeout)
+			defer cancel()
+			metricsServer.Shutdown(ctx)
+		}()
 	}
 
-	// ONLY FOR LINUX
-	// Create a new Epoll through system call.
-	// Epoll can be thought of as an "Observable" in the "Observer" pattern
-	// that monitors the information and passes that information to the observers.
-	epollFd, err := syscall.EpollCreate1(0)
-	if err != nil {
-		return err
+	// connected clients, keyed by their file descriptors.
+	clients := make(map[int]*client.Client)
+
+	// closes the connection to the client and releases everything held on its behalf.
+	disconnect := func(c *client.Client) {
+		pubsub.GetPubSub().UnsubscribeAll(c)
+		eval.UnwatchAllKeys(c)
+		tracking.GetTracker().Disable(c)
+		c.Release()
+		b.closeConn(c.Fd)
+		delete(clients, c.Fd)
+		delete(awaitingWritable, c.Fd)
+		delete(bufferedReads, c)
+		serverStats.ConnectedClients.Add(-1)
 	}
 
-	// This is the event that is going to be "observed".
-	// EPOLLIN gets fired when a file descriptor (in this case serverFd) is ready to be read.
-	var socketEvent = &syscall.EpollEvent{
-		Events: syscall.EPOLLIN,
-		Fd:     int32(serverFd),
+	// starts serving the client of the connection. returns nil if the client couldn't be served.
+	addClient := func(conn *connection) *client.Client {
+		if len(clients) >= config.MaxClients {
+			// the reply is written straight to the connection, which has room for it since nothing was written yet.
+			conn.comm.Write(resp.Encode(errors.New("ERR max number of clients reached"), false))
+			if buffered, ok := conn.comm.(redisio.BufferedConn); ok {
+				buffered.FlushBuffered()
+			}
+			serverStats.ConnectedClients.Add(-1)
+			serverStats.RejectedConnections.Add(1)
+			b.closeConn(conn.fd)
+			return nil
+		}
+
+		if e := b.serve(conn); e != nil {
+			log.Println("Error occured while estabilishing listner on Client", e)
+			serverStats.ConnectedClients.Add(-1)
+			serverStats.RejectedConnections.Add(1)
+			b.closeConn(conn.fd)
+			return nil
+		}
+
+		c := client.NewClient(conn.fd, conn.comm)
+		c.Addr = conn.addr
+		c.LAddr = conn.laddr
+		c.UnixSocket = conn.unix
+		acl.GetUsers().SetDefaultAuth(c)
+		clients[conn.fd] = c
+		client.Register(c)
+		return c
 	}
 
-	// Adds a new "observer" for the Event. The observer gets attached to Observable (EPOLL)'s FD.
-	if err = syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, serverFd, socketEvent); err != nil {
-		return err
+	// disconnects the clients that sent no commands for longer than the timeout. the subscribers
+	// and the clients waiting for their commands to run are idle by design, so they're kept.
+	closeIdleClients := func() {
+		timeout := time.Duration(config.Timeout) * time.Second
+		for _, c := range clients {
+			if c.SubscriptionCount()+c.ShardSubscriptionCount() > 0 || c.Postponed != nil {
+				continue
+			}
+			if time.Since(c.LastInteraction) > timeout {
+				log.Printf("Closing the connection to client %d, idle for over %d seconds\n", c.Id, config.Timeout)
+				disconnect(c)
+			}
+		}
 	}
 
-	log.Println("Sucessfully started the server.")
-	log.Printf("Listening on %s:%d...\n", config.Host, config.Port)
+	// runs the command read from the client, or disconnects it if it couldn't be read.
+	runCommand := func(c *client.Client, command *eval.RedisCmd, err error) {
+		if err == syscall.EAGAIN {
+			// the connection has no command to read yet, eg. only a part of a TLS record arrived.
+			return
+		}
+		if err != nil {
+			disconnect(c)
+			return
+		}
+		c.LastInteraction = time.Now()
+
+		// the connection may have read more than the command from the socket, which won't become readable for the rest.
+		if c.PendingReads() > 0 {
+			bufferedReads[c] = struct{}{}
+		}
 
-	var s store.Store = store.GetStore()
+		if commandhandler.ShouldPostpone(command, c) {
+			// stop reading from the client until the command can run.
+			c.Postponed = &client.QueuedCommand{Cmd: command.Cmd, Args: command.Args}
+			postponed = append(postponed, c)
+			watchEvents(b, c)
+			return
+		}
 
-	concurrent_clients := 0
+		respond(command, databases.Get(c.Db), c)
+	}
 
-	var events []syscall.EpollEvent = make([]syscall.EpollEvent, max_concurrent_clients)
+	// runs the postponed commands of the clients that are no longer paused, and watches their
+	// sockets for new commands again.
+	resumePostponed := func() {
+		stillPostponed := postponed[:0]
+		for _, c := range postponed {
+			if c.Postponed == nil {
+				// the client disconnected in the meantime.
+				continue
+			}
 
-	for {
+			cmd := &eval.RedisCmd{Cmd: c.Postponed.Cmd, Args: c.Postponed.Args}
+			if commandhandler.ShouldPostpone(cmd, c) {
+				stillPostponed = append(stillPostponed, c)
+				continue
+			}
 
-		if time.Now().After(lastCronExecutionTs.Add(cron_frequency)) {
-			s.AutoDeleteExpiredKeys()
-			lastCronExecutionTs = time.Now()
+			c.Postponed = nil
+			watchEvents(b, c)
+			respond(cmd, databases.Get(c.Db), c)
 		}
+		postponed = stillPostponed
+	}
 
-		// Wait for new events to be captured.
-		nevents, e := syscall.EpollWait(epollFd, events, -1)
+	// waits for up to timeoutMs milliseconds (forever if -1) for events on the connections and
+	// handles them. the protected client, if any, is not read from, eg. while it waits for
+	// the reply to the script it is running.
+	processEvents := func(timeoutMs int, protected *client.Client) error {
+		events, e := b.poll(timeoutMs)
 		if e != nil {
-			return nil
+			return e
 		}
 
-		for i := 0; i < nevents; i++ {
-			var event syscall.EpollEvent = events[i]
-
-			// If there is an event on serverFd,
-			// that means a new client wants to connect.
-			if event.Fd == int32(serverFd) {
-				// Accept the new connection
-				conn_fd, conn_address, e := syscall.Accept(serverFd)
-				if e != nil {
-					log.Println("An error occurred while accepting connection from a client: ", err)
-					continue
+		// the clients to read from. the scripts running past the busy threshold handle events while
+		// the commands of the outer call run, so nothing read is kept in between calls.
+		var readable []*client.Client
+		for _, event := range events {
+			if event.conn != nil {
+				// the client may have sent its first commands along with the end of its TLS handshake.
+				if c := addClient(event.conn); c != nil && c.PendingReads() > 0 {
+					bufferedReads[c] = struct{}{}
 				}
+				continue
+			}
 
-				concurrent_clients++
+			c, exists := clients[event.fd]
+			if !exists {
+				continue
+			}
 
-				if addr, ok := conn_address.(*syscall.SockaddrInet4); ok {
-					ip := net.IPv4(addr.Addr[0], addr.Addr[1], addr.Addr[2], addr.Addr[3])
-					log.Printf("Successfully accepted a connection from %s:%d. Concurrent Clients = %d\n", ip.String(), addr.Port, concurrent_clients)
+			if !event.readable {
+				if event.hangup && c != protected {
+					// the connection broke while the client wasn't being read from, eg. while its command is postponed.
+					disconnect(c)
 				}
+				// the client's connection only became writable, the pending replies get flushed below.
+				continue
+			}
 
-				if e := syscall.SetNonblock(serverFd, true); e != nil {
-					log.Println("Error while configuring the nonblocking server: ", e)
-					continue
-				}
+			if c == protected || c.Postponed != nil {
+				// the client is read from once it can be, eg. once its command is resumed.
+				bufferedReads[c] = struct{}{}
+				continue
+			}
+			if _, buffered := bufferedReads[c]; !buffered {
+				readable = append(readable, c)
+			}
+		}
 
-				// Event where the Client's Fd is ready to be read.
-				// This basically means we have new data/information incoming from the client.
-				var clientEvent *syscall.EpollEvent = &syscall.EpollEvent{
-					Events: syscall.EPOLLIN,
-					Fd:     int32(conn_fd),
-				}
+		// the clients with buffered reads are read from without waiting for their connections.
+		for c := range bufferedReads {
+			if c == protected || c.Postponed != nil {
+				continue
+			}
+			delete(bufferedReads, c)
+			readable = append(readable, c)
+		}
 
-				// Add a new "Observer" to listen for events on the Client's FD.
-				if e := syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, conn_fd, clientEvent); e != nil {
-					log.Println("Error occured while estabilishing listner on Client", err)
-					concurrent_clients--
-					syscall.Close(conn_fd)
-				}
+		// the commands are read in parallel, then run one at a time in the order they were read.
+		commands := make([]*eval.RedisCmd, len(readable))
+		readErrs := make([]error, len(readable))
+		threads.run(len(readable), func(i int) {
+			commands[i], readErrs[i] = readCommand(readable[i])
+		})
+		for i, c := range readable {
+			if clients[c.Fd] != c {
+				// the client was disconnected by one of the commands that ran before.
+				continue
+			}
+			runCommand(c, commands[i], readErrs[i])
+		}
+
+		flushPendingWrites(b, threads, disconnect)
+
+		// the killed clients got the replies they were waiting for, if their sockets took them.
+		for _, c := range client.Killed() {
+			disconnect(c)
+		}
+
+		select {
+		case sig := <-signals:
+			if shutdown.Pending() != nil {
+				log.Printf("Received %s while already shutting down, exiting now.\n", sig)
+				shutdown.Start(shutdown.Options{Now: true, Force: true}, nil)
 			} else {
-				// This means we have a new event on the Client's FD.
-				comm := &redisio.FDComm{
-					Fd: int(event.Fd),
-				}
+				log.Printf("Received %s, scheduling shutdown...\n", sig)
+				shutdown.Start(shutdown.Options{}, nil)
+			}
+		default:
+		}
 
-				command, err := readCommand(comm)
-				if err != nil {
-					syscall.Close(int(event.Fd))
-					concurrent_clients--
-					continue
-				}
+		// the script that keeps the loop from getting to the shutdown is stopped, whether or not it wrote.
+		if shutdown.Pending() != nil && scripting.GetScripts().IsBusy() {
+			scripting.GetScripts().Terminate()
+		}
+		return nil
+	}
 
-				respond(command, s, comm)
+	// keep serving the other clients while a script runs for too long, so that it can be killed.
+	scripting.GetScripts().ProcessEventsWhileBusy = func(c *client.Client) {
+		processEvents(busy_script_poll_interval_ms, c)
+	}
+
+	// the shutdown being carried out, and when it stops waiting for the replies of the clients to be written.
+	var stopping *shutdown.Request
+	var stopDeadline time.Time
+
+	// carries out the shutdown asked for with SHUTDOWN or by a signal, if any.
+	// returns true once the server can exit.
+	progressShutdown := func() bool {
+		req := shutdown.Pending()
+		switch {
+		case req == nil && stopping == nil:
+			return false
+		case req == nil:
+			log.Println("Shutdown aborted, resuming normal operation.")
+			b.accept(true)
+			client.Unpause()
+			if c := stopping.Client; c != nil && clients[c.Fd] == c {
+				c.Write(resp.Encode(errors.New("ERR Errors trying to SHUTDOWN. Check logs."), false))
+			}
+			stopping = nil
+			return false
+		case stopping == nil:
+			if req.Client != nil {
+				log.Println("User requested shutdown...")
 			}
+			// stop taking new connections, and the commands that would keep adding to the replies.
+			b.accept(false)
+			stopDeadline = time.Now().Add(config.ShutdownTimeout)
+			client.Pause(stopDeadline, true)
+		}
+
+		stopping = req
+		if req.Now || len(client.PendingWrites()) == 0 {
+			return true
+		}
+		if !time.Now().Before(stopDeadline) {
+			log.Println("Timed out waiting for the replies of the clients to be written, shutting down anyway.")
+			return true
 		}
+		return false
 	}
 
-	return nil
-}
+	for !progressShutdown() {
+		if time.Now().After(lastCronExecutionTs.Add(cron_frequency)) {
+			for i := 0; i < databases.Count(); i++ {
+				databases.Get(i).AutoDeleteExpiredKeys()
+				databases.Get(i).Rehash(cron_rehash_budget)
+			}
+			if config.Timeout > 0 {
+				closeIdleClients()
+			}
+			if config.MetricsPort != 0 {
+				metrics.Publish(metrics.TakeSnapshot(databases))
+			} else {
+				serverStats.TrackUsedMemory()
+			}
+			lastCronExecutionTs = time.Now()
+		}
+		serverStats.TrackInstantaneousMetrics()
 
-// reads a single RESP-encoded command from the connection, decodes it,
-// and returns a `RedisCmd`.
-func readCommand(c io.ReadWriter) (*eval.RedisCmd, error) {
-	var buffer []byte = make([]byte, 512)
+		if len(postponed) > 0 {
+			resumePostponed()
+		}
 
-	size, err := c.Read(buffer)
+		timeoutMs := event_poll_interval_ms
+		for c := range bufferedReads {
+			if c.Postponed == nil {
+				// the client has commands to run already.
+				timeoutMs = 0
+				break
+			}
+		}
 
-	if err != nil {
-		return nil, err
+		if err := processEvents(timeoutMs, nil); err != nil {
+			return nil
+		}
 	}
 
-	if config.LogRequest {
-		log.Println("Raw input: ", fmt.Sprintf("%q", buffer[:size]))
+	// the dataset is only ever kept in memory, so there's no snapshot to take before exiting.
+	flushPendingWrites(b, threads, disconnect)
+	for _, c := range clients {
+		disconnect(c)
 	}
-	tokens, err := decodeArrayString(buffer[:size])
 
+	// the shutdown is carried out, so that the server can be served again in the same process.
+	shutdown.Abort()
+	client.Unpause()
+	postponed = nil
+	log.Println("Redis is now ready to exit, bye bye...")
+	return nil
+}
+
+// reads the next command the client sent. returns syscall.EAGAIN until a whole command has arrived,
+// see Client.ReadCommand.
+func readCommand(c *client.Client) (*eval.RedisCmd, error) {
+	tokens, err := c.ReadCommand()
 	if err != nil {
 		return nil, err
 	}
@@ -188,26 +489,50 @@ func readCommand(c io.ReadWriter) (*eval.RedisCmd, error) {
 	}, nil
 }
 
-// Decodes the provided RESP-encoded bytes into a
-// slice of decoded string tokens.
-func decodeArrayString(data []byte) ([]string, error) {
-	decodedVal, err := resp.Decode(data)
+// writes the buffered replies of all the clients to their sockets without blocking, in parallel
+// on the I/O threads. clients whose sockets can't take all of their replies are watched for
+// writability (EPOLLOUT) until their output buffers drain, so that a slow client never stalls the loop.
+func flushPendingWrites(b backend, threads *ioThreads, disconnect func(*client.Client)) {
+	pending := client.PendingWrites()
+	drains := make([]bool, len(pending))
+	errs := make([]error, len(pending))
+	threads.run(len(pending), func(i int) {
+		drains[i], errs[i] = pending[i].WriteOut()
+	})
 
-	if err != nil {
-		return []string{}, err
-	}
+	for i, c := range pending {
+		drained, err := drains[i], errs[i]
+		if drained {
+			// nothing is left to write, so this only stops tracking the client's pending writes.
+			drained, err = c.Flush()
+		}
+		if err != nil {
+			log.Printf("Closing the connection to client %d: %s\n", c.Id, err)
+			disconnect(c)
+			continue
+		}
 
-	decodedValues := decodedVal.([]interface{})
+		if drained != awaitingWritable[c.Fd] {
+			continue
+		}
 
-	decodedArray := make([]string, len(decodedValues))
-	for i, v := range decodedValues {
-		decodedArray[i] = v.(string)
+		awaitingWritable[c.Fd] = !drained
+		watchEvents(b, c)
 	}
+}
 
-	return decodedArray, nil
+// watches the client's connection for the events the client is waiting for: readability, unless
+// its command is postponed, and writability while its replies are waiting for room in the socket.
+func watchEvents(b backend, c *client.Client) {
+	b.watch(c, awaitingWritable[c.Fd])
 }
 
-func respond(cmd *eval.RedisCmd, s store.Store, c io.ReadWriter) {
+func respond(cmd *eval.RedisCmd, s store.Store, c *client.Client) {
+	// the invalidations of the keys the client modifies itself follow the reply of its command.
+	tracker := tracking.GetTracker()
+	previous := tracker.BeginCommand(c)
+	defer tracker.EndCommand(previous)
+
 	err := commandhandler.EvalAndRespond(cmd, s, c)
 
 	if err != nil {
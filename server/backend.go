@@ -0,0 +1,102 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+)
+
+// the network backends, set with -net-backend.
+const (
+	// raw epoll over non-blocking sockets, on Linux only.
+	epoll_backend = "epoll"
+
+	// goroutines reading from and writing to each of the connections of net.Listeners, anywhere.
+	net_backend = "net"
+)
+
+// a backend accepts the connections of the clients and tells the event loop what happens to them.
+// The loop runs the commands of all the clients one at a time, whichever the backend, and only
+// ever calls the backend from the goroutine it runs on.
+type backend interface {
+	// returns the addresses the backend listens on.
+	addresses() []string
+
+	// waits for up to timeoutMs milliseconds (forever if -1) for events on the connections.
+	poll(timeoutMs int) ([]netEvent, error)
+
+	// starts watching the new connection for events. It's only closed by closeConn if this fails.
+	serve(conn *connection) error
+
+	// watches the connection of the client for the events it waits for: readability, unless its
+	// command is postponed, and writability if it waits for room for its replies.
+	watch(c *client.Client, writable bool)
+
+	// closes the connection on the fd, whether or not it's served yet.
+	closeConn(fd int)
+
+	// starts or stops accepting new connections, eg. while the server is shutting down.
+	accept(enabled bool)
+
+	// stops listening and releases what the backend holds.
+	close()
+}
+
+// a connection the backend accepted, for the loop to serve as a client.
+type connection struct {
+	fd   int
+	comm io.ReadWriter
+
+	// the addresses of the client and of the server, eg. 127.0.0.1:7379, and whether it's on the unix socket.
+	addr  string
+	laddr string
+	unix  bool
+}
+
+// something that happened on a connection.
+type netEvent struct {
+	// set for the connections that were just accepted.
+	conn *connection
+
+	// the connection the event is about otherwise, and whether there's something to read on it, or
+	// it broke while it wasn't being read from. there's neither if it can only be written to.
+	fd       int
+	readable bool
+	hangup   bool
+}
+
+// returns the addresses the server listens on: the bind list if it's set, the host otherwise.
+func bindAddresses() []string {
+	if addresses := strings.Fields(config.Bind); len(addresses) > 0 {
+		return addresses
+	}
+	return []string{config.Host}
+}
+
+// creates the backend set in the config, listening on the addresses and the ports of the config.
+func newBackend(tlsConfig *tls.Config) (backend, error) {
+	name := config.NetBackend
+	if name == "" {
+		name = net_backend
+		if runtime.GOOS == "linux" {
+			name = epoll_backend
+		}
+	}
+
+	switch name {
+	case epoll_backend:
+		return newEpollBackend(tlsConfig)
+	case net_backend:
+		listeners, err := listenNet(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return newNetBackend(listeners), nil
+	}
+	return nil, fmt.Errorf("unknown network backend %q, it's either %s or %s", name, epoll_backend, net_backend)
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"crypto/tls"
+	"log"
+	"os"
+	"syscall"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	redisio "github.com/shashwatrathod/redis-internals/core/io"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+)
+
+// GREAT video on FDs https://www.youtube.com/watch?v=-gP58pozNuM
+
+// the outcome of the TLS handshake with a client that just connected.
+type handshake struct {
+	conn *connection
+	err  error
+}
+
+// the epoll backend watches the non-blocking sockets of the listeners and of the clients with
+// an epoll instance, on the goroutine running the loop.
+type epollBackend struct {
+	epollFd int
+
+	// the sockets accepting new connections, and whether they accept TLS connections.
+	listeners map[int]bool
+	tlsConfig *tls.Config
+
+	// the unix socket file, removed once the backend is closed.
+	unixSocket string
+
+	// the TLS handshakes run in their own goroutines, which wake the loop up through the pipe once
+	// they're done, so that a client that is slow to complete its handshake doesn't stall the loop.
+	handshakes  chan *handshake
+	wakeFd      int
+	wakeWriteFd int
+
+	events []syscall.EpollEvent
+}
+
+// creates the epoll backend, listening on the addresses and the ports of the config.
+func newEpollBackend(tlsConfig *tls.Config) (backend, error) {
+	// ONLY FOR LINUX
+	// Create a new Epoll through system call.
+	// Epoll can be thought of as an "Observable" in the "Observer" pattern
+	// that monitors the information and passes that information to the observers.
+	epollFd, err := syscall.EpollCreate1(0)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &epollBackend{
+		epollFd:     epollFd,
+		listeners:   make(map[int]bool),
+		tlsConfig:   tlsConfig,
+		handshakes:  make(chan *handshake, max_concurrent_clients),
+		wakeFd:      -1,
+		wakeWriteFd: -1,
+		events:      make([]syscall.EpollEvent, max_concurrent_clients),
+	}
+	if err = b.listen(); err != nil {
+		b.close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// starts listening on the addresses and the ports of the config.
+func (b *epollBackend) listen() error {
+	for _, host := range bindAddresses() {
+		if config.Port != 0 {
+			log.Println("Initializing the server on ", host, ":", config.Port)
+			serverFd, err := listenTCP(b.epollFd, host, config.Port)
+			if err != nil {
+				return err
+			}
+			b.listeners[serverFd] = false
+		}
+
+		if config.TlsPort != 0 {
+			log.Println("Initializing the TLS server on ", host, ":", config.TlsPort)
+			tlsServerFd, err := listenTCP(b.epollFd, host, config.TlsPort)
+			if err != nil {
+				return err
+			}
+			b.listeners[tlsServerFd] = true
+		}
+	}
+
+	if config.UnixSocket != "" {
+		log.Println("Initializing the server on ", config.UnixSocket)
+		unixFd, err := listenUnix(b.epollFd, config.UnixSocket, config.UnixSocketPerm)
+		if err != nil {
+			return err
+		}
+		b.listeners[unixFd] = false
+		b.unixSocket = config.UnixSocket
+	}
+
+	if config.TlsPort == 0 {
+		return nil
+	}
+
+	wakeFds := make([]int, 2)
+	if err := syscall.Pipe2(wakeFds, syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		return err
+	}
+	b.wakeFd, b.wakeWriteFd = wakeFds[0], wakeFds[1]
+	return syscall.EpollCtl(b.epollFd, syscall.EPOLL_CTL_ADD, b.wakeFd, &syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(b.wakeFd),
+	})
+}
+
+func (b *epollBackend) addresses() []string {
+	var addresses []string
+	for fd := range b.listeners {
+		if local, e := syscall.Getsockname(fd); e == nil {
+			addresses = append(addresses, sockaddrString(local))
+		}
+	}
+	return addresses
+}
+
+func (b *epollBackend) poll(timeoutMs int) ([]netEvent, error) {
+	// Wait for new events to be captured.
+	nevents, e := syscall.EpollWait(b.epollFd, b.events, timeoutMs)
+	if e == syscall.EINTR {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	var events []netEvent
+	for i := 0; i < nevents; i++ {
+		var event syscall.EpollEvent = b.events[i]
+
+		if event.Fd == int32(b.wakeFd) {
+			var buf [64]byte
+			for {
+				if n, _ := syscall.Read(b.wakeFd, buf[:]); n <= 0 {
+					break
+				}
+			}
+			events = b.completeHandshakes(events)
+			continue
+		}
+
+		// If there is an event on one of the listeners,
+		// that means a new client wants to connect.
+		if isTLS, isListener := b.listeners[int(event.Fd)]; isListener {
+			if conn := b.acceptConn(int(event.Fd), isTLS); conn != nil {
+				events = append(events, netEvent{conn: conn})
+			}
+			continue
+		}
+
+		// This means we have a new event on the Client's FD.
+		events = append(events, netEvent{
+			fd:       int(event.Fd),
+			readable: event.Events&syscall.EPOLLIN != 0,
+			hangup:   event.Events&(syscall.EPOLLHUP|syscall.EPOLLERR) != 0,
+		})
+	}
+	return events, nil
+}
+
+// accepts a connection on the listener. returns nil if there's none, or if it's a TLS connection,
+// which is handed over to the loop once its handshake completes.
+func (b *epollBackend) acceptConn(listenerFd int, isTLS bool) *connection {
+	// Accept the new connection
+	// the accepted sockets are non-blocking, and aren't inherited by the processes the server starts.
+	conn_fd, conn_address, e := syscall.Accept4(listenerFd, syscall.SOCK_NONBLOCK|syscall.SOCK_CLOEXEC)
+	if e != nil {
+		log.Println("An error occurred while accepting connection from a client: ", e)
+		return nil
+	}
+
+	serverStats := stats.GetStats()
+	serverStats.ConnectedClients.Add(1)
+	serverStats.TotalConnectionsReceived.Add(1)
+
+	conn := &connection{fd: conn_fd, addr: sockaddrString(conn_address)}
+	if local, e := syscall.Getsockname(conn_fd); e == nil {
+		conn.laddr = sockaddrString(local)
+	}
+	if _, conn.unix = conn_address.(*syscall.SockaddrUnix); conn.unix {
+		// the clients of the unix socket are unnamed, so they go by the path of the socket, like in Redis.
+		conn.addr = config.UnixSocket + ":0"
+	}
+	log.Printf("Successfully accepted a connection from %s. Concurrent Clients = %d\n", conn.addr, serverStats.ConnectedClients.Load())
+
+	if !conn.unix {
+		if e := configureClientSocket(conn_fd); e != nil {
+			log.Println("Error while configuring the socket of the client: ", e)
+		}
+	}
+
+	if !isTLS {
+		conn.comm = &redisio.FDComm{Fd: conn_fd}
+		return conn
+	}
+
+	go func() {
+		comm := redisio.NewTLSComm(conn_fd, b.tlsConfig)
+		err := comm.Handshake(tls_handshake_timeout)
+		conn.comm = comm
+		b.handshakes <- &handshake{conn: conn, err: err}
+		// the pipe being full means the loop is about to wake up anyway.
+		syscall.Write(b.wakeWriteFd, []byte{0})
+	}()
+	return nil
+}
+
+// adds the connections that completed their TLS handshakes to the events.
+func (b *epollBackend) completeHandshakes(events []netEvent) []netEvent {
+	for {
+		select {
+		case h := <-b.handshakes:
+			if h.err != nil {
+				log.Printf("Closing the connection from %s, the TLS handshake failed: %s\n", h.conn.addr, h.err)
+				stats.GetStats().ConnectedClients.Add(-1)
+				syscall.Close(h.conn.fd)
+				continue
+			}
+			events = append(events, netEvent{conn: h.conn})
+		default:
+			return events
+		}
+	}
+}
+
+func (b *epollBackend) serve(conn *connection) error {
+	// Event where the Client's Fd is ready to be read.
+	// This basically means we have new data/information incoming from the client.
+	var clientEvent *syscall.EpollEvent = &syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(conn.fd),
+	}
+
+	// Add a new "Observer" to listen for events on the Client's FD.
+	return syscall.EpollCtl(b.epollFd, syscall.EPOLL_CTL_ADD, conn.fd, clientEvent)
+}
+
+func (b *epollBackend) watch(c *client.Client, writable bool) {
+	var events uint32 = 0
+	if c.Postponed == nil {
+		events |= syscall.EPOLLIN
+	}
+	if writable {
+		events |= syscall.EPOLLOUT
+	}
+
+	syscall.EpollCtl(b.epollFd, syscall.EPOLL_CTL_MOD, c.Fd, &syscall.EpollEvent{
+		Events: events,
+		Fd:     int32(c.Fd),
+	})
+}
+
+func (b *epollBackend) closeConn(fd int) {
+	syscall.Close(fd)
+}
+
+func (b *epollBackend) accept(enabled bool) {
+	op := syscall.EPOLL_CTL_DEL
+	if enabled {
+		op = syscall.EPOLL_CTL_ADD
+	}
+	for fd := range b.listeners {
+		syscall.EpollCtl(b.epollFd, op, fd, &syscall.EpollEvent{
+			Events: syscall.EPOLLIN,
+			Fd:     int32(fd),
+		})
+	}
+}
+
+func (b *epollBackend) close() {
+	for fd := range b.listeners {
+		syscall.Close(fd)
+	}
+	if b.unixSocket != "" {
+		os.Remove(b.unixSocket)
+	}
+	if b.wakeFd != -1 {
+		syscall.Close(b.wakeFd)
+		syscall.Close(b.wakeWriteFd)
+	}
+	syscall.Close(b.epollFd)
+}
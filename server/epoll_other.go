@@ -0,0 +1,13 @@
+//go:build !linux
+
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+)
+
+// epoll is only there on Linux, the net backend serves the connections anywhere else.
+func newEpollBackend(tlsConfig *tls.Config) (backend, error) {
+	return nil, errors.New("the epoll backend is only available on Linux, use the net backend instead")
+}
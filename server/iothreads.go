@@ -0,0 +1,70 @@
+package server
+
+import "sync"
+
+// the most I/O threads the server runs, like in Redis.
+const max_io_threads = 128
+
+// a batch of jobs, of which a thread runs the ones from start on, stride apart.
+type ioTask struct {
+	job    func(i int)
+	n      int
+	start  int
+	stride int
+}
+
+// threads that read the commands from the sockets and write the replies to them in parallel. the
+// goroutine running the loop is one of them, and runs the commands on its own once they're read.
+type ioThreads struct {
+	tasks []chan ioTask
+	done  sync.WaitGroup
+}
+
+// starts the I/O threads besides the goroutine running the loop, count-1 of them.
+func newIoThreads(count int) *ioThreads {
+	threads := &ioThreads{tasks: make([]chan ioTask, count-1)}
+	for t := range threads.tasks {
+		tasks := make(chan ioTask)
+		threads.tasks[t] = tasks
+		go func() {
+			for task := range tasks {
+				task.runShare()
+				threads.done.Done()
+			}
+		}()
+	}
+	return threads
+}
+
+// runs the jobs of the task that fall to this thread.
+func (task ioTask) runShare() {
+	for i := task.start; i < task.n; i += task.stride {
+		task.job(i)
+	}
+}
+
+// runs job(0) to job(n-1), spread across the threads, and returns once they're all done. the jobs
+// mustn't touch anything but the client they're given, eg. the keyspace or the other clients.
+func (threads *ioThreads) run(n int, job func(i int)) {
+	count := len(threads.tasks) + 1
+
+	// handing out a few jobs costs more than running them, so they run on the loop, like in Redis.
+	if n < count*2 {
+		ioTask{job: job, n: n, start: 0, stride: 1}.runShare()
+		return
+	}
+
+	threads.done.Add(len(threads.tasks))
+	for t, tasks := range threads.tasks {
+		tasks <- ioTask{job: job, n: n, start: t + 1, stride: count}
+	}
+	ioTask{job: job, n: n, start: 0, stride: count}.runShare()
+	threads.done.Wait()
+}
+
+// stops the threads besides the goroutine running the loop.
+func (threads *ioThreads) stop() {
+	for _, tasks := range threads.tasks {
+		close(tasks)
+	}
+}
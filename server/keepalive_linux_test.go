@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// returns the options of the socket of the connection, as option name to value.
+func socketOptions(conn *net.TCPConn) map[string]int {
+	raw, err := conn.SyscallConn()
+	Expect(err).NotTo(HaveOccurred())
+
+	options := map[string]int{}
+	Expect(raw.Control(func(fd uintptr) {
+		options = fdOptions(int(fd))
+	})).To(Succeed())
+	return options
+}
+
+// returns the options of the socket, as option name to value.
+func fdOptions(fd int) map[string]int {
+	get := func(level int, option int) int {
+		value, err := syscall.GetsockoptInt(fd, level, option)
+		Expect(err).NotTo(HaveOccurred())
+		return value
+	}
+
+	return map[string]int{
+		"nodelay":   get(syscall.IPPROTO_TCP, syscall.TCP_NODELAY),
+		"keepalive": get(syscall.SOL_SOCKET, syscall.SO_KEEPALIVE),
+		"idle":      get(syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE),
+		"interval":  get(syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL),
+		"count":     get(syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT),
+	}
+}
+
+var _ = Describe("Client sockets", func() {
+	var conn *net.TCPConn
+
+	BeforeEach(func() {
+		keepalive := config.TcpKeepalive
+		DeferCleanup(func() {
+			config.TcpKeepalive = keepalive
+		})
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer l.Close()
+
+		client, err := net.Dial("tcp", l.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { client.Close() })
+
+		accepted, err := l.Accept()
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { accepted.Close() })
+		conn = accepted.(*net.TCPConn)
+	})
+
+	It("probes the peers every third of tcp-keepalive on the net backend", func() {
+		config.TcpKeepalive = 60
+		configureNetConn(conn)
+
+		options := socketOptions(conn)
+		Expect(options["nodelay"]).NotTo(BeZero())
+		Expect(options["keepalive"]).NotTo(BeZero())
+		Expect(options["idle"]).To(Equal(60))
+		Expect(options["interval"]).To(Equal(20))
+		Expect(options["count"]).To(Equal(3))
+	})
+
+	It("turns keepalive off on the net backend when tcp-keepalive is 0", func() {
+		config.TcpKeepalive = 0
+		configureNetConn(conn)
+
+		Expect(socketOptions(conn)["keepalive"]).To(BeZero())
+	})
+
+	It("probes the peers every third of tcp-keepalive on the epoll backend", func() {
+		config.TcpKeepalive = 2
+
+		raw, err := conn.SyscallConn()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw.Control(func(fd uintptr) {
+			Expect(configureClientSocket(int(fd))).To(Succeed())
+		})).To(Succeed())
+
+		options := socketOptions(conn)
+		Expect(options["nodelay"]).NotTo(BeZero())
+		Expect(options["keepalive"]).NotTo(BeZero())
+		Expect(options["idle"]).To(Equal(2))
+		// the probes are at least a second apart.
+		Expect(options["interval"]).To(Equal(1))
+		Expect(options["count"]).To(Equal(3))
+	})
+})
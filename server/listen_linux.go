@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// creates a non-blocking socket listening on the IPv4 or IPv6 address and the port, and watches
+// it for new connections.
+func listenTCP(epollFd int, host string, port int) (int, error) {
+	// the link local IPv6 addresses name the interface they're on, eg. fe80::1%eth0.
+	host, zone, _ := strings.Cut(host, "%")
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		// a host name, eg. localhost, listens on the first of its addresses.
+		ips, err := net.LookupIP(host)
+		if err != nil || len(ips) == 0 {
+			return -1, fmt.Errorf("invalid bind address %q", host)
+		}
+		ip = ips[0]
+	}
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		addr := &syscall.SockaddrInet4{Port: port}
+		copy(addr.Addr[:], ipv4)
+		return listen(epollFd, syscall.AF_INET, addr)
+	}
+
+	addr := &syscall.SockaddrInet6{Port: port}
+	copy(addr.Addr[:], ip.To16())
+	if zone != "" {
+		iface, err := net.InterfaceByName(zone)
+		if err != nil {
+			return -1, fmt.Errorf("invalid bind address %q: %w", host+"%"+zone, err)
+		}
+		addr.ZoneId = uint32(iface.Index)
+	}
+	return listen(epollFd, syscall.AF_INET6, addr)
+}
+
+// creates a non-blocking unix socket listening on the path, and watches it for new connections.
+// The socket left behind by a previous run is replaced. perm sets the permissions of the socket
+// file, which are left to the umask if it's 0.
+func listenUnix(epollFd int, path string, perm uint32) (int, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return -1, fmt.Errorf("error removing the unix socket %s: %w", path, err)
+	}
+
+	serverFd, err := listen(epollFd, syscall.AF_UNIX, &syscall.SockaddrUnix{Name: path})
+	if err != nil {
+		return -1, err
+	}
+
+	if perm != 0 {
+		if err = os.Chmod(path, os.FileMode(perm)); err != nil {
+			syscall.Close(serverFd)
+			return -1, err
+		}
+	}
+	return serverFd, nil
+}
+
+// creates a non-blocking socket of the family listening on the address, and watches it for new connections.
+func listen(epollFd int, family int, addr syscall.Sockaddr) (int, error) {
+	// First initialize a socket.
+	// O_NONBLOCK creates the socket in a non-blocking mode.
+	// SOCK_STREAM sets the type of socket to STREAM.
+	serverFd, err := syscall.Socket(family, syscall.O_NONBLOCK|syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return -1, err
+	}
+
+	// Enable nonblocking behavior on the socket server.
+	if err = syscall.SetNonblock(serverFd, true); err != nil {
+		syscall.Close(serverFd)
+		return -1, err
+	}
+
+	if family != syscall.AF_UNIX {
+		// a restarted server binds to its port again right away, while the connections of the
+		// previous one linger in TIME_WAIT.
+		if err = syscall.SetsockoptInt(serverFd, syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
+			syscall.Close(serverFd)
+			return -1, err
+		}
+	}
+
+	if family == syscall.AF_INET6 {
+		// the IPv6 sockets only accept IPv6 connections, so that the IPv4 and the IPv6 wildcard
+		// addresses, eg. 0.0.0.0 and ::, can both be bound to the same port.
+		if err = syscall.SetsockoptInt(serverFd, syscall.IPPROTO_IPV6, syscall.IPV6_V6ONLY, 1); err != nil {
+			syscall.Close(serverFd)
+			return -1, err
+		}
+	}
+
+	// Bind the socket to the given address.
+	if err = syscall.Bind(serverFd, addr); err != nil {
+		syscall.Close(serverFd)
+		return -1, fmt.Errorf("error binding to %s: %w", sockaddrString(addr), err)
+	}
+
+	// Start listening on the socket server for new connections.
+	//  max_concurrent_clients specifies the max number of clients that can be in the queue.
+	if err = syscall.Listen(serverFd, max_concurrent_clients); err != nil {
+		syscall.Close(serverFd)
+		return -1, err
+	}
+
+	// This is the event that is going to be "observed".
+	// EPOLLIN gets fired when a file descriptor (in this case serverFd) is ready to be read.
+	var socketEvent = &syscall.EpollEvent{
+		Events: syscall.EPOLLIN,
+		Fd:     int32(serverFd),
+	}
+
+	// Adds a new "observer" for the Event. The observer gets attached to Observable (EPOLL)'s FD.
+	if err = syscall.EpollCtl(epollFd, syscall.EPOLL_CTL_ADD, serverFd, socketEvent); err != nil {
+		syscall.Close(serverFd)
+		return -1, err
+	}
+
+	return serverFd, nil
+}
+
+// formats the socket address as ip:port, [ip]:port for IPv6, or path:0 for the unix sockets, like
+// Redis does. returns an empty string for the unsupported addresses.
+func sockaddrString(sa syscall.Sockaddr) string {
+	switch addr := sa.(type) {
+	case *syscall.SockaddrInet4:
+		ip := net.IP(addr.Addr[:])
+		return net.JoinHostPort(ip.String(), strconv.Itoa(addr.Port))
+	case *syscall.SockaddrInet6:
+		ip := net.IP(addr.Addr[:])
+		return net.JoinHostPort(ip.String(), strconv.Itoa(addr.Port))
+	case *syscall.SockaddrUnix:
+		return addr.Name + ":0"
+	}
+	return ""
+}
+
+// configures the TCP socket of a client that just connected: the replies are sent right away instead
+// of being held back to be batched, and the peers that went away are detected by keepalive probes.
+func configureClientSocket(fd int) error {
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_NODELAY, 1); err != nil {
+		return err
+	}
+
+	if config.TcpKeepalive <= 0 {
+		return nil
+	}
+
+	// like Redis, the peer is given up on if it doesn't answer 3 probes sent a third of the interval apart.
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_KEEPALIVE, 1); err != nil {
+		return err
+	}
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_KEEPIDLE, config.TcpKeepalive); err != nil {
+		return err
+	}
+	if err := syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, max(config.TcpKeepalive/3, 1)); err != nil {
+		return err
+	}
+	return syscall.SetsockoptInt(fd, syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, 3)
+}
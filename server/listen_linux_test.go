@@ -0,0 +1,169 @@
+package server_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/server"
+)
+
+// connects to the server over the network, and closes the connection once the spec is done.
+func dial(network string, addr string) net.Conn {
+	conn, err := net.Dial(network, addr)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() { conn.Close() })
+	return conn
+}
+
+// returns a port nothing listens on, on either loopback address.
+func freePort() int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+var _ = Describe("Epoll listeners", func() {
+	var port int
+	var socket string
+
+	BeforeEach(func() {
+		host, bind, serverPort := config.Host, config.Bind, config.Port
+		unixSocket, unixSocketPerm, netBackend := config.UnixSocket, config.UnixSocketPerm, config.NetBackend
+		DeferCleanup(func() {
+			config.Host, config.Bind, config.Port = host, bind, serverPort
+			config.UnixSocket, config.UnixSocketPerm, config.NetBackend = unixSocket, unixSocketPerm, netBackend
+		})
+
+		port = freePort()
+		socket = filepath.Join(GinkgoT().TempDir(), "redis.sock")
+		config.NetBackend = "epoll"
+		config.Port = port
+		config.Bind = ""
+		config.UnixSocket = ""
+		config.UnixSocketPerm = 0
+	})
+
+	// runs the server on the config, and shuts it down from a connection to the address once the
+	// spec is done.
+	run := func(network string, addr string) {
+		served := make(chan error, 1)
+		go func() {
+			served <- server.RunAsyncTcpServer()
+		}()
+
+		var admin net.Conn
+		Eventually(func() error {
+			var err error
+			admin, err = net.Dial(network, addr)
+			return err
+		}, 5*time.Second).Should(Succeed())
+
+		DeferCleanup(func() {
+			defer admin.Close()
+			send(admin, "FLUSHALL")
+			expectReply(admin, "+OK\r\n")
+			send(admin, "SHUTDOWN", "NOW")
+			Eventually(served, 5*time.Second).Should(Receive(BeNil()))
+		})
+	}
+
+	It("listens on both the IPv4 and the IPv6 addresses of the bind list", func() {
+		config.Bind = "127.0.0.1 ::1"
+		ipv4 := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+		ipv6 := net.JoinHostPort("::1", strconv.Itoa(port))
+		run("tcp", ipv4)
+
+		conn := dial("tcp", ipv6)
+		send(conn, "PING")
+		expectReply(conn, "+PONG\r\n")
+
+		conn = dial("tcp", ipv4)
+		send(conn, "PING")
+		expectReply(conn, "+PONG\r\n")
+	})
+
+	It("listens on the IPv6 addresses scoped to an interface", func() {
+		config.Bind = "::1%lo"
+		addr := net.JoinHostPort("::1", strconv.Itoa(port))
+		run("tcp", addr)
+
+		conn := dial("tcp", addr)
+		send(conn, "PING")
+		expectReply(conn, "+PONG\r\n")
+	})
+
+	It("rejects the addresses scoped to an interface that doesn't exist", func() {
+		config.Bind = "::1%nonexistent0"
+
+		Expect(server.RunAsyncTcpServer()).To(MatchError(ContainSubstring("invalid bind address")))
+	})
+
+	It("runs the commands pipelined by many clients with io-threads", func() {
+		ioThreads := config.IoThreads
+		config.IoThreads = 4
+		DeferCleanup(func() {
+			config.IoThreads = ioThreads
+		})
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+		config.Host = "127.0.0.1"
+		run("tcp", addr)
+
+		conns := make([]net.Conn, 16)
+		for i := range conns {
+			conns[i] = dial("tcp", addr)
+			pipeline(conns[i], []string{"SET", "k" + strconv.Itoa(i), "v"}, []string{"GET", "k" + strconv.Itoa(i)}, []string{"PING"})
+		}
+		for _, conn := range conns {
+			expectReply(conn, "+OK\r\n$1\r\nv\r\n+PONG\r\n")
+		}
+	})
+
+	It("turns the clients away past maxclients", func() {
+		maxClients := config.MaxClients
+		config.MaxClients = 2
+		DeferCleanup(func() {
+			config.MaxClients = maxClients
+		})
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+		config.Host = "127.0.0.1"
+		run("tcp", addr)
+
+		conn := dial("tcp", addr)
+		send(conn, "PING")
+		expectReply(conn, "+PONG\r\n")
+
+		expectReply(dial("tcp", addr), "-ERR max number of clients reached\r\n")
+	})
+
+	It("listens on the unix socket with its permissions, and removes it once shut down", func() {
+		config.UnixSocket = socket
+		config.UnixSocketPerm = 0700
+		config.Host = "127.0.0.1"
+
+		// the socket left behind by a previous run is replaced.
+		Expect(os.WriteFile(socket, nil, 0644)).To(Succeed())
+		// runs once the server is shut down, as the cleanups run in the reverse order.
+		DeferCleanup(func() {
+			Expect(socket).NotTo(BeAnExistingFile())
+		})
+		run("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+
+		info, err := os.Stat(socket)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode() & os.ModeSocket).NotTo(BeZero())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+
+		conn := dial("unix", socket)
+		send(conn, "SET", "k", "v")
+		expectReply(conn, "+OK\r\n")
+		send(conn, "GET", "k")
+		expectReply(conn, "$1\r\nv\r\n")
+	})
+})
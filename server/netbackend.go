@@ -0,0 +1,376 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/client"
+	redisio "github.com/shashwatrathod/redis-internals/core/io"
+	"github.com/shashwatrathod/redis-internals/core/stats"
+)
+
+// how long to wait before accepting again after failing to, eg. because the process ran out of fds.
+const accept_retry_delay = 10 * time.Millisecond
+
+// the net backend serves each of the connections of the listeners with goroutines of its own, see
+// redisio.NetComm, which tell the loop what happens to them through a channel. It runs anywhere Go
+// does, over any net.Listener.
+type netBackend struct {
+	listeners []net.Listener
+
+	events chan netEvent
+	done   chan struct{}
+
+	// the connections are counted by the goroutines accepting them.
+	serverStats *stats.Stats
+
+	// the goroutines accepting and serving the connections.
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+
+	// the connections handed over to the loop, keyed by their fds.
+	conns map[int]*redisio.NetComm
+
+	// the fd of the next connection that has none, eg. a net.Pipe. they count down from -2, as the
+	// fake clients, eg. the one running the scripts, go by -1.
+	nextFd int
+
+	// set while the new connections aren't handed over to the loop, and closed once they are again.
+	paused chan struct{}
+}
+
+// creates the net backend, accepting the connections of the listeners.
+func newNetBackend(listeners []net.Listener) *netBackend {
+	b := &netBackend{
+		listeners:   listeners,
+		events:      make(chan netEvent, max_concurrent_clients),
+		done:        make(chan struct{}),
+		serverStats: stats.GetStats(),
+		conns:       make(map[int]*redisio.NetComm),
+		nextFd:      -2,
+	}
+	for _, l := range listeners {
+		b.wg.Add(1)
+		go b.acceptLoop(l)
+	}
+	return b
+}
+
+// listens on the addresses and the ports of the config.
+func listenNet(tlsConfig *tls.Config) ([]net.Listener, error) {
+	var listeners []net.Listener
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return nil, err
+	}
+
+	for _, host := range bindAddresses() {
+		if config.Port != 0 {
+			log.Println("Initializing the server on ", host, ":", config.Port)
+			l, err := listenNetTCP(host, config.Port)
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, l)
+		}
+
+		if config.TlsPort != 0 {
+			log.Println("Initializing the TLS server on ", host, ":", config.TlsPort)
+			l, err := listenNetTCP(host, config.TlsPort)
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, tls.NewListener(l, tlsConfig))
+		}
+	}
+
+	if config.UnixSocket != "" {
+		log.Println("Initializing the server on ", config.UnixSocket)
+		if err := os.Remove(config.UnixSocket); err != nil && !os.IsNotExist(err) {
+			return fail(fmt.Errorf("error removing the unix socket %s: %w", config.UnixSocket, err))
+		}
+		// the socket file is removed once the listener is closed.
+		l, err := net.Listen("unix", config.UnixSocket)
+		if err != nil {
+			return fail(err)
+		}
+		listeners = append(listeners, l)
+
+		if config.UnixSocketPerm != 0 {
+			if err = os.Chmod(config.UnixSocket, os.FileMode(config.UnixSocketPerm)); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	return listeners, nil
+}
+
+// listens on the IPv4 or the IPv6 address only, like the epoll backend, so that the IPv4 and the
+// IPv6 wildcard addresses, eg. 0.0.0.0 and ::, can both be bound to the same port.
+func listenNetTCP(host string, port int) (net.Listener, error) {
+	network := "tcp"
+	ip, _, _ := strings.Cut(host, "%")
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() != nil {
+		network = "tcp4"
+	} else if parsed != nil {
+		network = "tcp6"
+	}
+	return net.Listen(network, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// accepts the connections of the listener until it's closed.
+func (b *netBackend) acceptLoop(l net.Listener) {
+	defer b.wg.Done()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-b.done:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Println("An error occurred while accepting connection from a client: ", err)
+			time.Sleep(accept_retry_delay)
+			continue
+		}
+
+		b.serverStats.ConnectedClients.Add(1)
+		b.serverStats.TotalConnectionsReceived.Add(1)
+
+		b.wg.Add(1)
+		go b.serveConn(l, conn)
+	}
+}
+
+// completes the TLS handshake of the connection if it's a TLS one, hands it over to the loop, and
+// serves it until it's closed.
+func (b *netBackend) serveConn(l net.Listener, conn net.Conn) {
+	defer b.wg.Done()
+
+	c := &connection{addr: conn.RemoteAddr().String(), laddr: conn.LocalAddr().String()}
+	if l.Addr().Network() == "unix" {
+		// the clients of the unix socket are unnamed, so they go by the path of the socket, like in Redis.
+		c.addr = l.Addr().String() + ":0"
+		c.laddr = c.addr
+		c.unix = true
+	}
+	log.Printf("Successfully accepted a connection from %s. Concurrent Clients = %d\n", c.addr, b.serverStats.ConnectedClients.Load())
+
+	configureNetConn(conn)
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		tlsConn.SetDeadline(time.Now().Add(tls_handshake_timeout))
+		err := tlsConn.Handshake()
+		tlsConn.SetDeadline(time.Time{})
+		if err != nil {
+			log.Printf("Closing the connection from %s, the TLS handshake failed: %s\n", c.addr, err)
+			b.serverStats.ConnectedClients.Add(-1)
+			conn.Close()
+			return
+		}
+	}
+
+	fd, comm := b.register(conn)
+	if comm == nil {
+		b.serverStats.ConnectedClients.Add(-1)
+		conn.Close()
+		return
+	}
+	c.fd, c.comm = fd, comm
+
+	// the events of the connection follow the one handing it over, so the loop knows the client by then.
+	b.post(netEvent{conn: c})
+	comm.Serve()
+}
+
+// keeps track of the connection once the new connections are handed over to the loop, waiting
+// for them to be again if they aren't. returns a nil comm if the backend is closed in the meantime.
+func (b *netBackend) register(conn net.Conn) (int, *redisio.NetComm) {
+	for {
+		b.mu.Lock()
+		select {
+		case <-b.done:
+			// the backend closed the connections it knows of already.
+			b.mu.Unlock()
+			return 0, nil
+		default:
+		}
+
+		paused := b.paused
+		if paused == nil {
+			fd := b.connFd(conn)
+			comm := redisio.NewNetComm(conn, func() {
+				b.post(netEvent{fd: fd, readable: true})
+			})
+			b.conns[fd] = comm
+			b.mu.Unlock()
+			return fd, comm
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-paused:
+		case <-b.done:
+			return 0, nil
+		}
+	}
+}
+
+// returns the fd of the connection, or one counting down from -2 if it has none.
+func (b *netBackend) connFd(conn net.Conn) int {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if sc, ok := conn.(syscall.Conn); ok {
+		if raw, err := sc.SyscallConn(); err == nil {
+			fd := -1
+			if raw.Control(func(s uintptr) { fd = int(s) }) == nil && fd >= 0 {
+				return fd
+			}
+		}
+	}
+	fd := b.nextFd
+	b.nextFd--
+	return fd
+}
+
+// sends the replies right away instead of holding them back to be batched, and detects the peers
+// that went away with keepalive probes, like the epoll backend does.
+func configureNetConn(conn net.Conn) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+
+	tcpConn.SetNoDelay(true)
+	if config.TcpKeepalive <= 0 {
+		tcpConn.SetKeepAlive(false)
+		return
+	}
+	interval := time.Duration(config.TcpKeepalive) * time.Second
+	tcpConn.SetKeepAliveConfig(net.KeepAliveConfig{
+		Enable:   true,
+		Idle:     interval,
+		Interval: max(interval/3, time.Second),
+		Count:    3,
+	})
+}
+
+// tells the loop about the event, unless the backend is closed.
+func (b *netBackend) post(event netEvent) {
+	select {
+	case b.events <- event:
+	case <-b.done:
+	}
+}
+
+func (b *netBackend) addresses() []string {
+	var addresses []string
+	for _, l := range b.listeners {
+		addresses = append(addresses, l.Addr().String())
+	}
+	return addresses
+}
+
+func (b *netBackend) poll(timeoutMs int) ([]netEvent, error) {
+	events := b.receive(nil)
+	if len(events) > 0 || timeoutMs == 0 {
+		return events, nil
+	}
+
+	var timeout <-chan time.Time
+	if timeoutMs > 0 {
+		timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case event := <-b.events:
+		return b.receive(append(events, event)), nil
+	case <-timeout:
+		return nil, nil
+	}
+}
+
+// adds the events that were sent already to the events.
+func (b *netBackend) receive(events []netEvent) []netEvent {
+	for len(events) < max_concurrent_clients {
+		select {
+		case event := <-b.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+	return events
+}
+
+// the goroutines of the connection start reading from it once it's handed over, so there's nothing to watch.
+func (b *netBackend) serve(conn *connection) error {
+	return nil
+}
+
+// the goroutines of the connection tell when there's something to read, or more room for the
+// replies, on their own. the postponed commands hold the next ones back, so there's nothing to watch.
+func (b *netBackend) watch(c *client.Client, writable bool) {}
+
+func (b *netBackend) closeConn(fd int) {
+	b.mu.Lock()
+	comm := b.conns[fd]
+	delete(b.conns, fd)
+	b.mu.Unlock()
+
+	if comm != nil {
+		comm.Close()
+	}
+}
+
+func (b *netBackend) accept(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if enabled && b.paused != nil {
+		close(b.paused)
+		b.paused = nil
+	} else if !enabled && b.paused == nil {
+		b.paused = make(chan struct{})
+	}
+}
+
+// closes the listeners and the connections, and waits for the goroutines serving them to return.
+func (b *netBackend) close() {
+	close(b.done)
+	for _, l := range b.listeners {
+		l.Close()
+	}
+
+	b.mu.Lock()
+	for fd, comm := range b.conns {
+		comm.Close()
+		delete(b.conns, fd)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package server
+
+// the open files limit can't be raised outside of the unix systems, so maxclients is left as it is.
+func adjustOpenFilesLimit() {}
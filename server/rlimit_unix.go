@@ -0,0 +1,42 @@
+//go:build unix
+
+package server
+
+import (
+	"log"
+	"syscall"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// the file descriptors kept for the listeners, the epoll instance, if any, and the other files of the
+// server, on top of the ones of the clients.
+const reserved_fds = 32
+
+// raises the open files limit of the process so that it fits maxclients, or lowers maxclients to
+// what the limit fits if it can't be raised.
+func adjustOpenFilesLimit() {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		log.Println("Unable to obtain the current NOFILE limit, assuming it fits maxclients: ", err)
+		return
+	}
+
+	needed := uint64(config.MaxClients + reserved_fds)
+	if limit.Cur >= needed {
+		return
+	}
+
+	raised := limit
+	raised.Cur = min(needed, limit.Max)
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &raised); err == nil {
+		limit = raised
+	}
+
+	if limit.Cur < needed {
+		maxClients := max(int(limit.Cur)-reserved_fds, 1)
+		log.Printf("The open files limit of %d only fits %d clients, maxclients is lowered from %d.\n",
+			limit.Cur, maxClients, config.MaxClients)
+		config.MaxClients = maxClients
+	}
+}
@@ -0,0 +1,274 @@
+package server_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/config"
+	"github.com/shashwatrathod/redis-internals/core/resp"
+	"github.com/shashwatrathod/redis-internals/server"
+)
+
+func TestServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Server Suite")
+}
+
+// connects to the server, and closes the connection once the spec is done.
+func connect(addr string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() { conn.Close() })
+	return conn
+}
+
+// sends the command to the server.
+func send(conn net.Conn, args ...string) {
+	_, err := conn.Write(resp.Encode(args, false))
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// sends the commands to the server in a single write, as a client pipelining them does.
+func pipeline(conn net.Conn, commands ...[]string) {
+	var buf []byte
+	for _, args := range commands {
+		buf = append(buf, resp.Encode(args, false)...)
+	}
+	_, err := conn.Write(buf)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// reads until the reply the server sent is as long as the expected one.
+func expectReply(conn net.Conn, expected string) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply := make([]byte, 0, len(expected))
+	buf := make([]byte, 512)
+	for len(reply) < len(expected) {
+		n, err := conn.Read(buf)
+		Expect(err).NotTo(HaveOccurred())
+		reply = append(reply, buf[:n]...)
+	}
+	Expect(string(reply)).To(Equal(expected))
+}
+
+var _ = Describe("Serve", func() {
+	var addr string
+	var served chan error
+	// the connection the server is shut down from.
+	var admin net.Conn
+
+	// the server is started once the specs are done changing the config, which it reads as it runs.
+	JustBeforeEach(func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		addr = l.Addr().String()
+
+		served = make(chan error, 1)
+		go func() {
+			served <- server.Serve(l)
+		}()
+
+		// the server is shut down from the connection it served first, which is within maxclients.
+		admin, err = net.Dial("tcp", addr)
+		Expect(err).NotTo(HaveOccurred())
+		send(admin, "PING")
+		expectReply(admin, "+PONG\r\n")
+
+		DeferCleanup(func() {
+			defer admin.Close()
+			send(admin, "FLUSHALL")
+			expectReply(admin, "+OK\r\n")
+			send(admin, "SHUTDOWN", "NOW")
+			Eventually(served, 5*time.Second).Should(Receive(BeNil()))
+		})
+	})
+
+	It("runs the commands of the clients", func() {
+		conn := connect(addr)
+
+		send(conn, "PING")
+		expectReply(conn, "+PONG\r\n")
+
+		send(conn, "SET", "k", "v")
+		expectReply(conn, "+OK\r\n")
+		send(conn, "GET", "k")
+		expectReply(conn, "$1\r\nv\r\n")
+	})
+
+	It("shares the keyspace between the clients", func() {
+		writer := connect(addr)
+		send(writer, "SET", "k", "v")
+		expectReply(writer, "+OK\r\n")
+
+		reader := connect(addr)
+		send(reader, "GET", "k")
+		expectReply(reader, "$1\r\nv\r\n")
+	})
+
+	It("delivers the messages published by a client to the subscribers", func() {
+		subscriber := connect(addr)
+		send(subscriber, "SUBSCRIBE", "news")
+		expectReply(subscriber, "*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+
+		publisher := connect(addr)
+		send(publisher, "PUBLISH", "news", "hello")
+		expectReply(publisher, ":1\r\n")
+		expectReply(subscriber, "*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n")
+	})
+
+	It("runs all the commands pipelined in a single write", func() {
+		conn := connect(addr)
+
+		pipeline(conn, []string{"SET", "k", "v"}, []string{"GET", "k"}, []string{"PING"})
+		expectReply(conn, "+OK\r\n$1\r\nv\r\n+PONG\r\n")
+	})
+
+	It("runs the commands split across writes once they've fully arrived", func() {
+		conn := connect(addr)
+		command := resp.Encode([]string{"SET", "k", "v"}, false)
+
+		_, err := conn.Write(command[:5])
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(func() error {
+			conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+			_, err := conn.Read(make([]byte, 1))
+			return err
+		}, 100*time.Millisecond).Should(MatchError(ContainSubstring("timeout")))
+
+		_, err = conn.Write(command[5:])
+		Expect(err).NotTo(HaveOccurred())
+		expectReply(conn, "+OK\r\n")
+	})
+
+	It("runs the commands longer than a read", func() {
+		conn := connect(addr)
+		value := strings.Repeat("v", 100*1024)
+
+		pipeline(conn, []string{"SET", "k", value}, []string{"GET", "k"})
+		expectReply(conn, "+OK\r\n$"+strconv.Itoa(len(value))+"\r\n"+value+"\r\n")
+	})
+
+	Context("with io-threads set", func() {
+		BeforeEach(func() {
+			ioThreads := config.IoThreads
+			config.IoThreads = 4
+			DeferCleanup(func() {
+				config.IoThreads = ioThreads
+			})
+		})
+
+		It("runs the commands pipelined by many clients at once", func() {
+			// the threads are only handed the reads of at least twice as many clients as there are threads.
+			conns := make([]net.Conn, 16)
+			for i := range conns {
+				conns[i] = connect(addr)
+			}
+
+			for i, conn := range conns {
+				key := "k" + strconv.Itoa(i)
+				commands := [][]string{}
+				for j := 0; j < 50; j++ {
+					commands = append(commands, []string{"SET", key, strconv.Itoa(j)})
+				}
+				pipeline(conn, append(commands, []string{"GET", key})...)
+			}
+
+			for _, conn := range conns {
+				expectReply(conn, strings.Repeat("+OK\r\n", 50)+"$2\r\n49\r\n")
+			}
+		})
+	})
+
+	Context("with maxclients set", func() {
+		BeforeEach(func() {
+			maxClients := config.MaxClients
+			config.MaxClients = 2
+			DeferCleanup(func() {
+				config.MaxClients = maxClients
+			})
+		})
+
+		It("turns the clients away past it", func() {
+			conn := connect(addr)
+			send(conn, "PING")
+			expectReply(conn, "+PONG\r\n")
+
+			expectReply(connect(addr), "-ERR max number of clients reached\r\n")
+		})
+	})
+
+	Context("with a metrics port", func() {
+		var metricsAddr string
+
+		BeforeEach(func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			port := l.Addr().(*net.TCPAddr).Port
+			Expect(l.Close()).To(Succeed())
+
+			host, metricsPort := config.Host, config.MetricsPort
+			config.Host = "127.0.0.1"
+			config.MetricsPort = port
+			metricsAddr = net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+			// runs once the server is shut down, as the cleanups run in the reverse order.
+			DeferCleanup(func() {
+				config.Host, config.MetricsPort = host, metricsPort
+
+				l, err := net.Listen("tcp", metricsAddr)
+				Expect(err).NotTo(HaveOccurred())
+				l.Close()
+			})
+		})
+
+		It("serves the metrics until the server shuts down", func() {
+			res, err := http.Get("http://" + metricsAddr + "/metrics")
+			Expect(err).NotTo(HaveOccurred())
+			defer res.Body.Close()
+
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(res.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring("redis_connected_clients"))
+		})
+	})
+
+	Context("with timeout set", func() {
+		BeforeEach(func() {
+			timeout := config.Timeout
+			config.Timeout = 1
+			DeferCleanup(func() {
+				config.Timeout = timeout
+			})
+		})
+
+		It("disconnects the clients idle for longer than it, except for the subscribers", func() {
+			idle := connect(addr)
+			send(idle, "PING")
+			expectReply(idle, "+PONG\r\n")
+
+			subscriber := connect(addr)
+			send(subscriber, "SUBSCRIBE", "news")
+			expectReply(subscriber, "*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+
+			Eventually(func() error {
+				// the connection the server is shut down from is kept busy meanwhile.
+				send(admin, "PING")
+				expectReply(admin, "+PONG\r\n")
+
+				idle.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+				_, err := idle.Read(make([]byte, 1))
+				return err
+			}, 5*time.Second).Should(MatchError(io.EOF))
+
+			send(subscriber, "PING")
+			expectReply(subscriber, "*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+		})
+	})
+})
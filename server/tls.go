@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shashwatrathod/redis-internals/config"
+)
+
+// how long a client has to complete the TLS handshake before it's disconnected.
+const tls_handshake_timeout time.Duration = 10 * time.Second
+
+// TLS versions by the names tls-protocols accepts them by.
+var tlsVersions = map[string]uint16{
+	"TLSV1":   tls.VersionTLS10,
+	"TLSV1.1": tls.VersionTLS11,
+	"TLSV1.2": tls.VersionTLS12,
+	"TLSV1.3": tls.VersionTLS13,
+}
+
+// builds the configuration of the TLS connections from the tls-* settings.
+func loadTLSConfig() (*tls.Config, error) {
+	if config.TlsCertFile == "" || config.TlsKeyFile == "" {
+		return nil, fmt.Errorf("the TLS certificate and key files are required to accept TLS connections")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(config.TlsCertFile, config.TlsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch strings.ToLower(config.TlsAuthClients) {
+	case "yes":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case "no":
+		tlsConfig.ClientAuth = tls.NoClientCert
+	default:
+		return nil, fmt.Errorf("invalid tls-auth-clients %q, expected yes, optional or no", config.TlsAuthClients)
+	}
+
+	if tlsConfig.ClientAuth != tls.NoClientCert {
+		if config.TlsCaCertFile == "" {
+			return nil, fmt.Errorf("the TLS CA certificate file is required to authenticate the clients")
+		}
+		pem, err := os.ReadFile(config.TlsCaCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the TLS CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.TlsCaCertFile)
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	if config.TlsProtocols != "" {
+		tlsConfig.MinVersion, tlsConfig.MaxVersion, err = parseTLSProtocols(config.TlsProtocols)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.TlsCiphers != "" {
+		tlsConfig.CipherSuites, err = parseTLSCiphers(config.TlsCiphers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// returns the configuration the metrics are served with over HTTPS, nil if the server doesn't
+// accept TLS connections. They're served with the certificate of the server, but the scrapers
+// aren't asked for client certificates whatever tls-auth-clients is: the metrics hold no keys or
+// values, and are meant to be scraped by Prometheus rather than by the clients.
+func metricsTLSConfig(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+
+	metricsConfig := tlsConfig.Clone()
+	metricsConfig.ClientAuth = tls.NoClientCert
+	metricsConfig.ClientCAs = nil
+	return metricsConfig
+}
+
+// returns the lowest and the highest of the space separated TLS versions, eg. "TLSv1.2 TLSv1.3".
+func parseTLSProtocols(protocols string) (uint16, uint16, error) {
+	var min, max uint16
+	for _, name := range strings.Fields(protocols) {
+		version, ok := tlsVersions[strings.ToUpper(name)]
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid TLS protocol %q", name)
+		}
+		if min == 0 || version < min {
+			min = version
+		}
+		if version > max {
+			max = version
+		}
+	}
+	if min == 0 {
+		return 0, 0, fmt.Errorf("no TLS protocols in %q", protocols)
+	}
+	return min, max, nil
+}
+
+// returns the ids of the colon separated cipher suites. the insecure ones are accepted too, since
+// they have to be named explicitly.
+func parseTLSCiphers(ciphers string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites[suite.Name] = suite.ID
+	}
+
+	ids := []uint16{}
+	for _, name := range strings.Split(ciphers, ":") {
+		if name == "" {
+			continue
+		}
+		id, ok := suites[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no TLS cipher suites in %q", ciphers)
+	}
+	return ids, nil
+}
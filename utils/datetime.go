@@ -19,9 +19,9 @@ func FromExpiryInSeconds(expiryInSeconds int64) *ExpiryTime {
 	return &ExpiryTime{expireAtTimestamp: now}
 }
 
-// returns the ExpiryTime from the unix expiry timestamp.
-func FromExpiryInUnixTime(expiryInUnix int64) *ExpiryTime {
-	expireAt := time.Unix(expiryInUnix, 0)
+// returns the ExpiryTime from the unix expiry timestamp, in milliseconds.
+func FromExpiryInUnixMilli(expiryInUnixMilli int64) *ExpiryTime {
+	expireAt := time.UnixMilli(expiryInUnixMilli)
 	return &ExpiryTime{expireAtTimestamp: expireAt}
 }
 
@@ -58,9 +58,10 @@ func (et ExpiryTime) GetExpireAtTimestamp() time.Time {
 	return et.expireAtTimestamp
 }
 
-// returns the unix timestamp of the expirytime.
-func (et ExpiryTime) ToUnixTimestamp() int64 {
-	return et.expireAtTimestamp.Unix()
+// returns the unix timestamp of the expirytime, in milliseconds, so that the keys set with PX or
+// PEXPIRE expire on time.
+func (et ExpiryTime) ToUnixMilli() int64 {
+	return et.expireAtTimestamp.UnixMilli()
 }
 
 // the LRU-Time is represented as a 32-bit integer.
@@ -0,0 +1,104 @@
+package utils
+
+// GlobMatch reports whether str matches the glob-style pattern, following the
+// same rules as Redis's stringmatchlen:
+//   - '*' matches any sequence of characters, including an empty one.
+//   - '?' matches any single character.
+//   - '[abc]' matches one of the enclosed characters, '[^abc]' negates the set
+//     and '[a-z]' matches a range.
+//   - '\' escapes the character that follows it.
+func GlobMatch(pattern string, str string) bool {
+	p, s := 0, 0
+
+	// where to resume from once the pattern after the last star fails to match: the position in
+	// the pattern right after the star, and the position in the string the star stops at so far.
+	// Only the last star is ever backtracked to, so the matching takes at most the length of the
+	// pattern times the length of the string steps, instead of exponential time.
+	starP, starS := -1, 0
+
+	for s < len(str) {
+		if p < len(pattern) && pattern[p] == '*' {
+			p++
+			starP, starS = p, s
+			continue
+		}
+
+		if p < len(pattern) {
+			if next, matched := matchOne(pattern, p, str[s]); matched {
+				p = next
+				s++
+				continue
+			}
+		}
+
+		if starP == -1 {
+			return false
+		}
+		// the last star takes one more character.
+		starS++
+		p, s = starP, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matches c against the element of the pattern at p, which isn't a star. returns the index of the
+// element that follows it and whether c matches it.
+func matchOne(pattern string, p int, c byte) (int, bool) {
+	switch pattern[p] {
+	case '?':
+		return p + 1, true
+	case '[':
+		end, matched := matchCharClass(pattern, p+1, c)
+		return end + 1, matched
+	case '\\':
+		if p+1 < len(pattern) {
+			p++
+		}
+	}
+	return p + 1, pattern[p] == c
+}
+
+// matches c against the character class starting right after the '[' at pos.
+// returns the index of the closing ']' (or the last index of the pattern if the
+// class is unterminated) and whether c belongs to the class.
+func matchCharClass(pattern string, pos int, c byte) (int, bool) {
+	negate := false
+	if pos < len(pattern) && pattern[pos] == '^' {
+		negate = true
+		pos++
+	}
+
+	matched := false
+	for ; pos < len(pattern) && pattern[pos] != ']'; pos++ {
+		switch {
+		case pattern[pos] == '\\' && pos+1 < len(pattern):
+			pos++
+			if pattern[pos] == c {
+				matched = true
+			}
+		case pos+2 < len(pattern) && pattern[pos+1] == '-' && pattern[pos+2] != ']':
+			start, end := pattern[pos], pattern[pos+2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				matched = true
+			}
+			pos += 2
+		default:
+			if pattern[pos] == c {
+				matched = true
+			}
+		}
+	}
+
+	if pos >= len(pattern) {
+		pos = len(pattern) - 1
+	}
+
+	return pos, matched != negate
+}
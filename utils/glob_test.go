@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shashwatrathod/redis-internals/utils"
+)
+
+func TestUtils(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Utils Suite")
+}
+
+var _ = Describe("GlobMatch", func() {
+	DescribeTable("matching strings against glob-style patterns",
+		func(pattern string, str string, expected bool) {
+			Expect(utils.GlobMatch(pattern, str)).To(Equal(expected))
+		},
+		Entry("exact match", "hello", "hello", true),
+		Entry("exact mismatch", "hello", "hellO", false),
+		Entry("star matches everything", "*", "anything", true),
+		Entry("star matches empty", "h*llo", "hllo", true),
+		Entry("star in the middle", "h*llo", "heeeello", true),
+		Entry("question mark", "h?llo", "hallo", true),
+		Entry("question mark needs a character", "h?llo", "hllo", false),
+		Entry("character class", "h[ae]llo", "hello", true),
+		Entry("character class mismatch", "h[ae]llo", "hillo", false),
+		Entry("negated character class", "h[^e]llo", "hallo", true),
+		Entry("negated character class mismatch", "h[^e]llo", "hello", false),
+		Entry("character range", "h[a-c]llo", "hbllo", true),
+		Entry("character range mismatch", "h[a-c]llo", "hdllo", false),
+		Entry("escaped star", "h\\*llo", "h*llo", true),
+		Entry("escaped star is literal", "h\\*llo", "hello", false),
+		Entry("trailing characters", "news.*", "news", false),
+		Entry("prefix", "news.*", "news.tech", true),
+		Entry("several stars", "*a*b*c", "xaybzc", true),
+		Entry("star backtracks", "*ab", "aab", true),
+		Entry("star backtracks past partial matches", "a*bc", "abcbbc", true),
+		Entry("several stars mismatch", "*a*b*c", "xaybz", false),
+		Entry("trailing stars", "a**", "a", true),
+		Entry("star before a character class", "*[0-9]", "key9", true),
+		Entry("escaped character after a star", "*\\?", "what?", true),
+	)
+
+	It("doesn't backtrack exponentially on the patterns with many stars", func() {
+		str := strings.Repeat("a", 10000)
+		Expect(utils.GlobMatch(strings.Repeat("*a", 50)+"*b", str)).To(BeFalse())
+	})
+})